@@ -0,0 +1,315 @@
+package analyzer
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"go/token"
+	"go/types"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/haijima/analysisutil/ssautil"
+	sconeanalysis "github.com/haijima/scone/internal/analysis"
+	"github.com/haijima/scone/internal/sql"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/buildssa"
+	"golang.org/x/tools/go/ssa"
+)
+
+// QueryAnalyzer extracts the queries executed in the package.
+// Queries which could not be analyzed and queries which do not match the schema are reported as diagnostics.
+// Functions which execute queries are exported as QueriesFact, and wrapper functions of query functions as WrapperFact, so that other packages can use them.
+var QueryAnalyzer = &analysis.Analyzer{
+	Name:       "sconequery",
+	Doc:        "extract SQL queries and report the ones which can not be analyzed statically",
+	URL:        "https://github.com/haijima/scone",
+	Flags:      queryFlags(),
+	Run:        runQuery,
+	Requires:   []*analysis.Analyzer{buildssa.Analyzer},
+	ResultType: reflect.TypeOf((*Result)(nil)),
+	FactTypes:  []analysis.Fact{(*QueriesFact)(nil), (*WrapperFact)(nil)},
+}
+
+var (
+	dialectFlag      string
+	schemaFlag       string
+	filterFlag       string
+	analyzeFuncsFlag string
+)
+
+func queryFlags() flag.FlagSet {
+	fs := flag.NewFlagSet("sconequery", flag.ExitOnError)
+	fs.StringVar(&dialectFlag, "dialect", "mysql", "The SQL dialect of queries. One of: mysql|postgres|sqlite")
+	fs.StringVar(&schemaFlag, "schema", "", "The DDL file which defines tables (CREATE TABLE statements)")
+	fs.StringVar(&filterFlag, "filter", "", "filter queries by pattern")
+	fs.StringVar(&analyzeFuncsFlag, "analyze-funcs", "", "The comma separated names of functions to analyze additionally. format: <func pattern>@<argument index>")
+	return *fs
+}
+
+// Query is a query executed in the package.
+type Query struct {
+	Kind   string // SELECT, INSERT, UPDATE, DELETE or UNKNOWN
+	Raw    string
+	Tables []string
+	Func   *ssa.Function
+	Pos    token.Pos // the position of the call which executes the query
+
+	positions []token.Pos
+}
+
+// Result is the result of QueryAnalyzer.
+type Result struct {
+	Queries []*Query
+	// Summaries is the queries executed by the functions of the package and the imported functions called from the package.
+	Summaries map[*types.Func]*QueriesFact
+
+	result *sconeanalysis.Result
+}
+
+// QueriesAt returns the queries executed by the call at pos in fn.
+func (r *Result) QueriesAt(fn *ssa.Function, pos token.Pos) []*Query {
+	qs := make([]*Query, 0)
+	for _, q := range r.Queries {
+		if q.Func == fn && slices.Contains(q.positions, pos) {
+			qs = append(qs, q)
+		}
+	}
+	return qs
+}
+
+// IsIgnored returns true if pos is in the node commented by `scone:ignore` without rules or with the rule.
+func (r *Result) IsIgnored(rule string, fn *ssa.Function, pos token.Pos) bool {
+	return r.result != nil && r.result.IsIgnored(rule, ssautil.NewPos(fn, pos))
+}
+
+// QueriesFact is the summary of the queries which a function executes directly or through the calls of other functions.
+type QueriesFact struct {
+	Queries []FactQuery
+}
+
+// FactQuery is a query in QueriesFact.
+type FactQuery struct {
+	Kind   string
+	Raw    string
+	Tables []string
+}
+
+func (*QueriesFact) AFact() {}
+
+func (f *QueriesFact) String() string {
+	strs := make([]string, 0, len(f.Queries))
+	for _, q := range f.Queries {
+		strs = append(strs, strings.TrimSpace(q.Kind+" "+strings.Join(q.Tables, ",")))
+	}
+	return "queries(" + strings.Join(strs, "; ") + ")"
+}
+
+func (f *QueriesFact) merge(other *QueriesFact) bool {
+	changed := false
+	for _, q := range other.Queries {
+		if !slices.ContainsFunc(f.Queries, func(fq FactQuery) bool { return fq.Raw == q.Raw }) {
+			f.Queries = append(f.Queries, q)
+			changed = true
+		}
+	}
+	return changed
+}
+
+// WrapperFact marks a function which passes one of its parameters to a query function as a query.
+// The calls of the function in other packages are analyzed as queries.
+type WrapperFact struct {
+	NamePattern string
+	ArgIndex    int
+}
+
+func (*WrapperFact) AFact() {}
+
+func (f *WrapperFact) String() string {
+	return fmt.Sprintf("wrapper(%d)", f.ArgIndex)
+}
+
+func newOption() (*sconeanalysis.Option, error) {
+	dialect, err := sql.ParseDialect(dialectFlag)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := sconeanalysis.NewFilterExpr(filterFlag); err != nil {
+		return nil, err
+	}
+	funcs := make([]string, 0)
+	for _, f := range strings.Split(analyzeFuncsFlag, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			funcs = append(funcs, f)
+		}
+	}
+	opt := sconeanalysis.NewOption(filterFlag, funcs)
+	opt.Dialect = dialect
+	if schemaFlag != "" {
+		ddl, err := os.ReadFile(schemaFlag)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read schema file")
+		}
+		if opt.Schema, err = sql.ParseSchema(string(ddl)); err != nil {
+			return nil, err
+		}
+	}
+	return opt, nil
+}
+
+// queryPackages is the packages which provide the query functions. Packages which do not import them nor the packages having facts do not execute queries.
+var queryPackages = []string{"database/sql", "github.com/jmoiron/sqlx", "gorm.io/gorm", "github.com/jackc/pgx/v5"}
+
+func runQuery(pass *analysis.Pass) (any, error) {
+	ssaProg := pass.ResultOf[buildssa.Analyzer].(*buildssa.SSA)
+	opt, err := newOption()
+	if err != nil {
+		return nil, err
+	}
+	res := &Result{Queries: make([]*Query, 0), Summaries: make(map[*types.Func]*QueriesFact)}
+	facts := pass.AllObjectFacts()
+	if isQueryPackage(pass.Pkg.Path()) || (len(facts) == 0 && len(opt.AdditionalFuncs) == 0 && !importsQueryPackage(pass.Pkg)) {
+		return res, nil
+	}
+	// Wrapper functions in the dependencies
+	for _, f := range facts {
+		if w, ok := f.Fact.(*WrapperFact); ok {
+			opt.AdditionalFuncs = append(opt.AdditionalFuncs, fmt.Sprintf("%s@%d", w.NamePattern, w.ArgIndex))
+		}
+	}
+
+	result, err := sconeanalysis.AnalyzePackage(context.Background(), ssaProg, pass.Files, opt)
+	if err != nil {
+		return nil, err
+	}
+	res.result = result
+
+	direct := make(map[*ssa.Function]*QueriesFact)
+	for _, qr := range result.QueryResults {
+		i := slices.IndexFunc(qr.Posx.Pos, token.Pos.IsValid)
+		if i < 0 {
+			continue
+		}
+		for _, q := range qr.Queries() {
+			res.Queries = append(res.Queries, &Query{Kind: q.Kind.String(), Raw: q.Raw, Tables: q.Tables, Func: qr.Posx.Func, Pos: qr.Posx.Pos[i], positions: qr.Posx.Pos})
+			if len(q.SchemaErrors) > 0 {
+				pass.Report(analysis.Diagnostic{Pos: qr.Posx.Pos[i], Category: "schema-mismatch", Message: strings.Join(q.SchemaErrors, ", ")})
+			}
+			if qr.Posx.Func.Blocks != nil {
+				if direct[qr.Posx.Func] == nil {
+					direct[qr.Posx.Func] = &QueriesFact{}
+				}
+				direct[qr.Posx.Func].merge(&QueriesFact{Queries: []FactQuery{{Kind: q.Kind.String(), Raw: q.Raw, Tables: q.Tables}}})
+			}
+		}
+	}
+	for _, f := range result.ParseFailures {
+		i := slices.IndexFunc(f.Posx.Pos, token.Pos.IsValid)
+		if i < 0 {
+			continue
+		}
+		message := f.Message
+		if f.SQL != "" {
+			message += ": " + f.SQL
+		}
+		pass.Report(analysis.Diagnostic{Pos: f.Posx.Pos[i], Category: "parse-failure", Message: message, SuggestedFixes: ignoreFix(pass, f.Posx.Pos[i], "")})
+	}
+
+	funcs := srcFuncs(ssaProg)
+	summaries := summarize(pass, funcs, direct, res.Summaries)
+	for _, fn := range funcs {
+		obj, ok := fn.Object().(*types.Func)
+		if !ok || obj.Pkg() != pass.Pkg {
+			continue
+		}
+		if s, ok := summaries[fn]; ok {
+			pass.ExportObjectFact(obj, s)
+			res.Summaries[obj] = s
+		}
+		if t, ok := result.Wrappers[fn]; ok {
+			pass.ExportObjectFact(obj, &WrapperFact{NamePattern: t.NamePattern, ArgIndex: t.ArgIndex})
+		}
+	}
+	return res, nil
+}
+
+func importsQueryPackage(pkg *types.Package) bool {
+	return slices.ContainsFunc(pkg.Imports(), func(imp *types.Package) bool { return isQueryPackage(imp.Path()) })
+}
+
+func isQueryPackage(path string) bool {
+	return slices.ContainsFunc(queryPackages, func(p string) bool { return path == p || strings.HasPrefix(path, p+"/") })
+}
+
+// srcFuncs returns the source functions of the package including anonymous functions.
+func srcFuncs(ssaProg *buildssa.SSA) []*ssa.Function {
+	funcs := make([]*ssa.Function, 0, len(ssaProg.SrcFuncs))
+	var collect func(fn *ssa.Function)
+	collect = func(fn *ssa.Function) {
+		funcs = append(funcs, fn)
+		for _, anon := range fn.AnonFuncs {
+			collect(anon)
+		}
+	}
+	for _, fn := range ssaProg.SrcFuncs {
+		collect(fn)
+	}
+	return funcs
+}
+
+// summarize propagates the queries executed directly by the functions to their callers until no new query is found.
+// The summaries of the functions in other packages are imported from facts and stored in imported.
+func summarize(pass *analysis.Pass, funcs []*ssa.Function, direct map[*ssa.Function]*QueriesFact, imported map[*types.Func]*QueriesFact) map[*ssa.Function]*QueriesFact {
+	summaries := make(map[*ssa.Function]*QueriesFact)
+	for fn, s := range direct {
+		summaries[fn] = &QueriesFact{Queries: slices.Clone(s.Queries)}
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, fn := range funcs {
+			for _, block := range fn.Blocks {
+				for _, instr := range block.Instrs {
+					callCommon, ok := ssautil.InstrToCallCommon(instr)
+					if !ok || callCommon.StaticCallee() == nil {
+						continue
+					}
+					callee := callCommon.StaticCallee()
+					if callee.Origin() != nil {
+						callee = callee.Origin() // generic function
+					}
+					s, ok := summaries[callee]
+					if !ok {
+						s, ok = importSummary(pass, callee, imported)
+					}
+					if !ok || callee == fn {
+						continue
+					}
+					if summaries[fn] == nil {
+						summaries[fn] = &QueriesFact{}
+					}
+					changed = summaries[fn].merge(s) || changed
+				}
+			}
+		}
+	}
+	return summaries
+}
+
+func importSummary(pass *analysis.Pass, fn *ssa.Function, imported map[*types.Func]*QueriesFact) (*QueriesFact, bool) {
+	obj, ok := fn.Object().(*types.Func)
+	if !ok || obj.Pkg() == nil || obj.Pkg() == pass.Pkg {
+		return nil, false
+	}
+	if s, ok := imported[obj]; ok {
+		return s, true
+	}
+	s := &QueriesFact{}
+	if !pass.ImportObjectFact(obj, s) {
+		return nil, false
+	}
+	imported[obj] = s
+	return s, true
+}
@@ -0,0 +1,15 @@
+package analyzer
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestQueryAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), QueryAnalyzer, "a", "b")
+}
+
+func TestLoopAnalyzer(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(t, analysistest.TestData(), LoopAnalyzer, "loop")
+}
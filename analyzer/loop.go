@@ -0,0 +1,123 @@
+package analyzer
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"slices"
+	"strings"
+
+	"github.com/haijima/scone/internal/lint"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/buildssa"
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/ssa"
+)
+
+const loopRule = "query-in-loop"
+
+// LoopAnalyzer reports queries executed in for loops, directly or through the calls of functions which execute queries.
+// The functions in other packages are also checked by QueriesFact of QueryAnalyzer.
+var LoopAnalyzer = &analysis.Analyzer{
+	Name:     "sconeloop",
+	Doc:      "report queries executed in for loops, which cause N+1 problems",
+	URL:      "https://github.com/haijima/scone",
+	Run:      runLoop,
+	Requires: []*analysis.Analyzer{buildssa.Analyzer, QueryAnalyzer},
+}
+
+func runLoop(pass *analysis.Pass) (any, error) {
+	ssaProg := pass.ResultOf[buildssa.Analyzer].(*buildssa.SSA)
+	res := pass.ResultOf[QueryAnalyzer].(*Result)
+
+	for _, fn := range srcFuncs(ssaProg) {
+		for _, block := range fn.Blocks {
+			for _, instr := range block.Instrs {
+				call, ok := instr.(ssa.CallInstruction)
+				if !ok || !lint.InLoop(fn, call.Pos()) || res.IsIgnored(loopRule, fn, call.Pos()) {
+					continue
+				}
+				if qs := res.QueriesAt(fn, call.Pos()); len(qs) > 0 {
+					pass.Report(analysis.Diagnostic{
+						Pos:            call.Pos(),
+						Category:       loopRule,
+						Message:        fmt.Sprintf("%s query is executed in a loop", qs[0].Kind),
+						SuggestedFixes: ignoreFix(pass, call.Pos(), loopRule),
+					})
+					continue
+				}
+				callee := call.Common().StaticCallee()
+				if callee == nil {
+					continue
+				}
+				if callee.Origin() != nil {
+					callee = callee.Origin() // generic function
+				}
+				obj, ok := callee.Object().(*types.Func)
+				if !ok {
+					continue
+				}
+				if s, ok := res.Summaries[obj]; ok {
+					pass.Report(analysis.Diagnostic{
+						Pos:            call.Pos(),
+						Category:       loopRule,
+						Message:        fmt.Sprintf("%s which executes queries is called in a loop: %s", obj.Name(), summaryString(s)),
+						SuggestedFixes: ignoreFix(pass, call.Pos(), loopRule),
+					})
+				}
+			}
+		}
+	}
+	return nil, nil
+}
+
+func summaryString(s *QueriesFact) string {
+	strs := make([]string, 0, len(s.Queries))
+	for _, q := range s.Queries {
+		str := q.Kind
+		if len(q.Tables) > 0 {
+			str += " " + strings.Join(q.Tables, ", ")
+		}
+		if !slices.Contains(strs, str) {
+			strs = append(strs, str)
+		}
+	}
+	return strings.Join(strs, "; ")
+}
+
+// ignoreFix returns the fix which inserts `// scone:ignore <rule>` comment above the statement at pos.
+func ignoreFix(pass *analysis.Pass, pos token.Pos, rule string) []analysis.SuggestedFix {
+	var stmt ast.Stmt
+	for _, f := range pass.Files {
+		if f.FileStart <= pos && pos < f.FileEnd {
+			path, _ := astutil.PathEnclosingInterval(f, pos, pos)
+			for _, n := range path {
+				if s, ok := n.(ast.Stmt); ok {
+					stmt = s
+					break
+				}
+			}
+		}
+	}
+	if stmt == nil {
+		return nil
+	}
+	tf := pass.Fset.File(stmt.Pos())
+	position := tf.Position(stmt.Pos())
+	src, err := pass.ReadFile(position.Filename)
+	if err != nil || tf.Offset(stmt.Pos()) > len(src) {
+		return nil
+	}
+	lineStart := tf.LineStart(position.Line)
+	indent := src[tf.Offset(lineStart):tf.Offset(stmt.Pos())]
+	if len(bytes.TrimSpace(indent)) > 0 {
+		return nil // the statement does not start the line
+	}
+	comment := strings.TrimSpace("// scone:ignore " + rule)
+	return []analysis.SuggestedFix{{
+		Message:   fmt.Sprintf("Add %q comment", comment),
+		TextEdits: []analysis.TextEdit{{Pos: lineStart, End: lineStart, NewText: []byte(string(indent) + comment + "\n")}},
+	}}
+}
@@ -0,0 +1,27 @@
+package a
+
+import "database/sql"
+
+type Repo struct {
+	db *sql.DB
+}
+
+func (r *Repo) Query(q string, args ...any) (*sql.Rows, error) { // want Query:`wrapper\(0\)`
+	return r.db.Query(q, args...)
+}
+
+func (r *Repo) GetUser(id int) *sql.Row { // want GetUser:`queries\(SELECT users\)`
+	return r.db.QueryRow("SELECT id, name FROM users WHERE id = ?", id)
+}
+
+func (r *Repo) ListPosts(userID int) (*sql.Rows, error) { // want ListPosts:`queries\(SELECT posts\)`
+	return r.Query("SELECT id, title FROM posts WHERE user_id = ?", userID)
+}
+
+func (r *Repo) Search(name string) { // want Search:`queries\(UNKNOWN\)`
+	r.db.Query("SELECT id FROM users WHERE name MATCHES ?", name) // want `Failed to parse string as SQL: SELECT id FROM users WHERE name MATCHES \?`
+}
+
+func (r *Repo) TouchUser(id int) { // want TouchUser:`queries\(UPDATE users\)`
+	r.db.Exec("UPDATE users SET updated_at = NOW() WHERE id = ?", id)
+}
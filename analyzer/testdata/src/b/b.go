@@ -0,0 +1,16 @@
+package b
+
+import "a"
+
+func CountComments(r *a.Repo) { // want CountComments:`queries\(SELECT comments\)`
+	r.Query("SELECT COUNT(*) FROM comments")
+}
+
+func GetUserWithComments(r *a.Repo, id int) { // want GetUserWithComments:`queries\(SELECT users; SELECT comments\)`
+	r.GetUser(id)
+	CountComments(r)
+}
+
+func noQuery(n int) int {
+	return n * 2
+}
@@ -0,0 +1,34 @@
+package loop
+
+import (
+	"database/sql"
+
+	"a"
+	"b"
+)
+
+func TouchAll(db *sql.DB, ids []int) {
+	for _, id := range ids {
+		db.Exec("UPDATE users SET updated_at = NOW() WHERE id = ?", id) // want "UPDATE query is executed in a loop"
+	}
+}
+
+func Users(r *a.Repo, ids []int) {
+	for _, id := range ids {
+		r.GetUser(id) // want "GetUser which executes queries is called in a loop: SELECT users"
+	}
+}
+
+func Posts(r *a.Repo, ids []int) {
+	for _, id := range ids {
+		// scone:ignore query-in-loop
+		r.ListPosts(id)
+	}
+}
+
+func UsersWithComments(r *a.Repo, ids []int) {
+	for i := 0; i < len(ids); i++ {
+		b.GetUserWithComments(r, ids[i]) // want "GetUserWithComments which executes queries is called in a loop: SELECT users; SELECT comments"
+	}
+	r.GetUser(ids[0])
+}
@@ -0,0 +1,19 @@
+// scone-vet runs the analyzers of scone as a standalone command or a vet tool.
+//
+//	scone-vet ./...
+//	go vet -vettool=$(which scone-vet) ./...
+package main
+
+import (
+	"log/slog"
+	"os"
+
+	"github.com/haijima/scone/analyzer"
+	"golang.org/x/tools/go/analysis/multichecker"
+)
+
+func main() {
+	// Queries which can not be analyzed are reported as diagnostics instead of warning logs
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError})))
+	multichecker.Main(analyzer.QueryAnalyzer, analyzer.LoopAnalyzer)
+}
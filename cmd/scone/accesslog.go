@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/haijima/epf"
+	"github.com/haijima/scone/internal/accesslog"
+	"github.com/spf13/afero"
+	"github.com/spf13/viper"
+)
+
+// endpointTraffic is the number of requests of each endpoint counted from the access log.
+type endpointTraffic struct {
+	*accesslog.Traffic
+	requests map[*epf.Endpoint]int64
+}
+
+// loadAccessLog reads the file given by --access-log and counts the requests of the endpoints. It returns nil if the flag is not given.
+func loadAccessLog(v *viper.Viper, fs afero.Fs, endpoints []*epf.Endpoint) (*endpointTraffic, error) {
+	file := v.GetString("access-log")
+	if file == "" {
+		return nil, nil
+	}
+	f, err := fs.Open(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open access log")
+	}
+	defer f.Close()
+	entries, err := accesslog.Parse(f)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse access log")
+	}
+
+	routes := make([]*accesslog.Route, 0, len(endpoints))
+	byRoute := make(map[*accesslog.Route]*epf.Endpoint, len(endpoints))
+	for _, ep := range endpoints {
+		if ep.PathRegexpPattern == "" {
+			continue
+		}
+		r, err := accesslog.NewRoute(ep.Method, ep.Path, ep.PathRegexpPattern)
+		if err != nil {
+			return nil, err
+		}
+		routes = append(routes, r)
+		byRoute[r] = ep
+	}
+
+	t := &endpointTraffic{Traffic: accesslog.Count(entries, routes), requests: make(map[*epf.Endpoint]int64)}
+	for r, n := range t.Requests {
+		t.requests[byRoute[r]] = n
+	}
+	if t.Unmatched > 0 {
+		slog.Warn("Some requests in the access log are not routed to the endpoints", slog.Int64("requests", t.Unmatched), slog.Int("total", len(entries)))
+	}
+	return t, nil
+}
+
+// tableTraffic is the estimated number of reads and writes of a table.
+type tableTraffic struct {
+	Reads  int64
+	Writes int64
+}
+
+func (t *tableTraffic) String() string {
+	return fmt.Sprintf("R:%d W:%d", t.Reads, t.Writes)
+}
+
+// queriesPerRequest returns the number of queries issued by a request to the endpoint from the CRUD of the tables.
+// Queries in loops are counted once since the number of iterations is unknown.
+func queriesPerRequest(crud map[string]string) int64 {
+	var n int
+	for _, kind := range crud {
+		n += len(kind)
+	}
+	return int64(n)
+}
+
+// tableTraffics estimates the number of reads and writes of each table by multiplying the CRUD matrix and the number of requests.
+func tableTraffics(endpoints []*epf.Endpoint, crud map[*epf.Endpoint]map[string]string, t *endpointTraffic) map[string]*tableTraffic {
+	res := make(map[string]*tableTraffic)
+	for _, ep := range endpoints {
+		n := t.requests[ep]
+		for tbl, kind := range crud[ep] {
+			if res[tbl] == nil {
+				res[tbl] = &tableTraffic{}
+			}
+			reads := int64(strings.Count(kind, "R"))
+			res[tbl].Reads += n * reads
+			res[tbl].Writes += n * (int64(len(kind)) - reads)
+		}
+	}
+	return res
+}
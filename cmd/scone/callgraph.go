@@ -1,9 +1,15 @@
 package main
 
 import (
+	"cmp"
 	"fmt"
+	"go/types"
 	"io"
+	"path/filepath"
+	"slices"
+	"strings"
 
+	"github.com/cockroachdb/errors"
 	"github.com/haijima/scone/internal/analysis"
 	"github.com/haijima/scone/internal/dot"
 	"github.com/haijima/scone/internal/sql"
@@ -13,150 +19,522 @@ import (
 	"golang.org/x/exp/maps"
 )
 
-func NewCallgraphCommand(v *viper.Viper, _ afero.Fs) *cobra.Command {
+func NewCallgraphCommand(v *viper.Viper, fs afero.Fs) *cobra.Command {
 	cmd := &cobra.Command{}
 	cmd.Use = "callgraph"
 	cmd.Short = "Generate a call graph"
 	cmd.RunE = func(cmd *cobra.Command, _ []string) error {
-		return runCallgraph(cmd, v)
+		return runCallgraph(cmd, v, fs)
 	}
 
-	cmd.Flags().String("format", "dot", "The output format {dot|mermaid|text}")
+	cmd.Flags().String("format", "dot", "The output format {dot|mermaid|text|json|jsonl}")
+	cmd.Flags().StringSlice("focus-table", []string{}, "Show only the functions which query the `tables` directly or indirectly")
+	cmd.Flags().StringSlice("focus-func", []string{}, "Show only the callers and the callees of the `functions`. e.g. getUser, (*UserRepo).Get")
+	cmd.Flags().StringSlice("focus-endpoint", []string{}, "Show only the callees of the handlers of the `endpoints`. e.g. \"GET /users/{id}\", /users/{id}")
+	cmd.Flags().Int("depth", 0, "The max number of function calls from the focused nodes. 0 means no limit")
+	cmd.Flags().String("cluster-by", "", "Group the functions into the clusters in the dot format {package|file|receiver}")
+	cmd.Flags().Bool("collapse-pass-through", false, "Collapse the functions which are called by one function, call one function and execute no queries")
 
 	return cmd
 }
 
-func runCallgraph(cmd *cobra.Command, v *viper.Viper) error {
+func runCallgraph(cmd *cobra.Command, v *viper.Viper, fs afero.Fs) error {
 	dir := v.GetString("dir")
 	pattern := v.GetString("pattern")
-	filter := v.GetString("filter")
-	additionalFuncs := v.GetStringSlice("analyze-funcs")
+	format := v.GetString("format")
 
-	_, cgs, err := analysis.Analyze(cmd.Context(), dir, pattern, analysis.NewOption(filter, additionalFuncs))
+	clusterBy := v.GetString("cluster-by")
+
+	if !slices.Contains([]string{"dot", "mermaid", "text", "json", "jsonl"}, format) {
+		return errors.Newf("unknown format: %s", format)
+	}
+	if !slices.Contains([]string{"", "package", "file", "receiver"}, clusterBy) {
+		return errors.Newf("unknown cluster-by: %s", clusterBy)
+	}
+	opt, err := newOption(v, fs)
+	if err != nil {
+		return err
+	}
+	result, err := analysis.Analyze(cmd.Context(), dir, pattern, opt)
+	if err != nil {
+		return err
+	}
+	queryResults, cg := result.QueryResults, result.CallGraph
+
+	focus, err := focusNodes(v, cg)
+	if err != nil {
+		return err
+	}
+	if len(focus) > 0 {
+		cg = analysis.Slice(cg, focus, v.GetInt("depth"))
+	}
+	if v.GetBool("collapse-pass-through") {
+		cg = analysis.CollapsePassThrough(cg, focus)
+	}
+
+	switch format {
+	case "mermaid":
+		return printMermaid(cmd.OutOrStdout(), cg)
+	case "text":
+		return printCallgraphText(cmd.OutOrStdout(), cg)
+	case "json", "jsonl":
+		return printCallgraphJSON(cmd.OutOrStdout(), cg, dir, format)
+	default:
+		cacheability := make(map[string]sql.Cacheability)
+		for _, t := range queryResults.AllTables() {
+			cacheability[t.Name] = t.Cacheability()
+		}
+		return printGraphviz(cmd.OutOrStdout(), cg, clusterBy, cacheability)
+	}
+}
+
+// focusNodes returns the nodes selected by --focus-table, --focus-func and --focus-endpoint.
+func focusNodes(v *viper.Viper, cg *analysis.CallGraph) ([]*analysis.Node, error) {
+	focus := make([]*analysis.Node, 0)
+	for _, t := range v.GetStringSlice("focus-table") {
+		node, ok := cg.Nodes[t]
+		if !ok || !node.IsTable() {
+			return nil, errors.Newf("table not found in the call graph: %s", t)
+		}
+		focus = append(focus, node)
+	}
+
+	for _, f := range v.GetStringSlice("focus-func") {
+		found := false
+		for _, node := range sortedNodes(cg) {
+			if node.IsFunc() && (node.ID == f || node.Name == f || node.Func.Name() == f) {
+				focus = append(focus, node)
+				found = true
+			}
+		}
+		if !found {
+			return nil, errors.Newf("function not found in the call graph: %s", f)
+		}
+	}
+
+	focusEndpoints := v.GetStringSlice("focus-endpoint")
+	if len(focusEndpoints) == 0 {
+		return focus, nil
+	}
+	endpoints, err := findEndpoints(v.GetString("dir"), v.GetString("pattern"))
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range focusEndpoints {
+		found := false
+		for _, ep := range endpoints {
+			if ep.String() != e && ep.Path != e {
+				continue
+			}
+			if node, ok := endpointNode(cg, ep); ok {
+				focus = append(focus, node)
+				found = true
+			}
+		}
+		if !found {
+			return nil, errors.Newf("endpoint not found in the call graph: %s", e)
+		}
+	}
+	return focus, nil
+}
+
+// printCallgraphJSON writes the nodes and the edges of the call graph. Function nodes are identified by analysis.FuncID and table nodes by the table name.
+func printCallgraphJSON(w io.Writer, cg *analysis.CallGraph, dir, format string) error {
+	jw, err := newJSONWriter("callgraph", dir)
 	if err != nil {
 		return err
 	}
+	nodes := make(map[string]*jsonNode)
+	edges := make([]*jsonEdge, 0)
+	for _, node := range cg.Nodes {
+		id := node.ID
+		if node.IsTable() {
+			nodes[id] = &jsonNode{Type: "node", ID: id, Name: node.Name, Kind: "table"}
+			continue
+		}
+		nodes[id] = &jsonNode{Type: "node", ID: id, Name: node.Name, Kind: "func", PackagePath: nodePackage(node)}
+		for _, edge := range node.Out {
+			if edge.IsQuery() {
+				q := &sql.Query{Raw: edge.SqlValue.RawSQL}
+				edges = append(edges, &jsonEdge{Type: "edge", From: id, To: edge.Callee, Kind: "query", QueryKind: edge.SqlValue.Kind.String(), Hash: q.Hash(), Raw: q.Raw})
+			} else {
+				edges = append(edges, &jsonEdge{Type: "edge", From: id, To: edge.Callee, Kind: "call"})
+			}
+		}
+	}
+	ids := maps.Keys(nodes)
+	slices.Sort(ids)
+	for _, id := range ids {
+		jw.add(nodes[id])
+	}
+	slices.SortFunc(edges, func(a, b *jsonEdge) int {
+		return cmp.Or(strings.Compare(a.From, b.From), strings.Compare(a.To, b.To), strings.Compare(a.Kind, b.Kind), strings.Compare(a.Hash, b.Hash))
+	})
+	for _, e := range edges {
+		jw.add(e)
+	}
+	return jw.write(w, format)
+}
 
-	return printGraphviz(cmd.OutOrStdout(), cgs)
+// nodePackage returns the package path of the function node, or empty for table nodes and synthetic functions.
+func nodePackage(node *analysis.Node) string {
+	if node.Func == nil || node.Func.Pkg == nil {
+		return ""
+	}
+	return node.Func.Pkg.Pkg.Path()
 }
 
-func printGraphviz(w io.Writer, cgs map[string]*analysis.CallGraph) error {
+func printGraphviz(w io.Writer, cg *analysis.CallGraph, clusterBy string, cacheability map[string]sql.Cacheability) error {
 	g := &dot.Graph{Nodes: make([]*dot.Node, 0), Edges: make([]*dot.Edge, 0)}
+	if clusterBy != "" {
+		g.Clusters = graphvizClusters(cg, clusterBy, cacheability)
+	}
 
-	for pkg, cg := range cgs {
-		for _, node := range cg.Nodes {
-			// Print edges
-			for _, edge := range node.Out {
-				if edge.SqlValue != nil {
-					attrs := make(dot.Attrs)
-					attrs["weight"] = "100"
-					switch edge.SqlValue.Kind {
-					case sql.Select:
-						attrs["style"] = "dotted"
-					case sql.Insert:
-						attrs["color"] = "green"
-					case sql.Update:
-						attrs["color"] = "orange"
-					case sql.Delete:
-						attrs["color"] = "red"
-					default:
-					}
-					g.Edges = append(g.Edges, &dot.Edge{From: fmt.Sprintf("%s.%s", pkg, edge.Caller), To: edge.Callee, Attrs: attrs})
-					g.Nodes = append(g.Nodes, &dot.Node{ID: fmt.Sprintf("%s.%s", pkg, edge.Caller), Attrs: map[string]string{"label": edge.Caller}})
-				} else {
-					attrs := make(dot.Attrs)
-					attrs["style"] = "dashed"
-					attrs["weight"] = "100"
-					g.Edges = append(g.Edges, &dot.Edge{From: fmt.Sprintf("%s.%s", pkg, edge.Caller), To: fmt.Sprintf("%s.%s", pkg, edge.Callee), Attrs: attrs})
-					g.Nodes = append(g.Nodes, &dot.Node{ID: fmt.Sprintf("%s.%s", pkg, edge.Caller), Attrs: map[string]string{"label": edge.Caller}})
-					g.Nodes = append(g.Nodes, &dot.Node{ID: fmt.Sprintf("%s.%s", pkg, edge.Callee), Attrs: map[string]string{"label": edge.Callee}})
+	nodes := sortedNodes(cg)
+	for _, node := range nodes {
+		// Print edges
+		for _, edge := range sortedEdges(node) {
+			if edge.SqlValue != nil {
+				attrs := make(dot.Attrs)
+				attrs["weight"] = "100"
+				switch edge.SqlValue.Kind {
+				case sql.Select:
+					attrs["style"] = "dotted"
+				case sql.Insert:
+					attrs["color"] = "green"
+				case sql.Update:
+					attrs["color"] = "orange"
+				case sql.Delete:
+					attrs["color"] = "red"
+				default:
 				}
+				g.Edges = append(g.Edges, &dot.Edge{From: edge.Caller, To: edge.Callee, Attrs: attrs})
+				g.Nodes = append(g.Nodes, &dot.Node{ID: edge.Caller, Attrs: map[string]string{"label": node.Name}})
+			} else {
+				attrs := make(dot.Attrs)
+				attrs["style"] = "dashed"
+				attrs["weight"] = "100"
+				g.Edges = append(g.Edges, &dot.Edge{From: edge.Caller, To: edge.Callee, Attrs: attrs})
+				g.Nodes = append(g.Nodes, &dot.Node{ID: edge.Caller, Attrs: map[string]string{"label": node.Name}})
+				g.Nodes = append(g.Nodes, &dot.Node{ID: edge.Callee, Attrs: map[string]string{"label": cg.Nodes[edge.Callee].Name}})
 			}
 		}
-
-		fmt.Fprintln(w)
 	}
 
 	fmt.Fprintln(w)
 
 	// Print cacheable func and table node styles
-	selectOnlyNodes := make(map[string]sql.QueryKind)
-	for pkg, cg := range cgs {
-		for _, node := range analysis.TopologicalSort(cg.Nodes) {
-			// table node
-			if node.Func == nil {
-				kind := sql.Select
-				for _, cg2 := range cgs {
-					if n, ok := cg2.Nodes[node.Name]; ok {
-						for _, q := range n.In {
-							if q.SqlValue != nil {
-								kind = max(kind, q.SqlValue.Kind)
-							}
-						}
-					}
+	kinds := nodeKinds(cg)
+	for _, node := range nodes {
+		k, ok := kinds[node.ID]
+		if !ok {
+			continue
+		}
+		attr := make(dot.Attrs)
+		if k == sql.Select {
+			attr["color"] = "blue"
+			attr["fillcolor"] = "lightblue1"
+		} else if k == sql.Insert {
+			attr["color"] = "green"
+			attr["fillcolor"] = "darkolivegreen1"
+		} else if k == sql.Update {
+			attr["color"] = "orange"
+		} else if k == sql.Delete {
+			attr["color"] = "red"
+		}
+		if node.IsTable() {
+			attr["style"] = "bold"
+			attr["shape"] = "box"
+		}
+		g.Nodes = append(g.Nodes, &dot.Node{ID: node.ID, Attrs: attr})
+	}
+
+	fmt.Fprintln(w)
+
+	// Print node positions. Ranks are not used with the clusters since the nodes in rank sets are moved out of the clusters
+	if clusterBy != "" {
+		return dot.WriteGraph(w, *g)
+	}
+	minNodeNames := make([]string, 0)
+	maxNodeNames := make([]string, 0)
+	for _, node := range nodes {
+		if node.IsTable() {
+			maxNodeNames = append(maxNodeNames, node.ID)
+		} else if node.IsRoot() {
+			minNodeNames = append(minNodeNames, node.ID)
+		}
+	}
+	g.Ranks = append(g.Ranks, &dot.Rank{Name: "min", Nodes: minNodeNames})
+	g.Ranks = append(g.Ranks, &dot.Rank{Name: "max", Nodes: maxNodeNames})
+
+	return dot.WriteGraph(w, *g)
+}
+
+// cacheabilityColors are the border and the fill colors of the table clusters of each cacheability, following sql.Cacheability.Color
+var cacheabilityColors = map[sql.Cacheability][2]string{
+	sql.Static:              {"blue", "aliceblue"},
+	sql.Immutable:           {"green", "honeydew"},
+	sql.Mutable:             {"red", "mistyrose"},
+	sql.UnknownCacheability: {"gray", "gray95"},
+}
+
+// graphvizClusters groups the function nodes into the nested clusters of the packages and the files or the receiver types,
+// and the table nodes into the cluster of the tables which is divided by the cacheability.
+func graphvizClusters(cg *analysis.CallGraph, clusterBy string, cacheability map[string]sql.Cacheability) map[string]*dot.Cluster {
+	clusters := make(map[string]*dot.Cluster)
+	for _, node := range sortedNodes(cg) {
+		n := &dot.Node{ID: node.ID, Attrs: dot.Attrs{"label": node.Name}}
+		if node.IsTable() {
+			tables := subcluster(clusters, "tables", "tables")
+			c, ok := cacheability[node.ID]
+			if !ok {
+				c = sql.UnknownCacheability
+			}
+			cluster := subcluster(tables.Clusters, "tables/"+c.String(), c.String())
+			cluster.Attrs["style"] = "filled"
+			cluster.Attrs["color"] = cacheabilityColors[c][0]
+			cluster.Attrs["fillcolor"] = cacheabilityColors[c][1]
+			cluster.Nodes = append(cluster.Nodes, n)
+			continue
+		}
+
+		pkg := nodePackage(node)
+		if pkg == "" {
+			continue // synthetic functions
+		}
+		cluster := subcluster(clusters, "package/"+pkg, pkg)
+		fn := node.Func
+		for fn.Parent() != nil {
+			fn = fn.Parent() // anonymous functions belong to the enclosing function
+		}
+		switch clusterBy {
+		case "file":
+			if pos := fn.Prog.Fset.Position(fn.Pos()); pos.IsValid() {
+				file := filepath.Base(pos.Filename)
+				cluster = subcluster(cluster.Clusters, "file/"+pkg+"/"+file, file)
+			}
+		case "receiver":
+			if recv := fn.Signature.Recv(); recv != nil {
+				t := recv.Type()
+				if ptr, ok := t.(*types.Pointer); ok {
+					t = ptr.Elem() // methods of T and *T are grouped together
 				}
-				selectOnlyNodes[node.Name] = kind
-				continue
+				name := types.TypeString(t, types.RelativeTo(fn.Pkg.Pkg))
+				cluster = subcluster(cluster.Clusters, "receiver/"+pkg+"."+name, name)
 			}
-			// func node
-			selectOnly := true
-			kind := sql.Unknown
-			for _, edge := range node.Out {
-				if edge.SqlValue != nil {
-					selectOnly = selectOnly && edge.SqlValue.Kind == sql.Select
-				} else {
-					_, ok := selectOnlyNodes[edge.Callee]
-					selectOnly = selectOnly && ok
+		}
+		cluster.Nodes = append(cluster.Nodes, n)
+	}
+	return clusters
+}
+
+// subcluster returns the cluster of the id in clusters. The cluster is created if not exists.
+func subcluster(clusters map[string]*dot.Cluster, id, label string) *dot.Cluster {
+	if c, ok := clusters[id]; ok {
+		return c
+	}
+	c := &dot.Cluster{ID: id, Clusters: make(map[string]*dot.Cluster), Nodes: make([]*dot.Node, 0), Attrs: dot.Attrs{"label": label}}
+	clusters[id] = c
+	return c
+}
+
+func nodeKinds(cg *analysis.CallGraph) map[string]sql.QueryKind {
+	kinds := make(map[string]sql.QueryKind)
+	for _, node := range analysis.TopologicalSort(cg.Nodes) {
+		// table node
+		if node.IsTable() {
+			kind := sql.Select
+			for _, q := range node.In {
+				if q.SqlValue != nil {
+					kind = max(kind, q.SqlValue.Kind)
 				}
-				kind = max(kind, selectOnlyNodes[edge.Callee])
 			}
-			if selectOnly && kind != sql.Unknown {
-				selectOnlyNodes[node.Name] = kind
+			kinds[node.ID] = kind
+			continue
+		}
+		// func node
+		selectOnly := true
+		kind := sql.Unknown
+		for _, edge := range node.Out {
+			if edge.SqlValue != nil {
+				selectOnly = selectOnly && edge.SqlValue.Kind == sql.Select
+			} else {
+				_, ok := kinds[edge.Callee]
+				selectOnly = selectOnly && ok
 			}
+			kind = max(kind, kinds[edge.Callee])
+		}
+		if selectOnly && kind != sql.Unknown {
+			kinds[node.ID] = kind
 		}
+	}
+	return kinds
+}
 
-		for n, k := range selectOnlyNodes {
-			name := fmt.Sprintf("%s.%s", pkg, n)
-			attr := make(dot.Attrs)
-			if k == sql.Select {
-				attr["color"] = "blue"
-				attr["fillcolor"] = "lightblue1"
-			} else if k == sql.Insert {
-				attr["color"] = "green"
-				attr["fillcolor"] = "darkolivegreen1"
-			} else if k == sql.Update {
-				attr["color"] = "orange"
-			} else if k == sql.Delete {
-				attr["color"] = "red"
+// sortedEdges returns the outgoing edges of the node. Function calls come first, and then queries.
+func sortedEdges(node *analysis.Node) []*analysis.Edge {
+	edges := slices.Clone(node.Out)
+	slices.SortFunc(edges, func(a, b *analysis.Edge) int {
+		if a.IsQuery() != b.IsQuery() {
+			if a.IsFuncCall() {
+				return -1
 			}
-			if cg.Nodes[n].Func == nil {
-				name = n
-				attr["style"] = "bold"
-				attr["shape"] = "box"
+			return 1
+		}
+		if c := strings.Compare(a.Callee, b.Callee); c != 0 || a.IsFuncCall() {
+			return c
+		}
+		return cmp.Compare(a.SqlValue.Kind, b.SqlValue.Kind)
+	})
+	return edges
+}
+
+// sortedNodes returns the nodes of the call graph ordered by the package path and the name. Table nodes come last.
+func sortedNodes(cg *analysis.CallGraph) []*analysis.Node {
+	nodes := maps.Values(cg.Nodes)
+	slices.SortFunc(nodes, func(a, b *analysis.Node) int {
+		if a.IsTable() != b.IsTable() {
+			if a.IsFunc() {
+				return -1
 			}
-			g.Nodes = append(g.Nodes, &dot.Node{ID: name, Attrs: attr})
+			return 1
 		}
+		return cmp.Or(strings.Compare(nodePackage(a), nodePackage(b)), strings.Compare(a.Name, b.Name), strings.Compare(a.ID, b.ID))
+	})
+	return nodes
+}
 
-		// Reset
-		selectOnlyNodes = make(map[string]sql.QueryKind)
+// printMermaid writes the call graph as a Mermaid flowchart.
+// Edges of SELECT queries are dotted and the others are colored by the kind like the Graphviz output. Tables are drawn as cylinders.
+func printMermaid(w io.Writer, cg *analysis.CallGraph) error {
+	nodes := sortedNodes(cg)
+	ids := make(map[string]string) // node ID -> Mermaid node id
+	var funcs, tables int
+	fmt.Fprintln(w, "flowchart LR")
+	for _, node := range nodes {
+		if node.IsTable() {
+			ids[node.ID] = fmt.Sprintf("t%d", tables)
+			tables++
+			fmt.Fprintf(w, "    %s[(\"%s\")]\n", ids[node.ID], mermaidEscape(node.Name))
+		} else {
+			ids[node.ID] = fmt.Sprintf("f%d", funcs)
+			funcs++
+			fmt.Fprintf(w, "    %s[\"%s\"]\n", ids[node.ID], mermaidEscape(node.Name))
+		}
 	}
 
-	fmt.Fprintln(w)
-
-	// Print node positions
-	minNodeNames := make(map[string]bool)
-	maxNodeNames := make(map[string]bool)
-	for pkg, cg := range cgs {
-		for _, node := range cg.Nodes {
-			if node.Func == nil {
-				maxNodeNames[node.Name] = true
-			} else if len(node.In) == 0 {
-				minNodeNames[fmt.Sprintf("%s.%s", pkg, node.Name)] = true
+	linkStyles := make(map[sql.QueryKind][]string)
+	var n int
+	for _, node := range nodes {
+		for _, edge := range sortedEdges(node) {
+			switch {
+			case edge.IsFuncCall():
+				fmt.Fprintf(w, "    %s --> %s\n", ids[edge.Caller], ids[edge.Callee])
+			case edge.SqlValue.Kind == sql.Select:
+				fmt.Fprintf(w, "    %s -.-> %s\n", ids[edge.Caller], ids[edge.Callee])
+			default:
+				fmt.Fprintf(w, "    %s --> %s\n", ids[edge.Caller], ids[edge.Callee])
+				linkStyles[edge.SqlValue.Kind] = append(linkStyles[edge.SqlValue.Kind], fmt.Sprint(n))
 			}
+			n++
+		}
+	}
+	for _, k := range []sql.QueryKind{sql.Insert, sql.Update, sql.Delete} {
+		if len(linkStyles[k]) > 0 {
+			fmt.Fprintf(w, "    linkStyle %s stroke:%s\n", strings.Join(linkStyles[k], ","), mermaidColors[k])
 		}
 	}
-	g.Ranks = append(g.Ranks, &dot.Rank{Name: "min", Nodes: maps.Keys(minNodeNames)})
-	g.Ranks = append(g.Ranks, &dot.Rank{Name: "max", Nodes: maps.Keys(maxNodeNames)})
 
-	return dot.WriteGraph(w, *g)
+	classes := make(map[sql.QueryKind][]string)
+	for id, k := range nodeKinds(cg) {
+		if _, ok := mermaidColors[k]; ok {
+			classes[k] = append(classes[k], ids[id])
+		}
+	}
+	for _, k := range []sql.QueryKind{sql.Select, sql.Insert, sql.Update, sql.Delete} {
+		fill := ""
+		switch k {
+		case sql.Select:
+			fill = ",fill:lightblue"
+		case sql.Insert:
+			fill = ",fill:#caff70"
+		}
+		fmt.Fprintf(w, "    classDef %s stroke:%s%s\n", strings.ToLower(k.String()), mermaidColors[k], fill)
+		if len(classes[k]) > 0 {
+			slices.Sort(classes[k])
+			fmt.Fprintf(w, "    class %s %s\n", strings.Join(classes[k], ","), strings.ToLower(k.String()))
+		}
+	}
+	for _, node := range nodes {
+		if node.IsTable() {
+			fmt.Fprintf(w, "    style %s stroke-width:3px\n", ids[node.ID])
+		}
+	}
+	return nil
+}
+
+// calleeName returns the name of the callee relative to the package of the caller. e.g. "getComments", "(*example.com/app/repo.UserRepo).Get"
+func calleeName(caller, callee *analysis.Node) string {
+	if caller.Func == nil || caller.Func.Pkg == nil || callee.Func == nil || callee.Func.Signature == nil {
+		return callee.Name
+	}
+	return callee.Func.RelString(caller.Func.Pkg.Pkg)
+}
+
+var mermaidColors = map[sql.QueryKind]string{sql.Select: "blue", sql.Insert: "green", sql.Update: "orange", sql.Delete: "red"}
+
+// mermaidEscape escapes the characters which can not be written in a quoted Mermaid label.
+func mermaidEscape(s string) string {
+	return strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;").Replace(s)
+}
+
+// printCallgraphText writes the call graph as indented trees from the root functions (e.g. HTTP handlers) down to the tables.
+// The root functions are grouped by the package.
+// A function which calls itself directly or indirectly is marked as "(recursive)" and not expanded again.
+func printCallgraphText(w io.Writer, cg *analysis.CallGraph) error {
+	rootsByPkg := make(map[string][]*analysis.Node)
+	for _, node := range sortedNodes(cg) {
+		if node.IsFunc() && node.IsRoot() {
+			rootsByPkg[nodePackage(node)] = append(rootsByPkg[nodePackage(node)], node)
+		}
+	}
+	pkgs := maps.Keys(rootsByPkg)
+	slices.Sort(pkgs)
+
+	path := make(map[string]bool)
+	var visit func(node *analysis.Node, indent string)
+	visit = func(node *analysis.Node, indent string) {
+		path[node.ID] = true
+		defer delete(path, node.ID)
+		edges := sortedEdges(node)
+		for i, edge := range edges {
+			branch, next := "├── ", "│   "
+			if i == len(edges)-1 {
+				branch, next = "└── ", "    "
+			}
+			callee := cg.Nodes[edge.Callee]
+			switch {
+			case edge.IsQuery():
+				fmt.Fprintf(w, "%s%s%s [%s]\n", indent, branch, callee.Name, edge.SqlValue.Kind)
+			case path[edge.Callee]:
+				fmt.Fprintf(w, "%s%s%s (recursive)\n", indent, branch, calleeName(node, callee))
+			default:
+				fmt.Fprintf(w, "%s%s%s\n", indent, branch, calleeName(node, callee))
+				visit(callee, indent+next)
+			}
+		}
+	}
+	for _, pkg := range pkgs {
+		fmt.Fprintln(w, pkg)
+		roots := rootsByPkg[pkg]
+		for i, root := range roots {
+			branch, next := "├── ", "│   "
+			if i == len(roots)-1 {
+				branch, next = "└── ", "    "
+			}
+			fmt.Fprintf(w, "%s%s\n", branch, root.Name)
+			visit(root, next)
+		}
+	}
+	return nil
 }
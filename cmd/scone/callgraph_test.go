@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/sebdah/goldie/v2"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_runCallgraph(t *testing.T) {
+	tests := []string{"mermaid", "text"}
+	for _, tt := range tests {
+		t.Run(tt, func(t *testing.T) {
+			t.Parallel()
+			cmd := &cobra.Command{}
+			cmd.SetContext(context.Background())
+			buf := &bytes.Buffer{}
+			cmd.SetOut(buf)
+			cmd.SetErr(io.Discard)
+			v := viper.New()
+			v.Set("dir", "./testdata/src/lint")
+			v.Set("pattern", "./...")
+			v.Set("format", tt)
+
+			err := runCallgraph(cmd, v, afero.NewOsFs())
+			assert.NoError(t, err)
+
+			g := goldie.New(t)
+			g.Assert(t, "lint.callgraph-"+tt, buf.Bytes())
+		})
+	}
+}
+
+func Test_runCallgraph_unknownFormat(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.SetContext(context.Background())
+	v := viper.New()
+	v.Set("format", "svg")
+
+	err := runCallgraph(cmd, v, afero.NewOsFs())
+	assert.EqualError(t, err, "unknown format: svg")
+}
+
+func Test_runCallgraph_algorithm(t *testing.T) {
+	tests := []string{"static", "cha", "rta", "vta"}
+	for _, tt := range tests {
+		t.Run(tt, func(t *testing.T) {
+			t.Parallel()
+			cmd := &cobra.Command{}
+			cmd.SetContext(context.Background())
+			buf := &bytes.Buffer{}
+			cmd.SetOut(buf)
+			cmd.SetErr(io.Discard)
+			v := viper.New()
+			v.Set("dir", "./testdata/src/iface")
+			v.Set("pattern", "./...")
+			v.Set("format", "text")
+			v.Set("callgraph", tt)
+
+			err := runCallgraph(cmd, v, afero.NewOsFs())
+			assert.NoError(t, err)
+
+			g := goldie.New(t)
+			g.Assert(t, "iface.callgraph-"+tt, buf.Bytes())
+		})
+	}
+}
+
+func Test_runCallgraph_unknownAlgorithm(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.SetContext(context.Background())
+	v := viper.New()
+	v.Set("format", "text")
+	v.Set("callgraph", "pointer")
+
+	err := runCallgraph(cmd, v, afero.NewOsFs())
+	assert.EqualError(t, err, "unknown callgraph algorithm: pointer")
+}
+
+func Test_runCallgraph_focus(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags map[string]any
+	}{
+		{name: "table", flags: map[string]any{"focus-table": []string{"users"}}},
+		{name: "table-collapse", flags: map[string]any{"focus-table": []string{"users"}, "collapse-pass-through": true}},
+		{name: "func", flags: map[string]any{"focus-func": []string{"createOrder"}}},
+		{name: "endpoint-depth", flags: map[string]any{"focus-endpoint": []string{"POST /orders"}, "depth": 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cmd := &cobra.Command{}
+			cmd.SetContext(context.Background())
+			buf := &bytes.Buffer{}
+			cmd.SetOut(buf)
+			cmd.SetErr(io.Discard)
+			v := viper.New()
+			v.Set("dir", "./testdata/src/layered")
+			v.Set("pattern", "./...")
+			v.Set("format", "text")
+			for k, val := range tt.flags {
+				v.Set(k, val)
+			}
+
+			err := runCallgraph(cmd, v, afero.NewOsFs())
+			assert.NoError(t, err)
+
+			g := goldie.New(t)
+			g.Assert(t, "layered.callgraph-focus-"+tt.name, buf.Bytes())
+		})
+	}
+}
+
+func Test_runCallgraph_focusNotFound(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.SetContext(context.Background())
+	cmd.SetErr(io.Discard)
+	v := viper.New()
+	v.Set("dir", "./testdata/src/layered")
+	v.Set("pattern", "./...")
+	v.Set("format", "text")
+	v.Set("focus-table", []string{"items"})
+
+	err := runCallgraph(cmd, v, afero.NewOsFs())
+	assert.EqualError(t, err, "table not found in the call graph: items")
+}
+
+func Test_runCallgraph_cluster(t *testing.T) {
+	tests := []struct {
+		src       string
+		clusterBy string
+		callgraph string
+	}{
+		{src: "lint", clusterBy: "package", callgraph: "static"},
+		{src: "layered", clusterBy: "file", callgraph: "static"},
+		{src: "iface", clusterBy: "receiver", callgraph: "vta"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.clusterBy, func(t *testing.T) {
+			t.Parallel()
+			cmd := &cobra.Command{}
+			cmd.SetContext(context.Background())
+			buf := &bytes.Buffer{}
+			cmd.SetOut(buf)
+			cmd.SetErr(io.Discard)
+			v := viper.New()
+			v.Set("dir", "./testdata/src/"+tt.src)
+			v.Set("pattern", "./...")
+			v.Set("format", "dot")
+			v.Set("cluster-by", tt.clusterBy)
+			v.Set("callgraph", tt.callgraph)
+
+			err := runCallgraph(cmd, v, afero.NewOsFs())
+			assert.NoError(t, err)
+
+			g := goldie.New(t)
+			g.Assert(t, tt.src+".callgraph-cluster-"+tt.clusterBy, buf.Bytes())
+		})
+	}
+}
+
+func Test_runCallgraph_unknownClusterBy(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.SetContext(context.Background())
+	v := viper.New()
+	v.Set("format", "dot")
+	v.Set("cluster-by", "module")
+
+	err := runCallgraph(cmd, v, afero.NewOsFs())
+	assert.EqualError(t, err, "unknown cluster-by: module")
+}
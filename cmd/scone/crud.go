@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"io"
 	"slices"
 	"strings"
@@ -14,75 +15,204 @@ import (
 	"github.com/spf13/viper"
 )
 
-func NewCrudCmd(v *viper.Viper, _ afero.Fs) *cobra.Command {
+func NewCrudCmd(v *viper.Viper, fs afero.Fs) *cobra.Command {
 	cmd := &cobra.Command{}
 	cmd.Use = "crud"
 	cmd.Short = "Show the CRUD operations for each endpoint"
 	cmd.Args = cobra.NoArgs
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
-		return runCrud(cmd, v)
+		return runCrud(cmd, v, fs)
 	}
 
-	cmd.Flags().String("format", "table", "The output format {table|md|csv|tsv|html|simple}")
+	cmd.Flags().String("format", "table", formatUsage())
+	cmd.Flags().String("access-log", "", "The `file` of nginx access log in LTSV or JSON to estimate the number of queries from the requests")
+	addStatsFlags(cmd)
+	_ = cmd.MarkFlagFilename("access-log")
 
 	return cmd
 }
 
-func runCrud(cmd *cobra.Command, v *viper.Viper) error {
+func runCrud(cmd *cobra.Command, v *viper.Viper, fs afero.Fs) error {
 	dir := v.GetString("dir")
 	pattern := v.GetString("pattern")
-	filter := v.GetString("filter")
 	format := v.GetString("format")
-	additionalFuncs := v.GetStringSlice("analyze-funcs")
 
-	_, cgs, err := analysis.Analyze(cmd.Context(), dir, pattern, analysis.NewOption(filter, additionalFuncs))
+	if err := validateFormat(format); err != nil {
+		return err
+	}
+	opt, err := newOption(v, fs)
+	if err != nil {
+		return err
+	}
+	ss, err := loadStats(v, fs)
 	if err != nil {
 		return err
 	}
+	result, err := analysis.Analyze(cmd.Context(), dir, pattern, opt)
+	if err != nil {
+		return err
+	}
+	queryResults, cg := result.QueryResults, result.CallGraph
 
-	ext, err := epf.AutoExtractor(dir, pattern)
+	endpoints, err := findEndpoints(dir, pattern)
 	if err != nil {
 		return err
 	}
-	endpoints, err := epf.FindEndpoints(dir, pattern, ext)
+	traffic, err := loadAccessLog(v, fs, endpoints)
 	if err != nil {
 		return err
 	}
 
-	printCrud(cmd.OutOrStdout(), endpoints, cgs, format)
-	return nil
+	return printCrud(cmd.OutOrStdout(), endpoints, cg, dir, format, tableStats(queryResults, ss), traffic)
+}
+
+func findEndpoints(dir, pattern string) ([]*epf.Endpoint, error) {
+	ext, err := epf.AutoExtractor(dir, pattern)
+	if err != nil {
+		return nil, err
+	}
+	return epf.FindEndpoints(dir, pattern, ext)
+}
+
+// endpointNode returns the node of the handler function of the endpoint.
+// The handler is identified by its function found by epf. Otherwise, it is found by the name, preferring the package which registers the endpoint.
+func endpointNode(cg *analysis.CallGraph, ep *epf.Endpoint) (*analysis.Node, bool) {
+	var pkg string
+	if ep.DeclarePos != nil && ep.DeclarePos.Func != nil {
+		if ep.DeclarePos.Func.Name() == ep.FuncName {
+			if node, ok := cg.Lookup(ep.DeclarePos.Func); ok {
+				return node, true
+			}
+		}
+		if ep.DeclarePos.Func.Pkg != nil {
+			pkg = ep.DeclarePos.Func.Pkg.Pkg.Path()
+		}
+	}
+	name := strings.TrimSuffix(ep.FuncName, "$bound") // method values
+	var found *analysis.Node
+	for _, node := range cg.Nodes {
+		if !node.IsFunc() || node.Func.Name() != name {
+			continue
+		}
+		if found == nil {
+			found = node
+		} else if samePkg, foundSamePkg := nodePackage(node) == pkg, nodePackage(found) == pkg; samePkg != foundSamePkg {
+			if samePkg {
+				found = node
+			}
+		} else if node.ID < found.ID {
+			found = node
+		}
+	}
+	return found, found != nil
 }
 
-func printCrud(w io.Writer, endpoints []*epf.Endpoint, cgs map[string]*analysis.CallGraph, format string) {
-	crud := make(map[string]map[string]string)
+// crudMatrix returns the operations of each endpoint for each table. e.g. crud[ep]["users"] = "RR"
+func crudMatrix(endpoints []*epf.Endpoint, cg *analysis.CallGraph) map[*epf.Endpoint]map[string]string {
+	crud := make(map[*epf.Endpoint]map[string]string)
 	for _, ep := range endpoints {
-		for _, cg := range cgs {
-			for _, node := range cg.Nodes {
-				if node.Name == ep.FuncName {
-					crud[ep.FuncName] = make(map[string]string)
-					for _, c := range search(cg, node) {
-						crud[ep.FuncName][c.Table] += c.Kind.CRUD()
-					}
+		if node, ok := endpointNode(cg, ep); ok {
+			crud[ep] = make(map[string]string)
+			for _, c := range search(cg, node) {
+				crud[ep][c.Table] += c.Kind.CRUD()
+			}
+		}
+	}
+	return crud
+}
+
+// endpointsByFunc returns the endpoints which reach each function through the call graph. The keys are analysis.FuncID of the functions. e.g. eps["example.com/app.getUser"] = ["GET /users/{id}"]
+func endpointsByFunc(endpoints []*epf.Endpoint, cg *analysis.CallGraph) map[string][]string {
+	eps := make(map[string][]string)
+	for _, ep := range endpoints {
+		name := ep.Method + " " + ep.Path
+		node, ok := endpointNode(cg, ep)
+		if !ok {
+			continue
+		}
+		visited := make(map[string]bool)
+		var visit func(n *analysis.Node)
+		visit = func(n *analysis.Node) {
+			if visited[n.ID] {
+				return
+			}
+			visited[n.ID] = true
+			if !slices.Contains(eps[n.ID], name) {
+				eps[n.ID] = append(eps[n.ID], name)
+			}
+			for _, edge := range n.Out {
+				if edge.IsFuncCall() {
+					visit(cg.Nodes[edge.Callee])
 				}
 			}
 		}
+		visit(node)
+	}
+	return eps
+}
+
+// printCrud prints the CRUD matrix. If weights are given, the numbers of reads and writes of each table are shown in the footer.
+// If traffic is given, the number of requests and the estimated number of queries of each endpoint are shown,
+// and the estimated numbers of reads and writes of each table are shown in the footer.
+func printCrud(w io.Writer, endpoints []*epf.Endpoint, cg *analysis.CallGraph, dir, format string, weights map[string]*tableStat, traffic *endpointTraffic) error {
+	crud := crudMatrix(endpoints, cg)
+	var traffics map[string]*tableTraffic
+	if traffic != nil {
+		traffics = tableTraffics(endpoints, crud, traffic)
 	}
 	slices.SortFunc(endpoints, func(i, j *epf.Endpoint) int { return strings.Compare(i.Path, j.Path) })
 
 	tables := make([]string, 0)
-	for _, cg := range cgs {
-		for _, node := range cg.Nodes {
-			if node.IsTable() {
-				tables = append(tables, node.Name)
-			}
+	for _, node := range cg.Nodes {
+		if node.IsTable() {
+			tables = append(tables, node.Name)
 		}
 	}
 	slices.Sort(tables)
 
+	if slices.Contains(jsonFormats, format) {
+		jw, err := newJSONWriter("crud", dir)
+		if err != nil {
+			return err
+		}
+		for _, ep := range endpoints {
+			r := &jsonEndpoint{Type: "endpoint", Method: ep.Method, Path: ep.Path, Function: ep.FuncName, Tables: make(map[string]string)}
+			for tbl, kind := range crud[ep] {
+				r.Tables[tbl] = crudString(kind)
+			}
+			if traffic != nil {
+				requests, queries := traffic.requests[ep], traffic.requests[ep]*queriesPerRequest(crud[ep])
+				r.Requests, r.Queries = &requests, &queries
+			}
+			jw.add(r)
+		}
+		if weights != nil || traffics != nil {
+			for _, tbl := range tables {
+				weight, hasWeight := weights[tbl]
+				tt, hasTraffic := traffics[tbl]
+				if !hasWeight && !hasTraffic {
+					continue
+				}
+				r := &jsonCrudTable{Type: "table", Name: tbl}
+				if hasWeight {
+					r.Stats = newJSONTableStat(weight)
+				}
+				if hasTraffic {
+					r.Traffic = newJSONTableTraffic(tt, traffic)
+				}
+				jw.add(r)
+			}
+		}
+		return jw.write(w, format)
+	}
+
 	t := table.NewWriter()
 	t.SetOutputMirror(w)
 	var header table.Row
 	header = append(header, "METHOD", "URI", "Function")
+	if traffic != nil {
+		header = append(header, "Requests", "Queries")
+	}
 	for _, table := range tables {
 		header = append(header, table)
 	}
@@ -92,40 +222,68 @@ func printCrud(w io.Writer, endpoints []*epf.Endpoint, cgs map[string]*analysis.
 		row = append(row, ep.Method)
 		row = append(row, ep.Path)
 		row = append(row, ep.FuncName)
+		if traffic != nil {
+			row = append(row, traffic.requests[ep], traffic.requests[ep]*queriesPerRequest(crud[ep]))
+		}
 		for _, tbl := range tables {
-			if kind, ok := crud[ep.FuncName][tbl]; ok {
-				var v string
-				for _, k := range []string{"C", "R", "U", "D", "?"} {
-					if strings.Contains(kind, k) {
-						v += k
-					}
-				}
-				row = append(row, v)
+			if kind, ok := crud[ep][tbl]; ok {
+				row = append(row, crudString(kind))
 			} else {
 				row = append(row, "")
 			}
 		}
 		t.AppendRow(row)
 	}
+	footer := func(label string, cell func(tbl string) string) {
+		row := table.Row{"", "", label}
+		if traffic != nil {
+			row = append(row, "", "")
+		}
+		for _, tbl := range tables {
+			row = append(row, cell(tbl))
+		}
+		t.AppendFooter(row)
+	}
+	if traffic != nil {
+		footer("Estimated", func(tbl string) string {
+			if tt, ok := traffics[tbl]; ok {
+				return tt.String()
+			}
+			return ""
+		})
+		if _, ok := traffic.PerSecond(0); ok {
+			footer("Per second", func(tbl string) string {
+				if tt, ok := traffics[tbl]; ok {
+					reads, _ := traffic.PerSecond(tt.Reads)
+					writes, _ := traffic.PerSecond(tt.Writes)
+					return fmt.Sprintf("R:%.2f W:%.2f", reads, writes)
+				}
+				return ""
+			})
+		}
+	}
+	if weights != nil {
+		footer("Executions", func(tbl string) string {
+			if weight, ok := weights[tbl]; ok {
+				return weight.String()
+			}
+			return ""
+		})
+	}
+
+	renderTable(t, format)
+	return nil
+}
 
-	switch format {
-	case "table":
-		t.Render()
-	case "md":
-		t.RenderMarkdown()
-	case "csv":
-		t.RenderCSV()
-	case "tsv":
-		t.RenderTSV()
-	case "html":
-		t.RenderHTML()
-	case "simple":
-		t.Style().Options.DrawBorder = false
-		t.Style().Options.SeparateHeader = false
-		t.Style().Options.SeparateRows = false
-		t.Style().Box.MiddleVertical = " "
-		t.Render()
+// crudString returns the distinct operations in the order of C, R, U and D. e.g. "RRC" -> "CR"
+func crudString(kind string) string {
+	var v string
+	for _, k := range []string{"C", "R", "U", "D", "?"} {
+		if strings.Contains(kind, k) {
+			v += k
+		}
 	}
+	return v
 }
 
 type Crud struct {
@@ -133,13 +291,20 @@ type Crud struct {
 	Table string
 }
 
+// search returns the queries executed by the function directly or indirectly. Recursive calls are not followed again.
 func search(cg *analysis.CallGraph, node *analysis.Node) []Crud {
+	return searchPath(cg, node, make(map[string]bool))
+}
+
+func searchPath(cg *analysis.CallGraph, node *analysis.Node, path map[string]bool) []Crud {
+	path[node.ID] = true
+	defer delete(path, node.ID)
 	results := make([]Crud, 0)
 	for _, edge := range node.Out {
 		if edge.IsQuery() {
 			results = append(results, Crud{Kind: edge.SqlValue.Kind, Table: edge.Callee})
-		} else {
-			results = append(results, search(cg, cg.Nodes[edge.Callee])...)
+		} else if !path[edge.Callee] {
+			results = append(results, searchPath(cg, cg.Nodes[edge.Callee], path)...)
 		}
 	}
 	return results
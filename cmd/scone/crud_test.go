@@ -7,13 +7,15 @@ import (
 	"testing"
 
 	"github.com/sebdah/goldie/v2"
+	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 )
 
 func Test_runCrud(t *testing.T) {
-	tests := []string{"isucon10-qualify", "isucon10-final", "isucon11-qualify", "isucon11-final", "isucon12-qualify", "isucon12-final", "isucon13"}
+	tests := []string{"isucon10-qualify", "isucon10-final", "isucon11-qualify", "isucon11-final", "isucon12-qualify", "isucon12-final", "isucon13", "gorm", "pgx", "wrapper", "template", "stmt"}
+	dialects := map[string]string{"pgx": "postgres"}
 	for _, tt := range tests {
 		t.Run(tt, func(t *testing.T) {
 			t.Parallel()
@@ -25,8 +27,12 @@ func Test_runCrud(t *testing.T) {
 			v := viper.New()
 			v.Set("dir", "./testdata/src/"+tt)
 			v.Set("pattern", "./...")
+			v.Set("format", "table")
+			if dialect, ok := dialects[tt]; ok {
+				v.Set("dialect", dialect)
+			}
 
-			err := runCrud(cmd, v)
+			err := runCrud(cmd, v, afero.NewOsFs())
 			assert.NoError(t, err)
 
 			g := goldie.New(t)
@@ -34,3 +40,91 @@ func Test_runCrud(t *testing.T) {
 		})
 	}
 }
+
+func Test_runCrud_stats(t *testing.T) {
+	tests := []struct {
+		format string
+		golden string
+	}{
+		{"table", "lint.crud-stats"},
+		{"jsonl", "lint.crud-stats-jsonl"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			cmd.SetContext(context.Background())
+			buf := &bytes.Buffer{}
+			cmd.SetOut(buf)
+			cmd.SetErr(io.Discard)
+			v := viper.New()
+			v.Set("dir", "./testdata/src/lint")
+			v.Set("pattern", "./...")
+			v.Set("format", tt.format)
+			v.Set("stats", "./testdata/lint.digest.tsv")
+
+			err := runCrud(cmd, v, afero.NewOsFs())
+			assert.NoError(t, err)
+
+			g := goldie.New(t)
+			g.Assert(t, tt.golden, buf.Bytes())
+		})
+	}
+}
+
+func Test_runCrud_accessLog(t *testing.T) {
+	tests := []struct {
+		format string
+		golden string
+	}{
+		{"table", "lint.crud-access-log"},
+		{"jsonl", "lint.crud-access-log-jsonl"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			cmd.SetContext(context.Background())
+			buf := &bytes.Buffer{}
+			cmd.SetOut(buf)
+			cmd.SetErr(io.Discard)
+			v := viper.New()
+			v.Set("dir", "./testdata/src/lint")
+			v.Set("pattern", "./...")
+			v.Set("format", tt.format)
+			v.Set("access-log", "./testdata/lint.access.log")
+
+			err := runCrud(cmd, v, afero.NewOsFs())
+			assert.NoError(t, err)
+
+			g := goldie.New(t)
+			g.Assert(t, tt.golden, buf.Bytes())
+		})
+	}
+}
+
+func Test_runCrud_callgraph(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.SetContext(context.Background())
+	buf := &bytes.Buffer{}
+	cmd.SetOut(buf)
+	cmd.SetErr(io.Discard)
+	v := viper.New()
+	v.Set("dir", "./testdata/src/iface")
+	v.Set("pattern", "./...")
+	v.Set("format", "table")
+	v.Set("callgraph", "vta")
+
+	err := runCrud(cmd, v, afero.NewOsFs())
+	assert.NoError(t, err)
+
+	g := goldie.New(t)
+	g.Assert(t, "iface.crud-vta", buf.Bytes())
+}
+
+func Test_runCrud_unknownFormat(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.SetContext(context.Background())
+	v := viper.New()
+
+	err := runCrud(cmd, v, afero.NewOsFs())
+	assert.EqualError(t, err, "unknown format: ")
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/haijima/scone/internal/baseline"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func NewDiffCommand(v *viper.Viper, fs afero.Fs) *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = "diff"
+	cmd.Short = "Compare the current analysis with a baseline file"
+	cmd.Long = "Compare queries, CRUD cells and N+1 loops with the baseline file written by `scone snapshot`,\nand exit with non-zero code if the changes violate the policies given by --fail-on."
+	cmd.Args = cobra.NoArgs
+	cmd.RunE = func(cmd *cobra.Command, _ []string) error { return runDiff(cmd, v, fs) }
+
+	cmd.Flags().String("baseline", "scone-baseline.json", "The baseline `file` written by `scone snapshot`")
+	cmd.Flags().String("format", "table", formatUsage())
+	cmd.Flags().StringSlice("fail-on", []string{baseline.NewLoop, baseline.NewUnknown, baseline.NewWrite}, "Exit with non-zero code if changes of the `policies` are found {"+strings.Join(baseline.Policies, "|")+"|none}")
+	_ = cmd.MarkFlagFilename("baseline", "json")
+
+	return cmd
+}
+
+func runDiff(cmd *cobra.Command, v *viper.Viper, fs afero.Fs) error {
+	baselineFile := v.GetString("baseline")
+	format := v.GetString("format")
+	failOn := v.GetStringSlice("fail-on")
+
+	if err := validateFormat(format); err != nil {
+		return err
+	}
+	failOn = slices.DeleteFunc(failOn, func(p string) bool { return p == "none" })
+	for _, p := range failOn {
+		if !slices.Contains(baseline.Policies, p) {
+			return errors.Newf("unknown policy: %s", p)
+		}
+	}
+
+	f, err := fs.Open(baselineFile)
+	if err != nil {
+		return errors.Wrap(err, "failed to open baseline file")
+	}
+	defer f.Close()
+	base, err := baseline.Read(f)
+	if err != nil {
+		return err
+	}
+	current, err := takeSnapshot(cmd.Context(), v, fs)
+	if err != nil {
+		return err
+	}
+
+	changes := baseline.Diff(base, current)
+	violations := make([][]string, len(changes))
+	failed := 0
+	for i, c := range changes {
+		for _, p := range failOn {
+			if c.Violates(p) {
+				violations[i] = append(violations[i], p)
+			}
+		}
+		if len(violations[i]) > 0 {
+			failed++
+		}
+	}
+
+	if slices.Contains(jsonFormats, format) {
+		w, err := newJSONWriter("diff", v.GetString("dir"))
+		if err != nil {
+			return err
+		}
+		for i, c := range changes {
+			w.add(&jsonChange{Type: "change", Change: string(c.Type), Category: c.Category, Key: c.Key, Before: c.Before, After: c.After, Violations: nonNil(violations[i])})
+		}
+		if err := w.write(cmd.OutOrStdout(), format); err != nil {
+			return err
+		}
+	} else {
+		t := table.NewWriter()
+		t.SetOutputMirror(cmd.OutOrStdout())
+		t.AppendHeader(table.Row{"#", "Change", "Category", "Key", "Before", "After", "Violation"})
+		for i, c := range changes {
+			t.AppendRow(table.Row{i + 1, c.Type, c.Category, c.Key, c.Before, c.After, strings.Join(violations[i], ", ")})
+		}
+		renderTable(t, format)
+	}
+
+	if failed > 0 {
+		return errors.Newf("%d changes violating %s found", failed, strings.Join(failOn, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// tableFormats are the formats which are written by renderTable.
+var tableFormats = []string{"table", "md", "csv", "tsv", "html", "simple"}
+
+// outputFormats returns the table formats, the JSON formats and the extra formats of the command.
+func outputFormats(extra ...string) []string {
+	return slices.Concat(tableFormats, jsonFormats, extra)
+}
+
+// formatUsage returns the usage of the --format flag.
+func formatUsage(extra ...string) string {
+	return "The output format {" + strings.Join(outputFormats(extra...), "|") + "}"
+}
+
+// validateFormat returns an error if the format is not one of outputFormats.
+func validateFormat(format string, extra ...string) error {
+	if !slices.Contains(outputFormats(extra...), format) {
+		return errors.Newf("unknown format: %s", format)
+	}
+	return nil
+}
+
+// renderTable renders the table in the format, which should be one of tableFormats.
+func renderTable(t table.Writer, format string) {
+	switch format {
+	case "table":
+		t.Render()
+	case "md":
+		t.RenderMarkdown()
+	case "csv":
+		t.RenderCSV()
+	case "tsv":
+		t.RenderTSV()
+	case "html":
+		t.RenderHTML()
+	case "simple":
+		t.Style().Options.DrawBorder = false
+		t.Style().Options.SeparateHeader = false
+		t.Style().Options.SeparateRows = false
+		t.Style().Box.MiddleVertical = " "
+		t.Render()
+	}
+}
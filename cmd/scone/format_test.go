@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_validateFormat(t *testing.T) {
+	assert.NoError(t, validateFormat("simple"))
+	assert.NoError(t, validateFormat("jsonl"))
+	assert.NoError(t, validateFormat("sarif", "sarif"))
+	assert.EqualError(t, validateFormat("sarif"), "unknown format: sarif")
+	assert.EqualError(t, validateFormat("xml"), "unknown format: xml")
+	assert.Equal(t, "The output format {table|md|csv|tsv|html|simple|json|jsonl|sarif}", formatUsage("sarif"))
+}
+
+func Test_renderTable(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"md", "| A | B |\n| ---:| --- |\n| 1 | x |\n"},
+		{"csv", "A,B\n1,x\n"},
+		{"tsv", "A\tB\n1\tx\n"},
+		{"simple", " A   B \n 1   x \n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			tw := table.NewWriter()
+			tw.SetOutputMirror(buf)
+			tw.AppendHeader(table.Row{"A", "B"})
+			tw.AppendRow(table.Row{1, "x"})
+			renderTable(tw, tt.format)
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
@@ -10,7 +10,7 @@ import (
 func NewGenConfCmd(_ *viper.Viper, _ afero.Fs) *cobra.Command {
 	genConfCmd := cobrax.PrintConfigCmd("genconf")
 	genConfCmd.SetHelpFunc(func(cmd *cobra.Command, args []string) {
-		for _, flag := range []string{"dir", "pattern", "filter", "analyze-funcs", "config", "no-color"} {
+		for _, flag := range []string{"dir", "pattern", "filter", "analyze-funcs", "dialect", "schema", "callgraph", "config", "no-color"} {
 			cmd.Flag(flag).Hidden = true
 		}
 		cmd.Root().HelpFunc()(cmd, args)
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/haijima/scone/internal/analysis"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func NewIndexCommand(v *viper.Viper, fs afero.Fs) *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = "index"
+	cmd.Aliases = []string{"indexes"}
+	cmd.Short = "Find queries not served by indexes and propose indexes"
+	cmd.Long = "Find queries whose WHERE, JOIN and ORDER BY columns are not served by any index prefix declared in the schema file,\nand propose composite indexes which cover the most query sites."
+	cmd.Args = cobra.NoArgs
+	cmd.RunE = func(cmd *cobra.Command, _ []string) error { return runIndex(cmd, v, fs) }
+
+	cmd.Flags().String("format", "table", formatUsage())
+
+	return cmd
+}
+
+func runIndex(cmd *cobra.Command, v *viper.Viper, fs afero.Fs) error {
+	dir := v.GetString("dir")
+	pattern := v.GetString("pattern")
+	format := v.GetString("format")
+
+	if err := validateFormat(format); err != nil {
+		return err
+	}
+	if v.GetString("schema") == "" {
+		return errors.New("--schema is required to find indexes")
+	}
+
+	opt, err := newOption(v, fs)
+	if err != nil {
+		return err
+	}
+	result, err := analysis.Analyze(cmd.Context(), dir, pattern, opt)
+	if err != nil {
+		return err
+	}
+
+	sites := analysis.UnindexedSites(result.QueryResults, opt.Schema)
+	proposals := analysis.ProposeIndexes(sites)
+	proposalOf := make(map[*analysis.IndexSite]string)
+	for _, p := range proposals {
+		for _, s := range p.Sites {
+			proposalOf[s] = p.Name
+		}
+	}
+
+	if slices.Contains(jsonFormats, format) {
+		w, err := newJSONWriter("index", dir)
+		if err != nil {
+			return err
+		}
+		for _, s := range sites {
+			qr := &analysis.QueryResult{Posx: s.Posx}
+			w.add(&jsonIndexSite{Type: "site", Table: s.Table, Columns: nonNil(s.Columns), OrderBy: nonNil(s.OrderBy), Query: w.query(0, s.Query, qr), Proposal: proposalOf[s]})
+		}
+		for _, p := range proposals {
+			w.add(&jsonIndexProposal{Type: "proposal", Name: p.Name, Table: p.Table, Columns: nonNil(p.Columns), Queries: len(p.Sites), Statement: p.String()})
+		}
+		return w.write(cmd.OutOrStdout(), format)
+	}
+
+	t := table.NewWriter()
+	t.SetOutputMirror(cmd.OutOrStdout())
+	t.AppendHeader(table.Row{"#", "Table", "Columns", "Order By", "Function", "Query", "Position", "Proposal"})
+	for i, s := range sites {
+		t.AppendRow(table.Row{i + 1, s.Table, strings.Join(s.Columns, ", "), strings.Join(s.OrderBy, ", "), analysis.FuncName(s.Posx.Func), s.Query.String(), s.Posx.PositionString(), proposalOf[s]})
+	}
+	renderTable(t, format)
+
+	_, _ = fmt.Fprintln(cmd.OutOrStdout())
+
+	t = table.NewWriter()
+	t.SetOutputMirror(cmd.OutOrStdout())
+	t.AppendHeader(table.Row{"Proposal", "Table", "Columns", "Queries", "Statement"})
+	for _, p := range proposals {
+		t.AppendRow(table.Row{p.Name, p.Table, strings.Join(p.Columns, ", "), strconv.Itoa(len(p.Sites)), p.String()})
+	}
+	renderTable(t, format)
+	return nil
+}
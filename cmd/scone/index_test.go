@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/sebdah/goldie/v2"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_runIndex(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.SetContext(context.Background())
+	buf := &bytes.Buffer{}
+	cmd.SetOut(buf)
+	cmd.SetErr(io.Discard)
+	v := viper.New()
+	v.Set("dir", "./testdata/src/index")
+	v.Set("pattern", "./...")
+	v.Set("format", "table")
+	v.Set("schema", "./testdata/src/index/schema.sql")
+
+	err := runIndex(cmd, v, afero.NewOsFs())
+	require.NoError(t, err)
+
+	g := goldie.New(t)
+	g.Assert(t, "index.index", buf.Bytes())
+}
+
+func Test_runIndex_noSchema(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.SetContext(context.Background())
+	v := viper.New()
+	v.Set("dir", "./testdata/src/index")
+	v.Set("pattern", "./...")
+	v.Set("format", "table")
+
+	err := runIndex(cmd, v, afero.NewOsFs())
+	assert.EqualError(t, err, "--schema is required to find indexes")
+}
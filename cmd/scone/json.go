@@ -0,0 +1,355 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"slices"
+
+	"github.com/haijima/analysisutil/ssautil"
+	"github.com/haijima/scone/internal/analysis"
+	"github.com/haijima/scone/internal/sql"
+	"github.com/haijima/scone/internal/stats"
+)
+
+// jsonSchemaVersion is the version of the JSON output schema.
+// Fields may be added without changing the version, but they are never renamed nor removed.
+const jsonSchemaVersion = 1
+
+// jsonFormats are the formats which are written by jsonWriter.
+var jsonFormats = []string{"json", "jsonl"}
+
+// jsonDocument is the output of `--format json`. `--format jsonl` writes jsonHeader and each record in a line instead.
+type jsonDocument struct {
+	Version int    `json:"version"`
+	Command string `json:"command"`
+	Records []any  `json:"records"`
+}
+
+// jsonHeader is the first line of `--format jsonl`.
+type jsonHeader struct {
+	Type    string `json:"type"` // "header"
+	Version int    `json:"version"`
+	Command string `json:"command"`
+}
+
+type jsonPosition struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+type jsonQuery struct {
+	Type           string          `json:"type"` // "query"
+	Group          int             `json:"group,omitempty"`
+	Package        string          `json:"package"`
+	PackagePath    string          `json:"packagePath"`
+	Function       string          `json:"function"`
+	Position       *jsonPosition   `json:"position"`
+	PreparedAt     []*jsonPosition `json:"preparedAt,omitempty"` // positions of the Prepare calls
+	Kind           string          `json:"kind"`
+	Tables         []string        `json:"tables"`
+	Hash           string          `json:"hash"`
+	Fingerprint    string          `json:"fingerprint"`
+	Stats          *jsonStat       `json:"stats,omitempty"` // runtime statistics given by --stats
+	Raw            string          `json:"raw"`
+	Dynamic        bool            `json:"dynamic"`
+	FromComment    bool            `json:"fromComment"`
+	Lock           string          `json:"lock,omitempty"`
+	SelectColumns  []string        `json:"selectColumns"`
+	WriteColumns   []string        `json:"writeColumns"`
+	OrderByColumns []string        `json:"orderByColumns"`
+	GroupByColumns []string        `json:"groupByColumns"`
+	JoinColumns    []string        `json:"joinColumns"`
+}
+
+type jsonStat struct {
+	Count        int64   `json:"count"`
+	TotalTime    float64 `json:"totalTime"` // seconds
+	AvgTime      float64 `json:"avgTime"`   // seconds
+	MaxTime      float64 `json:"maxTime"`   // seconds
+	RowsSent     int64   `json:"rowsSent"`
+	RowsExamined int64   `json:"rowsExamined"`
+}
+
+type jsonTableStat struct {
+	Reads  *jsonStat `json:"reads"`
+	Writes *jsonStat `json:"writes"`
+}
+
+type jsonTableSummary struct {
+	Type          string              `json:"type"` // "summary"
+	Queries       int                 `json:"queries"`
+	Tables        int                 `json:"tables"`
+	Cacheability  map[string][]string `json:"cacheability"`
+	Clusters      [][]string          `json:"clusters"`
+	PartitionKeys map[string][]string `json:"partitionKeys"`
+}
+
+type jsonTable struct {
+	Type          string         `json:"type"` // "table"
+	Name          string         `json:"name"`
+	Kinds         []string       `json:"kinds"`
+	Cacheability  string         `json:"cacheability"`
+	Collocation   []string       `json:"collocation"`
+	Cluster       []string       `json:"cluster"`
+	PartitionKeys []string       `json:"partitionKeys"`
+	Stats         *jsonTableStat `json:"stats,omitempty"`
+	Queries       []*jsonQuery   `json:"queries"`
+}
+
+type jsonEndpoint struct {
+	Type     string            `json:"type"` // "endpoint"
+	Method   string            `json:"method"`
+	Path     string            `json:"path"`
+	Function string            `json:"function"`
+	Tables   map[string]string `json:"tables"`             // CRUD of each table. e.g. "CR"
+	Requests *int64            `json:"requests,omitempty"` // the number of requests in the access log given by --access-log
+	Queries  *int64            `json:"queries,omitempty"`  // the estimated number of queries issued by the requests
+}
+
+type jsonCrudTable struct {
+	Type    string            `json:"type"` // "table"
+	Name    string            `json:"name"`
+	Stats   *jsonTableStat    `json:"stats,omitempty"`
+	Traffic *jsonTableTraffic `json:"traffic,omitempty"`
+}
+
+type jsonTableTraffic struct {
+	Reads           int64    `json:"reads"`
+	Writes          int64    `json:"writes"`
+	ReadsPerSecond  *float64 `json:"readsPerSecond,omitempty"` // only if the time of the requests is logged
+	WritesPerSecond *float64 `json:"writesPerSecond,omitempty"`
+}
+
+type jsonLoop struct {
+	Type        string        `json:"type"` // "loop"
+	Package     string        `json:"package"`
+	PackagePath string        `json:"packagePath"`
+	Function    string        `json:"function"`
+	Callee      string        `json:"callee"`
+	Depth       int           `json:"depth"`
+	Position    *jsonPosition `json:"position"`
+	Hashes      []string      `json:"hashes"`
+}
+
+type jsonNode struct {
+	Type        string `json:"type"` // "node"
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Kind        string `json:"kind"` // "func" or "table"
+	PackagePath string `json:"packagePath,omitempty"`
+}
+
+type jsonEdge struct {
+	Type      string `json:"type"` // "edge"
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Kind      string `json:"kind"` // "call" or "query"
+	QueryKind string `json:"queryKind,omitempty"`
+	Hash      string `json:"hash,omitempty"`
+	Raw       string `json:"raw,omitempty"`
+}
+
+type jsonTransaction struct {
+	Type         string        `json:"type"` // "transaction"
+	Package      string        `json:"package"`
+	PackagePath  string        `json:"packagePath"`
+	Function     string        `json:"function"`
+	Position     *jsonPosition `json:"position"`
+	LockedTables []string      `json:"lockedTables"`
+	Tables       []string      `json:"tables"`
+	Queries      []*jsonQuery  `json:"queries"`
+	Ends         []*jsonTxEnd  `json:"ends"`
+}
+
+type jsonTxEnd struct {
+	Statement string        `json:"statement"` // "COMMIT" or "ROLLBACK"
+	Deferred  bool          `json:"deferred"`
+	Function  string        `json:"function"`
+	Position  *jsonPosition `json:"position"`
+}
+
+type jsonIndexSite struct {
+	Type     string     `json:"type"` // "site"
+	Table    string     `json:"table"`
+	Columns  []string   `json:"columns"`
+	OrderBy  []string   `json:"orderBy"`
+	Query    *jsonQuery `json:"query"`
+	Proposal string     `json:"proposal,omitempty"`
+}
+
+type jsonIndexProposal struct {
+	Type      string   `json:"type"` // "proposal"
+	Name      string   `json:"name"`
+	Table     string   `json:"table"`
+	Columns   []string `json:"columns"`
+	Queries   int      `json:"queries"`
+	Statement string   `json:"statement"`
+}
+
+type jsonDiagnostic struct {
+	Type        string        `json:"type"` // "diagnostic"
+	Rule        string        `json:"rule"`
+	Severity    string        `json:"severity"`
+	Message     string        `json:"message"`
+	Package     string        `json:"package"`
+	PackagePath string        `json:"packagePath"`
+	Function    string        `json:"function"`
+	Position    *jsonPosition `json:"position"`
+	Query       *jsonQuery    `json:"query,omitempty"`
+}
+
+type jsonChange struct {
+	Type       string   `json:"type"`   // "change"
+	Change     string   `json:"change"` // "added", "removed" or "changed"
+	Category   string   `json:"category"`
+	Key        string   `json:"key"`
+	Before     string   `json:"before"`
+	After      string   `json:"after"`
+	Violations []string `json:"violations"`
+}
+
+type jsonSlowQuery struct {
+	Type            string     `json:"type"` // "slowquery"
+	Fingerprint     string     `json:"fingerprint"`
+	Sample          string     `json:"sample"`
+	Count           int64      `json:"count"`
+	TotalTime       float64    `json:"totalTime"` // seconds
+	AvgTime         float64    `json:"avgTime"`   // seconds
+	MaxTime         float64    `json:"maxTime"`   // seconds
+	RowsSent        int64      `json:"rowsSent"`
+	RowsExamined    int64      `json:"rowsExamined"`
+	AvgRowsExamined float64    `json:"avgRowsExamined"`
+	Shared          bool       `json:"shared"` // the fingerprint matches multiple queries, and the statistics are the total of them
+	Endpoints       []string   `json:"endpoints"`
+	Query           *jsonQuery `json:"query"` // null if the query is not found in the source code
+}
+
+// jsonWriter writes the records of commands in JSON or JSON Lines format.
+type jsonWriter struct {
+	command string
+	records []any
+	root    string      // the positions are relative to this directory
+	stats   stats.Stats // attached to the queries if given
+}
+
+// newJSONWriter returns a writer whose positions are relative to dir, which is the root of the analysis.
+func newJSONWriter(command, dir string) (*jsonWriter, error) {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &jsonWriter{command: command, records: make([]any, 0), root: root}, nil
+}
+
+func (w *jsonWriter) add(records ...any) {
+	w.records = append(w.records, records...)
+}
+
+// position returns the position relative to the root, or nil if the position is unknown.
+func (w *jsonWriter) position(posx *ssautil.Posx) *jsonPosition {
+	pos := posx.Position()
+	if pos.Filename == "" {
+		return nil
+	}
+	file := pos.Filename
+	if rel, err := filepath.Rel(w.root, pos.Filename); err == nil {
+		file = rel
+	}
+	return &jsonPosition{File: filepath.ToSlash(file), Line: pos.Line, Column: pos.Column}
+}
+
+// positions returns the known positions relative to the root, or nil if there is no position.
+func (w *jsonWriter) positions(posxs []*ssautil.Posx) []*jsonPosition {
+	var res []*jsonPosition
+	for _, posx := range posxs {
+		if pos := w.position(posx); pos != nil {
+			res = append(res, pos)
+		}
+	}
+	return res
+}
+
+func (w *jsonWriter) query(group int, q *sql.Query, qr *analysis.QueryResult) *jsonQuery {
+	return &jsonQuery{
+		Type:           "query",
+		Group:          group,
+		Package:        qr.Posx.Package().Name(),
+		PackagePath:    qr.Posx.Package().Path(),
+		Function:       analysis.FuncName(qr.Posx.Func),
+		Position:       w.position(qr.Posx),
+		PreparedAt:     w.positions(qr.PreparedAt),
+		Kind:           q.Kind.String(),
+		Tables:         nonNil(q.Tables),
+		Hash:           q.Hash(),
+		Fingerprint:    q.Fingerprint(),
+		Stats:          newJSONStat(w.stats.Get(q)),
+		Raw:            q.Raw,
+		Dynamic:        q.Dynamic,
+		FromComment:    qr.FromComment,
+		Lock:           lockString(q),
+		SelectColumns:  nonNil(q.SelectColumns),
+		WriteColumns:   nonNil(q.WriteColumns),
+		OrderByColumns: nonNil(q.OrderByColumns),
+		GroupByColumns: nonNil(q.GroupByColumns),
+		JoinColumns:    nonNil(q.JoinColumns),
+	}
+}
+
+func (w *jsonWriter) write(out io.Writer, format string) error {
+	enc := json.NewEncoder(out)
+	if format == "jsonl" {
+		if err := enc.Encode(&jsonHeader{Type: "header", Version: jsonSchemaVersion, Command: w.command}); err != nil {
+			return err
+		}
+		for _, r := range w.records {
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	enc.SetIndent("", "  ")
+	return enc.Encode(&jsonDocument{Version: jsonSchemaVersion, Command: w.command, Records: w.records})
+}
+
+func newJSONStat(s *stats.Stat) *jsonStat {
+	if s == nil {
+		return nil
+	}
+	return &jsonStat{Count: s.Count, TotalTime: s.TotalTime.Seconds(), AvgTime: s.AvgTime().Seconds(), MaxTime: s.MaxTime.Seconds(), RowsSent: s.RowsSent, RowsExamined: s.RowsExamined}
+}
+
+func newJSONTableStat(t *tableStat) *jsonTableStat {
+	if t == nil {
+		return nil
+	}
+	return &jsonTableStat{Reads: newJSONStat(t.Reads), Writes: newJSONStat(t.Writes)}
+}
+
+func newJSONTableTraffic(t *tableTraffic, traffic *endpointTraffic) *jsonTableTraffic {
+	r := &jsonTableTraffic{Reads: t.Reads, Writes: t.Writes}
+	if reads, ok := traffic.PerSecond(t.Reads); ok {
+		writes, _ := traffic.PerSecond(t.Writes)
+		r.ReadsPerSecond, r.WritesPerSecond = &reads, &writes
+	}
+	return r
+}
+
+// nonNil returns an empty slice instead of nil so that it is written as [] instead of null.
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return slices.Clone(s)
+}
+
+// jsonQueryKinds returns the names of the kinds.
+func jsonQueryKinds(kinds []sql.QueryKind) []string {
+	strs := make([]string, 0, len(kinds))
+	for _, k := range kinds {
+		strs = append(strs, k.String())
+	}
+	return strs
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/sebdah/goldie/v2"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_jsonFormat(t *testing.T) {
+	tests := []struct {
+		dir    string
+		name   string
+		format string
+		run    func(cmd *cobra.Command, v *viper.Viper, fs afero.Fs) error
+	}{
+		{"lint", "query", "json", runQuery},
+		{"stmt", "query", "jsonl", runQuery},
+		{"gorm", "query", "jsonl", runQuery},
+		{"lint", "table", "jsonl", runTable},
+		{"lint", "crud", "jsonl", runCrud},
+		{"lint", "loop", "jsonl", runLoop},
+		{"lint", "callgraph", "jsonl", runCallgraph},
+		{"lint", "lint", "jsonl", runLint},
+		{"tx", "tx", "json", runTx},
+		{"index", "index", "jsonl", runIndex},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cmd := &cobra.Command{}
+			cmd.SetContext(context.Background())
+			buf := &bytes.Buffer{}
+			cmd.SetOut(buf)
+			cmd.SetErr(io.Discard)
+			v := viper.New()
+			v.Set("dir", "./testdata/src/"+tt.dir)
+			v.Set("pattern", "./...")
+			v.Set("format", tt.format)
+			v.Set("fail-on", "none")
+			if tt.dir == "index" {
+				v.Set("schema", "./testdata/src/index/schema.sql")
+			}
+
+			err := tt.run(cmd, v, afero.NewOsFs())
+			require.NoError(t, err)
+
+			g := goldie.New(t)
+			g.Assert(t, tt.dir+"."+tt.name+"-"+tt.format, buf.Bytes())
+		})
+	}
+}
+
+func Test_jsonWriter_write(t *testing.T) {
+	w, err := newJSONWriter("query", ".")
+	require.NoError(t, err)
+	w.add(&jsonQuery{Type: "query"}, &jsonQuery{Type: "query"})
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, w.write(buf, "json"))
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+	assert.Equal(t, float64(jsonSchemaVersion), doc["version"])
+	assert.Equal(t, "query", doc["command"])
+	assert.Len(t, doc["records"], 2)
+
+	buf.Reset()
+	require.NoError(t, w.write(buf, "jsonl"))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	var header map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &header))
+	assert.Equal(t, map[string]any{"type": "header", "version": float64(jsonSchemaVersion), "command": "query"}, header)
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"slices"
+
+	"github.com/cockroachdb/errors"
+	"github.com/haijima/scone/internal/analysis"
+	"github.com/haijima/scone/internal/lint"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func NewLintCommand(v *viper.Viper, fs afero.Fs) *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = "lint"
+	cmd.Short = "Check queries by lint rules"
+	cmd.Long = "Check queries by lint rules and exit with non-zero code if issues are found.\nRules are configured by `rules` in the config file, and suppressed by `// scone:ignore <rule>` comments."
+	cmd.Args = cobra.NoArgs
+	cmd.RunE = func(cmd *cobra.Command, _ []string) error { return runLint(cmd, v, fs) }
+
+	cmd.Flags().String("format", "table", formatUsage("sarif"))
+	cmd.Flags().String("fail-on", "error", "Exit with non-zero code if issues of the `severity` or higher are found {info|warning|error|none}")
+
+	return cmd
+}
+
+func runLint(cmd *cobra.Command, v *viper.Viper, fs afero.Fs) error {
+	dir := v.GetString("dir")
+	pattern := v.GetString("pattern")
+	format := v.GetString("format")
+	failOn := v.GetString("fail-on")
+
+	if err := validateFormat(format, "sarif"); err != nil {
+		return err
+	}
+	threshold := lint.Severity(-1)
+	if failOn != "none" {
+		var err error
+		if threshold, err = lint.ParseSeverity(failOn); err != nil {
+			return err
+		}
+	}
+	var config map[string]lint.RuleConfig
+	if err := v.UnmarshalKey("rules", &config); err != nil {
+		return errors.Wrap(err, "failed to read rules config")
+	}
+
+	opt, err := newOption(v, fs)
+	if err != nil {
+		return err
+	}
+	result, err := analysis.Analyze(cmd.Context(), dir, pattern, opt)
+	if err != nil {
+		return err
+	}
+	diagnostics, err := lint.Run(lint.DefaultRules, result, opt, config)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, d := range diagnostics {
+		if threshold >= 0 && d.Severity >= threshold {
+			failed++
+		}
+	}
+	if err := printDiagnostics(cmd, diagnostics, result.ParseFailures, dir, format); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return errors.Newf("%d issues of %s or higher severity found", failed, failOn)
+	}
+	return nil
+}
+
+func printDiagnostics(cmd *cobra.Command, diagnostics []*lint.Diagnostic, parseFailures []*analysis.ParseFailure, dir, format string) error {
+	if format == "sarif" {
+		w, err := newSarifWriter(dir)
+		if err != nil {
+			return err
+		}
+		w.addDiagnostics(lint.DefaultRules, diagnostics)
+		w.addParseFailures(parseFailures)
+		return w.write(cmd.OutOrStdout())
+	}
+	if slices.Contains(jsonFormats, format) {
+		w, err := newJSONWriter("lint", dir)
+		if err != nil {
+			return err
+		}
+		for _, d := range diagnostics {
+			r := &jsonDiagnostic{Type: "diagnostic", Rule: d.Rule.Name, Severity: d.Severity.String(), Message: d.Message, Package: d.Posx.Package().Name(), PackagePath: d.Posx.Package().Path(), Function: analysis.FuncName(d.Posx.Func), Position: w.position(d.Posx)}
+			if d.Query != nil {
+				r.Query = w.query(0, d.Query, &analysis.QueryResult{Posx: d.Posx})
+			}
+			w.add(r)
+		}
+		return w.write(cmd.OutOrStdout(), format)
+	}
+
+	t := table.NewWriter()
+	t.SetOutputMirror(cmd.OutOrStdout())
+	t.AppendHeader(table.Row{"#", "Severity", "Rule", "Message", "Function", "Query", "Position"})
+	for i, d := range diagnostics {
+		query := ""
+		if d.Query != nil {
+			query = d.Query.String()
+		}
+		t.AppendRow(table.Row{i + 1, d.Severity, d.Rule.Name, d.Message, analysis.FuncName(d.Posx.Func), query, d.Posx.PositionString()})
+	}
+
+	renderTable(t, format)
+	return nil
+}
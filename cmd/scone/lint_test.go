@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/sebdah/goldie/v2"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_runLint(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.SetContext(context.Background())
+	buf := &bytes.Buffer{}
+	cmd.SetOut(buf)
+	cmd.SetErr(io.Discard)
+	v := viper.New()
+	v.Set("dir", "./testdata/src/lint")
+	v.Set("pattern", "./...")
+	v.Set("format", "table")
+	v.Set("fail-on", "error")
+
+	err := runLint(cmd, v, afero.NewOsFs())
+	assert.EqualError(t, err, "2 issues of error or higher severity found")
+
+	g := goldie.New(t)
+	g.Assert(t, "lint.lint", buf.Bytes())
+}
+
+func Test_runLint_config(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.SetContext(context.Background())
+	buf := &bytes.Buffer{}
+	cmd.SetOut(buf)
+	cmd.SetErr(io.Discard)
+	v := viper.New()
+	v.Set("dir", "./testdata/src/lint")
+	v.Set("pattern", "./...")
+	v.Set("format", "simple")
+	v.Set("fail-on", "error")
+	v.Set("rules", map[string]any{
+		"missing-where":     map[string]any{"severity": "warning"},
+		"select-star":       map[string]any{"enabled": false},
+		"offset-pagination": map[string]any{"enabled": false},
+	})
+
+	err := runLint(cmd, v, afero.NewOsFs())
+	require.NoError(t, err)
+
+	g := goldie.New(t)
+	g.Assert(t, "lint.lint-config", buf.Bytes())
+}
+
+func Test_runLint_sarif(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.SetContext(context.Background())
+	buf := &bytes.Buffer{}
+	cmd.SetOut(buf)
+	cmd.SetErr(io.Discard)
+	v := viper.New()
+	v.Set("dir", "./testdata/src/lint")
+	v.Set("pattern", "./...")
+	v.Set("format", "sarif")
+	v.Set("fail-on", "none")
+
+	err := runLint(cmd, v, afero.NewOsFs())
+	require.NoError(t, err)
+
+	g := goldie.New(t)
+	g.Assert(t, "lint.lint-sarif", buf.Bytes())
+}
@@ -2,36 +2,34 @@ package main
 
 import (
 	"context"
-	"go/ast"
 	"go/token"
 	"os"
 	"path/filepath"
 	"slices"
 	"strings"
 
-	"github.com/cockroachdb/errors"
 	"github.com/haijima/analysisutil"
 	"github.com/haijima/analysisutil/ssautil"
 	"github.com/haijima/scone/internal/analysis"
+	"github.com/haijima/scone/internal/lint"
 	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
-	"golang.org/x/tools/go/packages"
 	"golang.org/x/tools/go/ssa"
 )
 
-func NewLoopCmd(v *viper.Viper, _ afero.Fs) *cobra.Command {
+func NewLoopCmd(v *viper.Viper, fs afero.Fs) *cobra.Command {
 	cmd := &cobra.Command{}
 	cmd.Use = "loop"
 	cmd.Aliases = []string{"loops", "n+1", "N+1"}
 	cmd.Short = "Find N+1 queries"
 	cmd.Args = cobra.NoArgs
 	cmd.RunE = func(cmd *cobra.Command, _ []string) error {
-		return runLoop(cmd, v)
+		return runLoop(cmd, v, fs)
 	}
 
-	cmd.Flags().String("format", "table", "The output format {table|md|csv|tsv|html|simple}")
+	cmd.Flags().String("format", "table", formatUsage("sarif"))
 
 	return cmd
 }
@@ -44,39 +42,50 @@ type FoundLoopedQuery struct {
 	N        int
 }
 
-func runLoop(cmd *cobra.Command, v *viper.Viper) error {
+func runLoop(cmd *cobra.Command, v *viper.Viper, fs afero.Fs) error {
 	dir := v.GetString("dir")
 	pattern := v.GetString("pattern")
-	filter := v.GetString("filter")
-	additionalFuncs := v.GetStringSlice("analyze-funcs")
 	format := v.GetString("format")
 
-	if !slices.Contains([]string{"table", "md", "csv", "tsv", "html", "simple"}, format) {
-		return errors.Newf("unknown format: %s", format)
+	if err := validateFormat(format, "sarif"); err != nil {
+		return err
 	}
 
-	opt := analysis.NewOption(filter, additionalFuncs)
-	_, cgs, err := analysis.Analyze(cmd.Context(), dir, pattern, opt)
+	opt, err := newOption(v, fs)
 	if err != nil {
 		return err
 	}
+	result, err := analysis.Analyze(cmd.Context(), dir, pattern, opt)
+	if err != nil {
+		return err
+	}
+	queryResults := result.QueryResults
 
-	pkgs, err := analysisutil.LoadPackages(dir, pattern)
+	results, err := findLoopedQueries(cmd.Context(), dir, pattern, result, opt)
 	if err != nil {
 		return err
 	}
 
-	results := make([]*FoundLoopedQuery, 0)
-	for _, pkg := range pkgs {
-		ssaProg, err := ssautil.BuildSSA(pkg)
+	if format == "sarif" {
+		w, err := newSarifWriter(dir)
 		if err != nil {
 			return err
 		}
-		bodies := getForRangeBodies(pkg.Syntax)
-		results = append(results, analyzeForLoopBody(cmd.Context(), cgs, pkg, ssaProg.SrcFuncs, bodies, opt)...)
+		w.addLoopedQueries(results, queryResults)
+		w.addParseFailures(result.ParseFailures)
+		return w.write(cmd.OutOrStdout())
+	}
+	if slices.Contains(jsonFormats, format) {
+		w, err := newJSONWriter("loop", dir)
+		if err != nil {
+			return err
+		}
+		for _, res := range results {
+			posx := ssautil.NewPos(res.Func, res.Call.Pos())
+			w.add(&jsonLoop{Type: "loop", Package: posx.Package().Name(), PackagePath: posx.Package().Path(), Function: analysis.FuncName(res.Func), Callee: calleeString(res.Callee), Depth: res.N, Position: w.position(posx), Hashes: loopedQueryHashes(res, queryResults)})
+		}
+		return w.write(cmd.OutOrStdout(), format)
 	}
-
-	slices.SortFunc(results, func(a, b *FoundLoopedQuery) int { return strings.Compare(a.Position.String(), b.Position.String()) })
 
 	t := table.NewWriter()
 	t.SetOutputMirror(cmd.OutOrStdout())
@@ -91,76 +100,56 @@ func runLoop(cmd *cobra.Command, v *viper.Viper) error {
 		if err != nil {
 			return err
 		}
-		t.AppendRow(table.Row{i + 1, res.Func.Name(), res.Callee.Package().Pkg.Path() + "." + res.Callee.Name(), res.N, relPath})
+		t.AppendRow(table.Row{i + 1, analysis.FuncName(res.Func), calleeString(res.Callee), res.N, relPath})
 	}
 
-	switch format {
-	case "table":
-		t.Render()
-	case "md":
-		t.RenderMarkdown()
-	case "csv":
-		t.RenderCSV()
-	case "tsv":
-		t.RenderTSV()
-	case "html":
-		t.RenderHTML()
-	case "simple":
-		t.Style().Options.DrawBorder = false
-		t.Style().Options.SeparateHeader = false
-		t.Style().Options.SeparateRows = false
-		t.Style().Box.MiddleVertical = " "
-		t.Render()
-	}
+	renderTable(t, format)
 	return nil
 }
 
-func getForRangeBodies(astFiles []*ast.File) []*ast.BlockStmt {
-	bodies := make([]*ast.BlockStmt, 0)
-	for _, astFile := range astFiles {
-		ast.Inspect(astFile, func(n ast.Node) bool {
-			switch n := n.(type) {
-			case *ast.ForStmt:
-				bodies = append(bodies, n.Body)
-			case *ast.RangeStmt:
-				bodies = append(bodies, n.Body)
-			}
-			return true
-		})
+func findLoopedQueries(ctx context.Context, dir, pattern string, result *analysis.Result, opt *analysis.Option) ([]*FoundLoopedQuery, error) {
+	pkgs, err := analysisutil.LoadPackages(dir, pattern)
+	if err != nil {
+		return nil, err
 	}
-	return bodies
-}
 
-func analyzeForLoopBody(ctx context.Context, cgs map[string]*analysis.CallGraph, pkg *packages.Package, fns []*ssa.Function, bodies []*ast.BlockStmt, opt *analysis.Option) []*FoundLoopedQuery {
 	results := make([]*FoundLoopedQuery, 0)
-	for _, fn := range fns {
-		for _, block := range fn.Blocks {
-			for _, instr := range block.Instrs {
-				if n := withInForLoop(instr, bodies); n > 0 { // Check within the for loop
-					if call, ok := instr.(*ssa.Call); ok {
-						if callee := call.Call.StaticCallee(); callee != nil && callee.Pkg != nil {
-							if _, ok := analysis.CheckIfTargetFunction(ctx, &call.Call, opt); ok ||
-								(cgs[callee.Pkg.Pkg.Path()] != nil && cgs[callee.Pkg.Pkg.Path()].Nodes[callee.Name()] != nil) {
-								results = append(results, &FoundLoopedQuery{Func: fn, Callee: callee, Call: call, Position: pkg.Fset.Position(call.Pos()), N: n})
-							}
-						}
-					}
-				}
-			}
+	for _, pkg := range pkgs {
+		ssaProg, err := ssautil.BuildSSA(pkg)
+		if err != nil {
+			return nil, err
+		}
+		isTarget := func(call *ssa.CallCommon) bool {
+			_, ok := result.CheckIfTargetFunction(ctx, call, opt)
+			return ok
+		}
+		for _, c := range lint.FindLoopedCalls(ssaProg.SrcFuncs, result.CallGraph, isTarget) {
+			results = append(results, &FoundLoopedQuery{Func: c.Func, Callee: c.Callee, Call: c.Call, Position: pkg.Fset.Position(c.Call.Pos()), N: c.Depth})
 		}
 	}
-	return results
+
+	slices.SortFunc(results, func(a, b *FoundLoopedQuery) int { return strings.Compare(a.Position.String(), b.Position.String()) })
+	return results, nil
 }
 
-func withInForLoop(instr ssa.Instruction, bodies []*ast.BlockStmt) int {
-	if instr.Pos() == 0 {
-		return 0
-	}
-	var cnt int
-	for _, body := range bodies {
-		if body.Pos() <= instr.Pos() && instr.Pos() <= body.End() {
-			cnt++
+func calleeString(callee *ssa.Function) string {
+	return analysis.FuncID(callee)
+}
+
+// loopedQueryHashes returns the hashes of the queries executed by the call in the loop.
+// The call is matched by the position since the SSA of the loop is built separately from the one of the queries.
+func loopedQueryHashes(res *FoundLoopedQuery, qrs analysis.QueryResults) []string {
+	hashes := make([]string, 0)
+	for _, qr := range qrs {
+		if qr.Posx.Func.Prog == nil {
+			continue // queries from comments outside functions
+		}
+		fset := qr.Posx.Func.Prog.Fset
+		if slices.ContainsFunc(qr.Posx.Pos, func(p token.Pos) bool { return p.IsValid() && fset.Position(p) == res.Position }) {
+			for _, q := range qr.Queries() {
+				hashes = append(hashes, q.Hash())
+			}
 		}
 	}
-	return cnt
+	return hashes
 }
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/sebdah/goldie/v2"
+	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
@@ -26,7 +27,7 @@ func Test_runLoop_singleProject(t *testing.T) {
 	v.Set("format", "table")
 	v.Set("v", 1)
 
-	err := runLoop(cmd, v)
+	err := runLoop(cmd, v, afero.NewOsFs())
 	assert.NoError(t, err)
 }
 
@@ -46,7 +47,7 @@ func Test_runLoop(t *testing.T) {
 			v.Set("format", "table")
 			v.Set("analyze-funcs", []string{"github.com/isucon/isucon12-qualify/webapp/go.dbOrTx.GetContext@2", "github.com/isucon/isucon12-qualify/webapp/go.dbOrTx.SelectContext@2", "github.com/isucon/isucon12-qualify/webapp/go.dbOrTx.ExecContext@1"})
 
-			err := runLoop(cmd, v)
+			err := runLoop(cmd, v, afero.NewOsFs())
 			require.NoError(t, err)
 
 			g := goldie.New(t)
@@ -54,3 +55,22 @@ func Test_runLoop(t *testing.T) {
 		})
 	}
 }
+
+func Test_runLoop_sarif(t *testing.T) {
+	t.Parallel()
+	cmd := &cobra.Command{}
+	cmd.SetContext(context.Background())
+	buf := &bytes.Buffer{}
+	cmd.SetOut(buf)
+	cmd.SetErr(io.Discard)
+	v := viper.New()
+	v.Set("dir", "./testdata/src/lint")
+	v.Set("pattern", "./...")
+	v.Set("format", "sarif")
+
+	err := runLoop(cmd, v, afero.NewOsFs())
+	require.NoError(t, err)
+
+	g := goldie.New(t)
+	g.Assert(t, "lint.loop-sarif", buf.Bytes())
+}
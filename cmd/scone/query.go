@@ -1,8 +1,10 @@
 package main
 
 import (
+	"cmp"
 	"fmt"
 	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/cockroachdb/errors"
@@ -10,35 +12,40 @@ import (
 	"github.com/haijima/analysisutil/ssautil"
 	"github.com/haijima/scone/internal/analysis"
 	"github.com/haijima/scone/internal/sql"
+	"github.com/haijima/scone/internal/stats"
 	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
-func NewQueryCommand(v *viper.Viper, _ afero.Fs) *cobra.Command {
+func NewQueryCommand(v *viper.Viper, fs afero.Fs) *cobra.Command {
 	cmd := &cobra.Command{}
 	cmd.Use = "query"
 	cmd.Aliases = []string{"queries"}
 	cmd.Short = "List SQL queries"
-	cmd.RunE = func(cmd *cobra.Command, _ []string) error { return runQuery(cmd, v) }
+	cmd.RunE = func(cmd *cobra.Command, _ []string) error { return runQuery(cmd, v, fs) }
 
-	cmd.Flags().String("format", "table", "The output format {table|md|csv|tsv|html|simple}")
+	cmd.Flags().String("format", "table", formatUsage())
 	cmd.Flags().StringSlice("sort", []string{"file"}, "The sort `keys` {"+strings.Join(sortableColumns, "|")+"}")
 	cmd.Flags().StringSlice("cols", []string{}, "The `columns` to show {"+strings.Join(headerColumns, "|")+"}")
 	cmd.Flags().Bool("no-header", false, "Hide header")
 	cmd.Flags().Bool("no-rownum", false, "Hide row number")
 	cmd.Flags().Bool("full-package-path", false, "Show full package path")
 	cmd.Flags().Bool("expand-query-group", false, "Expand query group")
+	cmd.Flags().String("group-by", "", "Group queries by the `key` {fingerprint}")
+	addStatsFlags(cmd)
 
 	return cmd
 }
 
-var headerColumns = []string{"package", "package-path", "file", "function", "type", "tables", "hash", "query", "raw-query"}
+var headerColumns = []string{"package", "package-path", "file", "function", "type", "tables", "hash", "query", "raw-query", "select-columns", "write-columns", "order-by-columns", "group-by-columns", "fingerprint", "exec-count", "total-time", "avg-time", "rows-examined"}
 var defaultHeaderIndex = []int{0, 1, 2, 3, 4, 5, 6, 7}
-var sortableColumns = []string{"file", "function", "type", "tables", "hash"}
+var defaultGroupHeaderIndex = []int{4, 5, 13}
+var statsHeaderIndex = []int{14, 15, 16, 17}
+var sortableColumns = []string{"file", "function", "type", "tables", "hash", "fingerprint", "exec-count", "total-time", "avg-time", "rows-examined"}
 
-func runQuery(cmd *cobra.Command, v *viper.Viper) error {
+func runQuery(cmd *cobra.Command, v *viper.Viper, fs afero.Fs) error {
 	dir := v.GetString("dir")
 	pattern := v.GetString("pattern")
 	format := v.GetString("format")
@@ -48,8 +55,7 @@ func runQuery(cmd *cobra.Command, v *viper.Viper) error {
 	sortKeys := v.GetStringSlice("sort")
 	expandQueryGroup := v.GetBool("expand-query-group")
 	showFullPackagePath := v.GetBool("full-package-path")
-	filter := v.GetString("filter")
-	additionalFuncs := v.GetStringSlice("analyze-funcs")
+	groupBy := v.GetString("group-by")
 	if !mapset.NewSet(sortKeys...).IsSubset(mapset.NewSet(sortableColumns...)) {
 		return errors.Newf("unknown sort key: %s", mapset.NewSet(sortKeys...).Difference(mapset.NewSet(sortableColumns...)).ToSlice())
 	}
@@ -59,17 +65,52 @@ func runQuery(cmd *cobra.Command, v *viper.Viper) error {
 	if !mapset.NewSet(cols...).IsSubset(mapset.NewSet(headerColumns...)) {
 		return errors.Newf("unknown columns: %s", mapset.NewSet(cols...).Difference(mapset.NewSet(headerColumns...)).ToSlice())
 	}
-	if !slices.Contains([]string{"table", "md", "csv", "tsv", "html", "simple"}, format) {
-		return errors.Newf("unknown format: %s", format)
+	if err := validateFormat(format); err != nil {
+		return err
+	}
+	if groupBy != "" && groupBy != "fingerprint" {
+		return errors.Newf("unknown group-by key: %s", groupBy)
 	}
 
-	queryResults, _, err := analysis.Analyze(cmd.Context(), dir, pattern, analysis.NewOption(filter, additionalFuncs))
+	opt, err := newOption(v, fs)
+	if err != nil {
+		return err
+	}
+	ss, err := loadStats(v, fs)
+	if err != nil {
+		return err
+	}
+	result, err := analysis.Analyze(cmd.Context(), dir, pattern, opt)
 	if err != nil {
 		return err
 	}
-	slices.SortFunc(queryResults, sortQuery(sortKeys))
+	queryResults := result.QueryResults
+	slices.SortFunc(queryResults, sortQuery(sortKeys, ss))
+	groups := groupQuery(queryResults, groupBy)
 
-	printOpt := &PrintQueryOption{Cols: defaultHeaderIndex, NoHeader: noHeader, NoRowNum: noRowNum, ExpandQueryGroup: expandQueryGroup, ShowFullPackagePath: showFullPackagePath}
+	if slices.Contains(jsonFormats, format) {
+		w, err := newJSONWriter("query", dir)
+		if err != nil {
+			return err
+		}
+		w.stats = ss
+		for i, group := range groups {
+			for _, qr := range group {
+				for _, q := range qr.Queries() {
+					w.add(w.query(i+1, q, qr))
+				}
+			}
+		}
+		return w.write(cmd.OutOrStdout(), format)
+	}
+
+	printOpt := &PrintQueryOption{Cols: defaultHeaderIndex, NoHeader: noHeader, NoRowNum: noRowNum, ExpandQueryGroup: expandQueryGroup, ShowFullPackagePath: showFullPackagePath, Stats: ss}
+	if groupBy != "" {
+		printOpt.Cols = defaultGroupHeaderIndex
+	}
+	if ss != nil {
+		printOpt.Cols = slices.Concat(printOpt.Cols, statsHeaderIndex)
+	}
 	if len(cols) > 0 {
 		printOpt.Cols = make([]int, 0, len(cols))
 		for _, col := range cols {
@@ -87,19 +128,30 @@ func runQuery(cmd *cobra.Command, v *viper.Viper) error {
 		} else {
 			header = table.Row{"#", "*"}
 		}
+		if groupBy != "" {
+			header = append(header, "count")
+		}
 		for _, col := range printOpt.Cols {
 			header = append(header, strings.ReplaceAll(headerColumns[col], "-", " "))
 		}
 		t.AppendHeader(header)
 	}
-	for i, qr := range queryResults {
+	for i, group := range groups {
+		qr := group[0] // the queries of the group are shown by the first one
 		for j, q := range qr.Queries() {
-			r := slices.Insert(row(q, qr.Posx, printOpt), 0, "")
+			r := row(q, qr.Posx, printOpt)
+			if groupBy != "" {
+				r = slices.Insert(r, 0, table.Row{len(group)}...)
+			}
+			r = slices.Insert(r, 0, "")
 			if len(qr.Queries()) > 1 && expandQueryGroup {
 				r[0] = fmt.Sprintf("P%d", j+1)
 			} else if len(qr.Queries()) > 1 {
 				r[0] = "P"
 			}
+			if q.Dynamic {
+				r[0] = fmt.Sprintf("%sD", r[0])
+			}
 			if qr.FromComment {
 				r[0] = fmt.Sprintf("%sC", r[0])
 			}
@@ -113,38 +165,39 @@ func runQuery(cmd *cobra.Command, v *viper.Viper) error {
 		}
 	}
 
-	switch format {
-	case "table":
-		t.Render()
-	case "md":
-		t.RenderMarkdown()
-	case "csv":
-		t.RenderCSV()
-	case "tsv":
-		t.RenderTSV()
-	case "html":
-		t.RenderHTML()
-	case "simple":
-		t.Style().Options.DrawBorder = false
-		t.Style().Options.SeparateHeader = false
-		t.Style().Options.SeparateRows = false
-		t.Style().Box.MiddleVertical = " "
-		t.Render()
-	}
+	renderTable(t, format)
 	return nil
 }
 
-func sortQuery(sortKeys []string) func(a, b *analysis.QueryResult) int {
+// sortQuery returns the comparator of the sort keys. The keys of the statistics are sorted in descending order.
+func sortQuery(sortKeys []string, ss stats.Stats) func(a, b *analysis.QueryResult) int {
 	return func(aa, bb *analysis.QueryResult) int {
 		return slices.CompareFunc(sortKeys, sortKeys, func(k, _ string) int {
-			if k == "type" {
+			as, bs := ss.Get(aa.Queries()[0]), ss.Get(bb.Queries()[0])
+			if as == nil {
+				as = &stats.Stat{}
+			}
+			if bs == nil {
+				bs = &stats.Stat{}
+			}
+			if k == "exec-count" {
+				return cmp.Compare(bs.Count, as.Count)
+			} else if k == "total-time" {
+				return cmp.Compare(bs.TotalTime, as.TotalTime)
+			} else if k == "avg-time" {
+				return cmp.Compare(bs.AvgTime(), as.AvgTime())
+			} else if k == "rows-examined" {
+				return cmp.Compare(bs.RowsExamined, as.RowsExamined)
+			} else if k == "type" {
 				return int(aa.Queries()[0].Kind) - int(bb.Queries()[0].Kind)
 			} else if k == "tables" {
 				return strings.Compare(aa.Queries()[0].MainTable, bb.Queries()[0].MainTable)
 			} else if k == "hash" {
 				return strings.Compare(aa.Queries()[0].Hash(), bb.Queries()[0].Hash())
+			} else if k == "fingerprint" {
+				return strings.Compare(aa.Queries()[0].Fingerprint(), bb.Queries()[0].Fingerprint())
 			} else if k == "function" {
-				return strings.Compare(aa.Posx.Func.Name(), bb.Posx.Func.Name())
+				return strings.Compare(analysis.FuncName(aa.Posx.Func), analysis.FuncName(bb.Posx.Func))
 			} else if k == "file" {
 				return aa.Posx.Compare(bb.Posx)
 			}
@@ -153,12 +206,39 @@ func sortQuery(sortKeys []string) func(a, b *analysis.QueryResult) int {
 	}
 }
 
+// groupQuery groups the query results by the key in the order of their first appearance.
+// Each query result is a group by itself if key is empty.
+func groupQuery(queryResults []*analysis.QueryResult, key string) [][]*analysis.QueryResult {
+	groups := make([][]*analysis.QueryResult, 0, len(queryResults))
+	index := make(map[string]int)
+	for _, qr := range queryResults {
+		if key != "fingerprint" {
+			groups = append(groups, []*analysis.QueryResult{qr})
+			continue
+		}
+		fps := make([]string, 0, len(qr.Queries()))
+		for _, q := range qr.Queries() {
+			fps = append(fps, q.Fingerprint())
+		}
+		slices.Sort(fps)
+		fp := strings.Join(slices.Compact(fps), "\n")
+		if i, ok := index[fp]; ok {
+			groups[i] = append(groups[i], qr)
+			continue
+		}
+		index[fp] = len(groups)
+		groups = append(groups, []*analysis.QueryResult{qr})
+	}
+	return groups
+}
+
 type PrintQueryOption struct {
 	Cols                []int
 	NoHeader            bool
 	NoRowNum            bool
 	ExpandQueryGroup    bool
 	ShowFullPackagePath bool
+	Stats               stats.Stats
 }
 
 func row(q *sql.Query, pos *ssautil.Posx, opt *PrintQueryOption) table.Row {
@@ -166,12 +246,24 @@ func row(q *sql.Query, pos *ssautil.Posx, opt *PrintQueryOption) table.Row {
 		pos.Package().Name(),
 		pos.PackagePath(!opt.ShowFullPackagePath),
 		pos.PositionString(),
-		pos.Func.Name(),
+		analysis.FuncName(pos.Func),
 		q.Kind.ColoredString(),
 		strings.Join(q.Tables, ", "),
 		q.Hash(),
 		q.String(),
 		q.Raw,
+		strings.Join(q.SelectColumns, ", "),
+		strings.Join(q.WriteColumns, ", "),
+		strings.Join(q.OrderByColumns, ", "),
+		strings.Join(q.GroupByColumns, ", "),
+		q.Fingerprint(),
+		"", "", "", "",
+	}
+	if s := opt.Stats.Get(q); s != nil {
+		fullRow[14] = strconv.FormatInt(s.Count, 10)
+		fullRow[15] = durationString(s.TotalTime)
+		fullRow[16] = durationString(s.AvgTime())
+		fullRow[17] = strconv.FormatInt(s.RowsExamined, 10)
 	}
 	var res table.Row
 	for _, col := range opt.Cols {
@@ -7,13 +7,15 @@ import (
 	"testing"
 
 	"github.com/sebdah/goldie/v2"
+	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/require"
 )
 
 func Test_runQuery(t *testing.T) {
-	tests := []string{"isucon10-qualify", "isucon10-final", "isucon11-qualify", "isucon11-final", "isucon12-qualify", "isucon12-final", "isucon13"}
+	tests := []string{"isucon10-qualify", "isucon10-final", "isucon11-qualify", "isucon11-final", "isucon12-qualify", "isucon12-final", "isucon13", "gorm", "pgx", "wrapper", "template", "stmt"}
+	dialects := map[string]string{"pgx": "postgres"}
 	for _, tt := range tests {
 		t.Run(tt, func(t *testing.T) {
 			t.Parallel()
@@ -26,9 +28,12 @@ func Test_runQuery(t *testing.T) {
 			v.Set("dir", "./testdata/src/"+tt)
 			v.Set("pattern", "./...")
 			v.Set("format", "table")
+			if dialect, ok := dialects[tt]; ok {
+				v.Set("dialect", dialect)
+			}
 			v.Set("analyze-funcs", []string{"github.com/isucon/isucon12-qualify/webapp/go.dbOrTx.GetContext@2", "github.com/isucon/isucon12-qualify/webapp/go.dbOrTx.SelectContext@2", "github.com/isucon/isucon12-qualify/webapp/go.dbOrTx.ExecContext@1"})
 
-			err := runQuery(cmd, v)
+			err := runQuery(cmd, v, afero.NewOsFs())
 			require.NoError(t, err)
 
 			g := goldie.New(t)
@@ -36,3 +41,82 @@ func Test_runQuery(t *testing.T) {
 		})
 	}
 }
+
+func Test_runQuery_cols(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.SetContext(context.Background())
+	buf := &bytes.Buffer{}
+	cmd.SetOut(buf)
+	cmd.SetErr(io.Discard)
+	v := viper.New()
+	v.Set("dir", "./testdata/src/tx")
+	v.Set("pattern", "./...")
+	v.Set("format", "table")
+	v.Set("cols", []string{"function", "type", "select-columns", "write-columns", "order-by-columns", "group-by-columns"})
+
+	err := runQuery(cmd, v, afero.NewOsFs())
+	require.NoError(t, err)
+
+	g := goldie.New(t)
+	g.Assert(t, "tx.query-cols", buf.Bytes())
+}
+
+func Test_runQuery_schema(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.SetContext(context.Background())
+	buf := &bytes.Buffer{}
+	cmd.SetOut(buf)
+	cmd.SetErr(io.Discard)
+	v := viper.New()
+	v.Set("dir", "./testdata/src/tx")
+	v.Set("pattern", "./...")
+	v.Set("format", "table")
+	v.Set("schema", "./testdata/src/tx/schema.sql")
+	v.Set("cols", []string{"function", "type", "tables", "select-columns", "write-columns"})
+
+	err := runQuery(cmd, v, afero.NewOsFs())
+	require.NoError(t, err)
+
+	g := goldie.New(t)
+	g.Assert(t, "tx.query-schema", buf.Bytes())
+}
+
+func Test_runQuery_groupByFingerprint(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.SetContext(context.Background())
+	buf := &bytes.Buffer{}
+	cmd.SetOut(buf)
+	cmd.SetErr(io.Discard)
+	v := viper.New()
+	v.Set("dir", "./testdata/src/fingerprint")
+	v.Set("pattern", "./...")
+	v.Set("format", "table")
+	v.Set("group-by", "fingerprint")
+
+	err := runQuery(cmd, v, afero.NewOsFs())
+	require.NoError(t, err)
+
+	g := goldie.New(t)
+	g.Assert(t, "fingerprint.query-group", buf.Bytes())
+}
+
+func Test_runQuery_stats(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.SetContext(context.Background())
+	buf := &bytes.Buffer{}
+	cmd.SetOut(buf)
+	cmd.SetErr(io.Discard)
+	v := viper.New()
+	v.Set("dir", "./testdata/src/lint")
+	v.Set("pattern", "./...")
+	v.Set("format", "table")
+	v.Set("stats", "./testdata/lint.pt-query-digest.json")
+	v.Set("sort", []string{"total-time"})
+	v.Set("cols", []string{"function", "type", "tables", "exec-count", "total-time", "avg-time", "rows-examined"})
+
+	err := runQuery(cmd, v, afero.NewOsFs())
+	require.NoError(t, err)
+
+	g := goldie.New(t)
+	g.Assert(t, "lint.query-stats", buf.Bytes())
+}
@@ -9,13 +9,13 @@ import (
 	"github.com/spf13/viper"
 )
 
-func NewReportCmd(v *viper.Viper, _ afero.Fs) *cobra.Command {
+func NewReportCmd(v *viper.Viper, fs afero.Fs) *cobra.Command {
 	cmd := &cobra.Command{}
 	cmd.Use = "report"
 	cmd.Aliases = []string{"reports"}
 	cmd.Args = cobra.NoArgs
 	cmd.RunE = func(cmd *cobra.Command, _ []string) error {
-		return RunReport(cmd, v)
+		return RunReport(cmd, v, fs)
 	}
 
 	cmd.Flags().String("format", "text", "The output format {text|html}")
@@ -23,7 +23,7 @@ func NewReportCmd(v *viper.Viper, _ afero.Fs) *cobra.Command {
 	return cmd
 }
 
-func RunReport(cmd *cobra.Command, v *viper.Viper) error {
+func RunReport(cmd *cobra.Command, v *viper.Viper, fs afero.Fs) error {
 
 	format := v.GetString("format")
 	if format != "text" && format != "html" {
@@ -35,26 +35,26 @@ func RunReport(cmd *cobra.Command, v *viper.Viper) error {
 	var buf bytes.Buffer
 	cmd.SetOut(&buf)
 
-	if err := runCrud(cmd, v); err != nil {
+	if err := runCrud(cmd, v, fs); err != nil {
 		return err
 	}
 
 	fmt.Println(buf.String())
 	buf.Truncate(0)
 
-	if err := runTable(cmd, v); err != nil {
+	if err := runTable(cmd, v, fs); err != nil {
 		return err
 	}
 	fmt.Println(buf.String())
 	buf.Truncate(0)
 
-	if err := runQuery(cmd, v); err != nil {
+	if err := runQuery(cmd, v, fs); err != nil {
 		return err
 	}
 	fmt.Println(buf.String())
 	buf.Truncate(0)
 
-	if err := runLoop(cmd, v); err != nil {
+	if err := runLoop(cmd, v, fs); err != nil {
 		return err
 	}
 	fmt.Println(buf.String())
@@ -2,9 +2,14 @@ package main
 
 import (
 	"log/slog"
+	"slices"
+	"strings"
 
+	"github.com/cockroachdb/errors"
 	"github.com/fatih/color"
 	"github.com/haijima/cobrax"
+	"github.com/haijima/scone/internal/analysis"
+	"github.com/haijima/scone/internal/sql"
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -38,7 +43,11 @@ func NewRootCmd(v *viper.Viper, fs afero.Fs) *cobra.Command {
 	cmd.PersistentFlags().StringP("pattern", "p", "./...", "The pattern to analyze")
 	cmd.PersistentFlags().String("filter", "", "filter queries by `pattern`")
 	cmd.PersistentFlags().StringSlice("analyze-funcs", []string{}, "The names of functions to analyze additionally. format: `<func pattern>@<argument index>`")
+	cmd.PersistentFlags().String("dialect", "mysql", "The SQL dialect of queries. One of: mysql|postgres|sqlite")
+	cmd.PersistentFlags().String("schema", "", "The DDL `file` which defines tables (CREATE TABLE statements)")
+	cmd.PersistentFlags().String("callgraph", analysis.CallGraphStatic, "The algorithm to build the call graph of the whole program. One of: "+strings.Join(analysis.CallGraphAlgorithms, "|"))
 	_ = cmd.MarkFlagDirname("dir")
+	_ = cmd.MarkFlagFilename("schema", "sql")
 
 	cmd.AddCommand(NewCallgraphCommand(v, fs))
 	cmd.AddCommand(NewQueryCommand(v, fs))
@@ -46,8 +55,41 @@ func NewRootCmd(v *viper.Viper, fs afero.Fs) *cobra.Command {
 	cmd.AddCommand(NewGenConfCmd(v, fs))
 	cmd.AddCommand(NewCrudCmd(v, fs))
 	cmd.AddCommand(NewLoopCmd(v, fs))
+	cmd.AddCommand(NewSuggestFuncsCmd(v, fs))
+	cmd.AddCommand(NewTxCommand(v, fs))
+	cmd.AddCommand(NewIndexCommand(v, fs))
+	cmd.AddCommand(NewLintCommand(v, fs))
+	cmd.AddCommand(NewSnapshotCommand(v, fs))
+	cmd.AddCommand(NewDiffCommand(v, fs))
+	cmd.AddCommand(NewSlowlogCommand(v, fs))
 
 	cmd.SetGlobalNormalizationFunc(cobrax.SnakeToKebab)
 
 	return cmd
 }
+
+func newOption(v *viper.Viper, fs afero.Fs) (*analysis.Option, error) {
+	dialect, err := sql.ParseDialect(v.GetString("dialect"))
+	if err != nil {
+		return nil, err
+	}
+	opt := analysis.NewOption(v.GetString("filter"), v.GetStringSlice("analyze-funcs"))
+	opt.Dialect = dialect
+	opt.CallGraph = v.GetString("callgraph")
+	if opt.CallGraph == "" {
+		opt.CallGraph = analysis.CallGraphStatic
+	}
+	if !slices.Contains(analysis.CallGraphAlgorithms, opt.CallGraph) {
+		return nil, errors.Newf("unknown callgraph algorithm: %s", opt.CallGraph)
+	}
+	if path := v.GetString("schema"); path != "" {
+		ddl, err := afero.ReadFile(fs, path)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read schema file")
+		}
+		if opt.Schema, err = sql.ParseSchema(string(ddl)); err != nil {
+			return nil, err
+		}
+	}
+	return opt, nil
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/spf13/afero"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewRootCmd(t *testing.T) {
@@ -19,5 +20,20 @@ func TestNewRootCmd(t *testing.T) {
 
 	assert.Equal(t, "scone", cmd.Use)
 	assert.NotNil(t, cmd.Commands())
-	assert.Equal(t, 6, len(cmd.Commands()))
+	assert.Equal(t, 13, len(cmd.Commands()))
+}
+
+func Test_newOption_schema(t *testing.T) {
+	t.Parallel()
+	v := viper.New()
+	v.Set("schema", "schema.sql")
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "schema.sql", []byte("CREATE TABLE users (id BIGINT NOT NULL PRIMARY KEY, name VARCHAR(255) NOT NULL);"), 0644))
+
+	opt, err := newOption(v, fs)
+	require.NoError(t, err)
+	assert.NotNil(t, opt.Schema)
+
+	_, err = newOption(v, afero.NewMemMapFs())
+	assert.ErrorContains(t, err, "failed to read schema file")
 }
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"go/token"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/haijima/scone/internal/analysis"
+	"github.com/haijima/scone/internal/lint"
+	"github.com/haijima/scone/internal/sarif"
+	"github.com/haijima/scone/internal/sql"
+)
+
+const (
+	parseFailureRuleID = "parse-failure"
+	loopRuleID         = "loop"
+)
+
+// sarifWriter writes findings of commands in SARIF format.
+type sarifWriter struct {
+	log  *sarif.Log
+	root string // the artifact URIs are relative to this directory
+}
+
+// newSarifWriter returns a writer whose artifact URIs are relative to dir, which is the root of the analysis.
+func newSarifWriter(dir string) (*sarifWriter, error) {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &sarifWriter{log: sarif.NewLog("scone", version, "https://github.com/haijima/scone"), root: root}, nil
+}
+
+func (w *sarifWriter) add(ruleID, level, message string, pos token.Position, fingerprint string) {
+	uri := pos.Filename
+	if uri == "" {
+		w.log.AddResult(ruleID, level, message, "", 0, 0, fingerprint)
+		return
+	}
+	if rel, err := filepath.Rel(w.root, pos.Filename); err == nil {
+		uri = rel
+	}
+	w.log.AddResult(ruleID, level, message, filepath.ToSlash(uri), pos.Line, pos.Column, fingerprint)
+}
+
+// addParseFailures adds the queries which could not be analyzed.
+func (w *sarifWriter) addParseFailures(failures []*analysis.ParseFailure) {
+	w.log.AddRule(parseFailureRuleID, "The query could not be analyzed statically", sarif.LevelWarning)
+	for _, f := range failures {
+		message, key := f.Message, f.Message
+		if f.SQL != "" {
+			message += ": " + f.SQL
+			key = (&sql.Query{Raw: f.SQL}).Hash()
+		}
+		w.add(parseFailureRuleID, sarif.LevelWarning, message, f.Posx.Position(), fingerprint(parseFailureRuleID, analysis.FuncID(f.Posx.Func), key))
+	}
+}
+
+// addLoopedQueries adds the calls in loops found by `scone loop`.
+func (w *sarifWriter) addLoopedQueries(results []*FoundLoopedQuery, qrs analysis.QueryResults) {
+	w.log.AddRule(loopRuleID, "Queries executed in for loops cause N+1 problems", sarif.LevelWarning)
+	for _, res := range results {
+		callee := calleeString(res.Callee)
+		hashes := loopedQueryHashes(res, qrs)
+		w.add(loopRuleID, sarif.LevelWarning, fmt.Sprintf("%s is called in %d nested loop(s)", callee, res.N), res.Position, fingerprint(loopRuleID, analysis.FuncID(res.Func), callee, strings.Join(hashes, ",")))
+	}
+}
+
+// addDiagnostics adds the diagnostics of lint rules.
+func (w *sarifWriter) addDiagnostics(rules []*lint.Rule, diagnostics []*lint.Diagnostic) {
+	for _, r := range rules {
+		w.log.AddRule(r.Name, r.Doc, sarifLevel(r.Severity))
+	}
+	for _, d := range diagnostics {
+		key := d.Message
+		if d.Query != nil {
+			key = d.Query.Hash()
+		}
+		w.add(d.Rule.Name, sarifLevel(d.Severity), d.Message, d.Posx.Position(), fingerprint(d.Rule.Name, analysis.FuncID(d.Posx.Func), key))
+	}
+}
+
+func (w *sarifWriter) write(out io.Writer) error {
+	return w.log.Write(out)
+}
+
+func sarifLevel(s lint.Severity) string {
+	switch s {
+	case lint.Error:
+		return sarif.LevelError
+	case lint.Warning:
+		return sarif.LevelWarning
+	default:
+		return sarif.LevelNote
+	}
+}
+
+// fingerprint returns the hash of the parts. The parts should not contain line numbers so that the finding is tracked when the code is moved.
+func fingerprint(parts ...string) string {
+	h := sha1.New()
+	h.Write([]byte(strings.Join(parts, "\x00")))
+	return fmt.Sprintf("%x", h.Sum(nil))[:16]
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"cmp"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/haijima/scone/internal/analysis"
+	"github.com/haijima/scone/internal/sql"
+	"github.com/haijima/scone/internal/stats"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func NewSlowlogCommand(v *viper.Viper, fs afero.Fs) *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = "slowlog <file>"
+	cmd.Short = "Show the statistics of MySQL slow query log for each query in the source code"
+	cmd.Long = "Parse MySQL slow query log, JSON report of pt-query-digest or dump of performance_schema.events_statements_summary_by_digest, and match the queries to the queries in the source code by their fingerprints.\nThe total and average time, rows examined and the number of calls are shown for each position of the query and the endpoints which reach it."
+	cmd.Args = cobra.ExactArgs(1)
+	cmd.RunE = func(cmd *cobra.Command, args []string) error { return runSlowlog(cmd, v, fs, args[0]) }
+
+	cmd.Flags().String("format", "table", formatUsage())
+	cmd.Flags().String("sort", "total", "The sort `key` {"+strings.Join(slowlogSortKeys, "|")+"}")
+	cmd.Flags().String("stats-format", stats.FormatAuto, "The format of the file {"+strings.Join(stats.Formats, "|")+"}")
+
+	return cmd
+}
+
+var slowlogSortKeys = []string{"total", "avg", "max", "count", "rows-examined"}
+
+// slowlogRow is the statistics of a fingerprint and one of the positions of the queries which have it.
+type slowlogRow struct {
+	stat      *stats.Stat
+	qr        *analysis.QueryResult // nil if the query is not found in the source code
+	q         *sql.Query
+	shared    bool // the fingerprint matches multiple positions, so that the statistics are the total of them
+	endpoints []string
+}
+
+func runSlowlog(cmd *cobra.Command, v *viper.Viper, fs afero.Fs, file string) error {
+	dir := v.GetString("dir")
+	pattern := v.GetString("pattern")
+	format := v.GetString("format")
+	sortKey := v.GetString("sort")
+	statsFormat := v.GetString("stats-format")
+	if statsFormat == "" {
+		statsFormat = stats.FormatAuto
+	}
+
+	if err := validateFormat(format); err != nil {
+		return err
+	}
+	if !slices.Contains(slowlogSortKeys, sortKey) {
+		return errors.Newf("unknown sort key: %s", sortKey)
+	}
+
+	f, err := fs.Open(file)
+	if err != nil {
+		return errors.Wrap(err, "failed to open slow log")
+	}
+	defer f.Close()
+	ss, err := stats.Read(f, statsFormat)
+	if err != nil {
+		return err
+	}
+
+	opt, err := newOption(v, fs)
+	if err != nil {
+		return err
+	}
+	result, err := analysis.Analyze(cmd.Context(), dir, pattern, opt)
+	if err != nil {
+		return err
+	}
+	queryResults, cg := result.QueryResults, result.CallGraph
+	eps := make(map[string][]string)
+	if endpoints, err := findEndpoints(dir, pattern); err != nil {
+		slog.Warn("Endpoints are not shown since they are not found", slog.Any("error", err))
+	} else {
+		eps = endpointsByFunc(endpoints, cg)
+	}
+
+	rows := slowlogRows(ss, queryResults, eps)
+	slices.SortStableFunc(rows, func(a, b *slowlogRow) int {
+		switch sortKey {
+		case "avg":
+			return cmp.Compare(b.stat.AvgTime(), a.stat.AvgTime())
+		case "max":
+			return cmp.Compare(b.stat.MaxTime, a.stat.MaxTime)
+		case "count":
+			return cmp.Compare(b.stat.Count, a.stat.Count)
+		case "rows-examined":
+			return cmp.Compare(b.stat.RowsExamined, a.stat.RowsExamined)
+		}
+		return cmp.Compare(b.stat.TotalTime, a.stat.TotalTime)
+	})
+
+	if slices.Contains(jsonFormats, format) {
+		w, err := newJSONWriter("slowlog", dir)
+		if err != nil {
+			return err
+		}
+		for _, r := range rows {
+			rec := &jsonSlowQuery{
+				Type:            "slowquery",
+				Fingerprint:     r.stat.Fingerprint,
+				Sample:          r.stat.Sample,
+				Count:           r.stat.Count,
+				TotalTime:       r.stat.TotalTime.Seconds(),
+				AvgTime:         r.stat.AvgTime().Seconds(),
+				MaxTime:         r.stat.MaxTime.Seconds(),
+				RowsSent:        r.stat.RowsSent,
+				RowsExamined:    r.stat.RowsExamined,
+				AvgRowsExamined: r.stat.AvgRowsExamined(),
+				Shared:          r.shared,
+				Endpoints:       nonNil(r.endpoints),
+			}
+			if r.qr != nil {
+				rec.Query = w.query(0, r.q, r.qr)
+			}
+			w.add(rec)
+		}
+		return w.write(cmd.OutOrStdout(), format)
+	}
+
+	t := table.NewWriter()
+	t.SetOutputMirror(cmd.OutOrStdout())
+	t.AppendHeader(table.Row{"#", "*", "Total", "Avg", "Max", "Count", "Rows examined", "Avg rows", "File", "Function", "Endpoints", "Query"})
+	for i, r := range rows {
+		var mark, file, function string
+		if r.qr == nil {
+			mark = "N"
+		} else {
+			file = r.qr.Posx.PositionString()
+			function = analysis.FuncName(r.qr.Posx.Func)
+			if r.shared {
+				mark = "S"
+			}
+		}
+		t.AppendRow(table.Row{
+			i + 1,
+			mark,
+			durationString(r.stat.TotalTime),
+			durationString(r.stat.AvgTime()),
+			durationString(r.stat.MaxTime),
+			r.stat.Count,
+			r.stat.RowsExamined,
+			int64(r.stat.AvgRowsExamined()),
+			file,
+			function,
+			strings.Join(r.endpoints, ", "),
+			(&sql.Query{Raw: r.stat.Fingerprint}).String(),
+		})
+	}
+	t.SetColumnConfigs([]table.ColumnConfig{{Name: "Total", Align: text.AlignRight}, {Name: "Avg", Align: text.AlignRight}, {Name: "Max", Align: text.AlignRight}})
+
+	renderTable(t, format)
+	return nil
+}
+
+// slowlogRows matches the statistics to the positions of the queries which have the same fingerprint.
+// The statistics which match no query are also returned without the position.
+func slowlogRows(ss stats.Stats, queryResults []*analysis.QueryResult, eps map[string][]string) []*slowlogRow {
+	type match struct {
+		qr *analysis.QueryResult
+		q  *sql.Query
+	}
+	matches := make(map[string][]match)
+	for _, qr := range queryResults {
+		for _, q := range qr.Queries() {
+			fp := q.Fingerprint()
+			if fp == "" || slices.ContainsFunc(matches[fp], func(m match) bool { return m.qr == qr }) {
+				continue
+			}
+			matches[fp] = append(matches[fp], match{qr: qr, q: q})
+		}
+	}
+
+	rows := make([]*slowlogRow, 0, len(ss))
+	for _, s := range ss.Sorted() {
+		ms := matches[s.Fingerprint]
+		if len(ms) == 0 {
+			rows = append(rows, &slowlogRow{stat: s})
+			continue
+		}
+		for _, m := range ms {
+			rows = append(rows, &slowlogRow{stat: s, qr: m.qr, q: m.q, shared: len(ms) > 1, endpoints: eps[analysis.FuncID(m.qr.Posx.Func)]})
+		}
+	}
+	return rows
+}
+
+// durationString rounds the duration to be readable. e.g. 1.234567s, 12.345ms
+func durationString(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond).String()
+	case d >= time.Millisecond:
+		return d.Round(time.Microsecond).String()
+	}
+	return d.String()
+}
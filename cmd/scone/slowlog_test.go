@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/sebdah/goldie/v2"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_runSlowlog(t *testing.T) {
+	tests := []struct {
+		format string
+		sort   string
+		golden string
+	}{
+		{"table", "total", "lint.slowlog"},
+		{"jsonl", "count", "lint.slowlog-jsonl"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			cmd.SetContext(context.Background())
+			buf := &bytes.Buffer{}
+			cmd.SetOut(buf)
+			cmd.SetErr(io.Discard)
+			v := viper.New()
+			v.Set("dir", "./testdata/src/lint")
+			v.Set("pattern", "./...")
+			v.Set("format", tt.format)
+			v.Set("sort", tt.sort)
+
+			err := runSlowlog(cmd, v, afero.NewOsFs(), "./testdata/lint.slow.log")
+			require.NoError(t, err)
+
+			g := goldie.New(t)
+			g.Assert(t, tt.golden, buf.Bytes())
+		})
+	}
+}
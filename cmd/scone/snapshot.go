@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+
+	"github.com/cockroachdb/errors"
+	"github.com/haijima/scone/internal/analysis"
+	"github.com/haijima/scone/internal/baseline"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/exp/maps"
+)
+
+func NewSnapshotCommand(v *viper.Viper, fs afero.Fs) *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = "snapshot"
+	cmd.Short = "Write the current analysis to a baseline file"
+	cmd.Long = "Write queries, CRUD cells and N+1 loops to a baseline file, which is compared by `scone diff --baseline`.\nThe baseline does not contain positions, so moving code does not change it."
+	cmd.Args = cobra.NoArgs
+	cmd.RunE = func(cmd *cobra.Command, _ []string) error { return runSnapshot(cmd, v, fs) }
+
+	cmd.Flags().StringP("output", "o", "scone-baseline.json", "The baseline `file` to write. \"-\" writes to stdout")
+	_ = cmd.MarkFlagFilename("output", "json")
+
+	return cmd
+}
+
+func runSnapshot(cmd *cobra.Command, v *viper.Viper, fs afero.Fs) error {
+	output := v.GetString("output")
+
+	s, err := takeSnapshot(cmd.Context(), v, fs)
+	if err != nil {
+		return err
+	}
+	if output == "-" {
+		return s.Write(cmd.OutOrStdout())
+	}
+	f, err := fs.Create(output)
+	if err != nil {
+		return errors.Wrap(err, "failed to create baseline file")
+	}
+	defer f.Close()
+	if err := s.Write(f); err != nil {
+		return err
+	}
+	slog.Info("Wrote baseline", slog.String("file", output), slog.Int("queries", len(s.Queries)), slog.Int("crud", len(s.Crud)), slog.Int("loops", len(s.Loops)))
+	return nil
+}
+
+// takeSnapshot analyzes the queries, the CRUD matrix and the N+1 loops.
+// The CRUD matrix is empty if no endpoint is found, e.g. the web framework is not supported.
+func takeSnapshot(ctx context.Context, v *viper.Viper, fs afero.Fs) (*baseline.Snapshot, error) {
+	dir := v.GetString("dir")
+	pattern := v.GetString("pattern")
+
+	opt, err := newOption(v, fs)
+	if err != nil {
+		return nil, err
+	}
+	result, err := analysis.Analyze(ctx, dir, pattern, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	s := baseline.New()
+	for _, qr := range result.QueryResults {
+		for _, q := range qr.Queries() {
+			s.AddQuery(&baseline.Query{PackagePath: qr.Posx.Package().Path(), Function: analysis.FuncName(qr.Posx.Func), Hash: q.Hash(), Kind: q.Kind.String(), Tables: nonNil(q.Tables), Raw: q.Raw})
+		}
+	}
+
+	if endpoints, err := findEndpoints(dir, pattern); err != nil {
+		slog.Warn("CRUD matrix is not recorded since endpoints are not found", slog.Any("error", err))
+	} else {
+		crud := crudMatrix(endpoints, result.CallGraph)
+		for _, ep := range endpoints {
+			tables := maps.Keys(crud[ep])
+			slices.Sort(tables)
+			for _, table := range tables {
+				s.Crud = append(s.Crud, &baseline.Crud{Method: ep.Method, Path: ep.Path, Function: ep.FuncName, Table: table, Operations: crudString(crud[ep][table])})
+			}
+		}
+	}
+
+	loops, err := findLoopedQueries(ctx, dir, pattern, result, opt)
+	if err != nil {
+		return nil, err
+	}
+	for _, res := range loops {
+		s.AddLoop(&baseline.Loop{PackagePath: res.Func.Pkg.Pkg.Path(), Function: analysis.FuncName(res.Func), Callee: calleeString(res.Callee), Count: 1, Hashes: loopedQueryHashes(res, result.QueryResults)})
+	}
+
+	s.Sort()
+	return s, nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/sebdah/goldie/v2"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_runSnapshot(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.SetContext(context.Background())
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	fs := afero.NewMemMapFs()
+	v := viper.New()
+	v.Set("dir", "./testdata/src/lint")
+	v.Set("pattern", "./...")
+	v.Set("output", "baseline.json")
+
+	err := runSnapshot(cmd, v, fs)
+	require.NoError(t, err)
+
+	b, err := afero.ReadFile(fs, "baseline.json")
+	require.NoError(t, err)
+	g := goldie.New(t)
+	g.Assert(t, "lint.snapshot", b)
+}
+
+func Test_runDiff(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.SetContext(context.Background())
+	buf := &bytes.Buffer{}
+	cmd.SetOut(buf)
+	cmd.SetErr(io.Discard)
+	v := viper.New()
+	v.Set("dir", "./testdata/src/lint")
+	v.Set("pattern", "./...")
+	v.Set("baseline", "./testdata/lint.baseline.json")
+	v.Set("format", "table")
+	v.Set("fail-on", []string{"new-loop", "new-unknown", "new-write"})
+
+	err := runDiff(cmd, v, afero.NewOsFs())
+	assert.EqualError(t, err, "3 changes violating new-loop, new-unknown, new-write found")
+
+	g := goldie.New(t)
+	g.Assert(t, "lint.diff", buf.Bytes())
+}
+
+func Test_runDiff_failOn(t *testing.T) {
+	tests := []struct {
+		name   string
+		failOn []string
+		err    string
+	}{
+		{"none", []string{"none"}, ""},
+		{"new-loop", []string{"new-loop"}, "1 changes violating new-loop found"},
+		{"any", []string{"any"}, "6 changes violating any found"},
+		{"unknown policy", []string{"new-table"}, "unknown policy: new-table"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			cmd.SetContext(context.Background())
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			v := viper.New()
+			v.Set("dir", "./testdata/src/lint")
+			v.Set("pattern", "./...")
+			v.Set("baseline", "./testdata/lint.baseline.json")
+			v.Set("format", "jsonl")
+			v.Set("fail-on", tt.failOn)
+
+			err := runDiff(cmd, v, afero.NewOsFs())
+			if tt.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/haijima/scone/internal/analysis"
+	"github.com/haijima/scone/internal/sql"
+	"github.com/haijima/scone/internal/stats"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func addStatsFlags(cmd *cobra.Command) {
+	cmd.Flags().String("stats", "", "The `file` of runtime statistics of queries. MySQL slow query log, JSON report of pt-query-digest or dump of performance_schema.events_statements_summary_by_digest")
+	cmd.Flags().String("stats-format", stats.FormatAuto, "The format of the stats file {"+strings.Join(stats.Formats, "|")+"}")
+	_ = cmd.MarkFlagFilename("stats")
+}
+
+// loadStats reads the file given by --stats. It returns nil if the flag is not given.
+func loadStats(v *viper.Viper, fs afero.Fs) (stats.Stats, error) {
+	file := v.GetString("stats")
+	if file == "" {
+		return nil, nil
+	}
+	format := v.GetString("stats-format")
+	if format == "" {
+		format = stats.FormatAuto
+	}
+	if !slices.Contains(stats.Formats, format) {
+		return nil, errors.Newf("unknown stats format: %s", format)
+	}
+	f, err := fs.Open(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open stats file")
+	}
+	defer f.Close()
+	return stats.Read(f, format)
+}
+
+// tableStat is the total statistics of the queries which read or write a table.
+type tableStat struct {
+	Reads  *stats.Stat
+	Writes *stats.Stat
+}
+
+func (t *tableStat) String() string {
+	return fmt.Sprintf("R:%d W:%d", t.Reads.Count, t.Writes.Count)
+}
+
+// tableStats sums up the statistics of the queries for each table.
+// The statistics of a fingerprint are counted once even if the queries of the fingerprint are found at multiple positions.
+func tableStats(queryResults analysis.QueryResults, ss stats.Stats) map[string]*tableStat {
+	if ss == nil {
+		return nil
+	}
+	res := make(map[string]*tableStat)
+	seen := make(map[string]map[string]bool)
+	for _, qr := range queryResults {
+		for _, q := range qr.Queries() {
+			s := ss.Get(q)
+			if s == nil {
+				continue
+			}
+			for _, t := range q.Tables {
+				if seen[t] == nil {
+					seen[t] = make(map[string]bool)
+					res[t] = &tableStat{Reads: &stats.Stat{}, Writes: &stats.Stat{}}
+				}
+				if seen[t][s.Fingerprint] {
+					continue
+				}
+				seen[t][s.Fingerprint] = true
+				if q.Kind == sql.Select {
+					res[t].Reads.Merge(s)
+				} else {
+					res[t].Writes.Merge(s)
+				}
+			}
+		}
+	}
+	return res
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/haijima/scone/internal/analysis"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func NewSuggestFuncsCmd(v *viper.Viper, fs afero.Fs) *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = "suggest-funcs"
+	cmd.Short = "Suggest functions to analyze additionally"
+	cmd.Long = "Suggest functions which take queries as arguments but are not analyzed.\nThe output can be pasted into .scone.yaml as it is."
+	cmd.Args = cobra.NoArgs
+	cmd.RunE = func(cmd *cobra.Command, _ []string) error {
+		return runSuggestFuncs(cmd, v, fs)
+	}
+
+	return cmd
+}
+
+func runSuggestFuncs(cmd *cobra.Command, v *viper.Viper, fs afero.Fs) error {
+	dir := v.GetString("dir")
+	pattern := v.GetString("pattern")
+
+	opt, err := newOption(v, fs)
+	if err != nil {
+		return err
+	}
+	opt.CollectNonTargetCalls = true
+	result, err := analysis.Analyze(cmd.Context(), dir, pattern, opt)
+	if err != nil {
+		return err
+	}
+
+	printSuggestedFuncs(cmd.OutOrStdout(), opt.AdditionalFuncs, analysis.SuggestFuncs(result.NonTargetCalls))
+	return nil
+}
+
+func printSuggestedFuncs(w io.Writer, configured []string, suggestions []*analysis.FuncSuggestion) {
+	if len(configured) == 0 && len(suggestions) == 0 {
+		_, _ = fmt.Fprintln(w, "analyze-funcs: []")
+		return
+	}
+	_, _ = fmt.Fprintln(w, "analyze-funcs:")
+	for _, f := range configured {
+		_, _ = fmt.Fprintf(w, "  - %s # configured\n", strconv.Quote(f))
+	}
+	for _, s := range suggestions {
+		_, _ = fmt.Fprintf(w, "  - %s # %d queries in %d calls\n", strconv.Quote(s.String()), s.Queries.Cardinality(), len(s.Calls))
+	}
+}
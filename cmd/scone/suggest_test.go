@@ -0,0 +1,37 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/sebdah/goldie/v2"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_runSuggestFuncs(t *testing.T) {
+	tests := []string{"isucon12-qualify", "wrapper"}
+	for _, tt := range tests {
+		t.Run(tt, func(t *testing.T) {
+			t.Parallel()
+			cmd := &cobra.Command{}
+			cmd.SetContext(context.Background())
+			buf := &bytes.Buffer{}
+			cmd.SetOut(buf)
+			cmd.SetErr(io.Discard)
+			v := viper.New()
+			v.Set("dir", "./testdata/src/"+tt)
+			v.Set("pattern", "./...")
+
+			err := runSuggestFuncs(cmd, v, afero.NewOsFs())
+			require.NoError(t, err)
+
+			g := goldie.New(t)
+			g.Assert(t, tt+".suggest-funcs", buf.Bytes())
+		})
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/fatih/color"
 	"github.com/haijima/scone/internal/analysis"
 	"github.com/haijima/scone/internal/sql"
+	"github.com/haijima/scone/internal/stats"
 	"github.com/haijima/scone/internal/util"
 	prettyTable "github.com/jedib0t/go-pretty/v6/table"
 	"github.com/spf13/afero"
@@ -19,39 +20,54 @@ import (
 	"github.com/spf13/viper"
 )
 
-func NewTableCommand(v *viper.Viper, _ afero.Fs) *cobra.Command {
+func NewTableCommand(v *viper.Viper, fs afero.Fs) *cobra.Command {
 	cmd := &cobra.Command{}
 	cmd.Use = "table"
 	cmd.Aliases = []string{"tables"}
 	cmd.Short = "List tables information from queries"
-	cmd.RunE = func(cmd *cobra.Command, _ []string) error { return runTable(cmd, v) }
+	cmd.RunE = func(cmd *cobra.Command, _ []string) error { return runTable(cmd, v, fs) }
 
+	cmd.Flags().String("format", "text", "The output format {text|json|jsonl}")
 	cmd.Flags().Bool("summary", false, "Print summary only")
 	cmd.Flags().Bool("collapse-phi", false, "Collapse phi queries")
+	addStatsFlags(cmd)
 
 	return cmd
 }
 
-func runTable(cmd *cobra.Command, v *viper.Viper) error {
+func runTable(cmd *cobra.Command, v *viper.Viper, fs afero.Fs) error {
 	dir := v.GetString("dir")
 	pattern := v.GetString("pattern")
+	format := v.GetString("format")
 	summaryOnly := v.GetBool("summary")
 	collapsePhi := v.GetBool("collapse-phi")
-	filter := v.GetString("filter")
-	additionalFuncs := v.GetStringSlice("analyze-funcs")
 
-	queryResults, cgs, err := analysis.Analyze(cmd.Context(), dir, pattern, analysis.NewOption(filter, additionalFuncs))
+	opt, err := newOption(v, fs)
 	if err != nil {
 		return err
 	}
-	tableConn := clusterize(queryResults, cgs)
+	ss, err := loadStats(v, fs)
+	if err != nil {
+		return err
+	}
+	result, err := analysis.Analyze(cmd.Context(), dir, pattern, opt)
+	if err != nil {
+		return err
+	}
+	queryResults := result.QueryResults
+	tableConn := clusterize(queryResults, result.Transactions)
+	weights := tableStats(queryResults, ss)
+
+	if slices.Contains(jsonFormats, format) {
+		return printTableJSON(cmd.OutOrStdout(), dir, format, queryResults, tableConn, summaryOnly, ss, weights)
+	}
 
 	if err := printSummary(cmd.OutOrStdout(), queryResults, tableConn); err != nil {
 		return err
 	}
 	if !summaryOnly {
 		for _, t := range queryResults.AllTables() {
-			if err := printTableResult(cmd.OutOrStdout(), t, queryResults, tableConn, collapsePhi); err != nil {
+			if err := printTableResult(cmd.OutOrStdout(), t, queryResults, tableConn, collapsePhi, ss, weights[t.Name]); err != nil {
 				return err
 			}
 		}
@@ -60,27 +76,21 @@ func runTable(cmd *cobra.Command, v *viper.Viper) error {
 	return nil
 }
 
-func clusterize(queryResults analysis.QueryResults, cgs map[string]*analysis.CallGraph) util.Connection {
+func clusterize(queryResults analysis.QueryResults, txs []*analysis.Transaction) util.Connection {
 	c := util.NewConnection(queryResults.AllTableNames()...) // Create a graph with tables as nodes
 
 	// Extract tables updated in the same transaction
-	for _, cg := range cgs {
-		for _, r := range cg.Nodes {
-			if r.IsRoot() {
-				// walk from the root nodes
-				tablesInTx := mapset.NewSet[string]()
-				analysis.Walk(cg, r, func(n *analysis.Node) bool {
-					for _, edge := range n.Out {
-						if edge.IsQuery() && edge.SqlValue.Kind != sql.Select {
-							tablesInTx.Add(edge.Callee)
-						}
-					}
-					return false
-				})
-				// connect updated tables under the same root node
-				util.PairCombinateFunc(tablesInTx.ToSlice(), c.Connect)
+	for _, tx := range txs {
+		tablesInTx := mapset.NewSet[string]()
+		for _, qr := range tx.Queries {
+			for _, q := range qr.Queries() {
+				if q.Kind != sql.Select && q.MainTable != "" {
+					tablesInTx.Add(q.MainTable)
+				}
 			}
 		}
+		// connect updated tables in the same transaction
+		util.PairCombinateFunc(tablesInTx.ToSlice(), c.Connect)
 	}
 
 	// extract tables used in the same query
@@ -123,6 +133,7 @@ func printSummary(w io.Writer, queryResults analysis.QueryResults, tableConn uti
 	for _, ts := range tableConn.GetClusters() {
 		clusters = append(clusters, mapset.Sorted(ts))
 	}
+	slices.SortFunc(clusters, slices.Compare)
 
 	data := make(map[string]any)
 	data["queries"] = len(queryResults)
@@ -144,10 +155,14 @@ const tmplTableResult = `
   {{key "partition key"}}	: {{printf "%q" .partitionKey}}
   {{- end}}
   {{key "queries"}}	: {{len .queries}}
+  {{- with .stats}}
+  {{key "reads"}}	: {{.Reads.Count}} times, {{duration .Reads.TotalTime}}
+  {{key "writes"}}	: {{.Writes.Count}} times, {{duration .Writes.TotalTime}}
+  {{- end}}
 {{/* Show queries by TablePrinter */}}
 `
 
-func printTableResult(w io.Writer, table *sql.Table, queryResults analysis.QueryResults, tableConn util.Connection, collapsePhi bool) error {
+func printTableResult(w io.Writer, table *sql.Table, queryResults analysis.QueryResults, tableConn util.Connection, collapsePhi bool, ss stats.Stats, weight *tableStat) error {
 	qrs := make([]*analysis.QueryResult, 0)
 	for _, qr := range queryResults {
 		if slices.ContainsFunc(qr.Queries(), func(q *sql.Query) bool { return slices.Contains(q.Tables, table.Name) }) {
@@ -164,6 +179,9 @@ func printTableResult(w io.Writer, table *sql.Table, queryResults analysis.Query
 	data["cluster"] = mapset.Sorted(tableConn.GetConnection(table.Name, -1))
 	data["partitionKey"] = table.PartitionKeys()
 	data["queries"] = qrs
+	if weight != nil {
+		data["stats"] = weight
+	}
 
 	if err := templateRender(w, "tableResult", tmplTableResult, data); err != nil {
 		return err
@@ -176,11 +194,23 @@ func printTableResult(w io.Writer, table *sql.Table, queryResults analysis.Query
 	t.Style().Options.SeparateHeader = false
 	t.Style().Options.SeparateRows = false
 	t.Style().Box.MiddleVertical = " "
-	t.AppendHeader(prettyTable.Row{"", "#", "file", "function", "t", "query"})
+	header := prettyTable.Row{"", "#", "file", "function", "t", "query"}
+	if ss != nil {
+		header = slices.Insert(header, 5, prettyTable.Row{"count", "time"}...)
+	}
+	t.AppendHeader(header)
 	for i, qr := range qrs {
 		for _, q := range qr.Queries() {
 			if slices.Contains(q.Tables, table.Name) {
-				t.AppendRow(prettyTable.Row{"   ", strconv.Itoa(i + 1), qr.Posx.PositionString(), qr.Posx.Func.Name(), q.Kind.Color(q.Kind.CRUD()), q.Raw}) //nolint:govet
+				row := prettyTable.Row{"   ", strconv.Itoa(i + 1), qr.Posx.PositionString(), analysis.FuncName(qr.Posx.Func), q.Kind.Color(q.Kind.CRUD()), q.Raw} //nolint:govet
+				if ss != nil {
+					count, time := "", ""
+					if s := ss.Get(q); s != nil {
+						count, time = strconv.FormatInt(s.Count, 10), durationString(s.TotalTime)
+					}
+					row = slices.Insert(row, 5, prettyTable.Row{count, time}...)
+				}
+				t.AppendRow(row)
 				if collapsePhi {
 					break
 				}
@@ -191,13 +221,61 @@ func printTableResult(w io.Writer, table *sql.Table, queryResults analysis.Query
 	return nil
 }
 
+func printTableJSON(w io.Writer, dir, format string, queryResults analysis.QueryResults, tableConn util.Connection, summaryOnly bool, ss stats.Stats, weights map[string]*tableStat) error {
+	jw, err := newJSONWriter("table", dir)
+	if err != nil {
+		return err
+	}
+	jw.stats = ss
+	tables := queryResults.AllTables()
+	summary := &jsonTableSummary{Type: "summary", Queries: len(queryResults), Tables: len(tables), Cacheability: make(map[string][]string), Clusters: make([][]string, 0), PartitionKeys: make(map[string][]string)}
+	for _, t := range tables {
+		summary.Cacheability[t.Cacheability().String()] = append(summary.Cacheability[t.Cacheability().String()], t.Name)
+		if len(t.PartitionKeys()) > 0 {
+			summary.PartitionKeys[t.Name] = t.PartitionKeys()
+		}
+	}
+	for _, ts := range tableConn.GetClusters() {
+		summary.Clusters = append(summary.Clusters, mapset.Sorted(ts))
+	}
+	slices.SortFunc(summary.Clusters, slices.Compare)
+	jw.add(summary)
+	if summaryOnly {
+		return jw.write(w, format)
+	}
+
+	for _, t := range tables {
+		r := &jsonTable{
+			Type:          "table",
+			Name:          t.Name,
+			Kinds:         jsonQueryKinds(t.Kinds()),
+			Cacheability:  t.Cacheability().String(),
+			Collocation:   mapset.Sorted(tableConn.GetConnection(t.Name, 1).Difference(mapset.NewSet(t.Name))),
+			Cluster:       mapset.Sorted(tableConn.GetConnection(t.Name, -1)),
+			PartitionKeys: nonNil(t.PartitionKeys()),
+			Stats:         newJSONTableStat(weights[t.Name]),
+			Queries:       make([]*jsonQuery, 0),
+		}
+		for i, qr := range queryResults {
+			for _, q := range qr.Queries() {
+				if slices.Contains(q.Tables, t.Name) {
+					r.Queries = append(r.Queries, jw.query(i+1, q, qr))
+				}
+			}
+		}
+		jw.add(r)
+	}
+	return jw.write(w, format)
+}
+
 var tmplFuncs = map[string]any{
 	"labeled": func(table string, kind sql.QueryKind) string {
 		return color.New(color.FgBlack, kind.ColorAttribute()+10).Sprintf(" %s ", table)
 	},
-	"title":   color.CyanString,
-	"key":     color.MagentaString,
-	"colored": func(s interface{ ColoredString() string }) string { return s.ColoredString() },
+	"title":    color.CyanString,
+	"duration": durationString,
+	"key":      color.MagentaString,
+	"colored":  func(s interface{ ColoredString() string }) string { return s.ColoredString() },
 }
 
 func templateRender(w io.Writer, name string, tmpl string, data map[string]any) error {
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/sebdah/goldie/v2"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_runTable(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.SetContext(context.Background())
+	buf := &bytes.Buffer{}
+	cmd.SetOut(buf)
+	cmd.SetErr(io.Discard)
+	v := viper.New()
+	v.Set("dir", "./testdata/src/tx")
+	v.Set("pattern", "./...")
+	v.Set("summary", true)
+
+	err := runTable(cmd, v, afero.NewOsFs())
+	require.NoError(t, err)
+
+	g := goldie.New(t)
+	g.Assert(t, "tx.table", buf.Bytes())
+}
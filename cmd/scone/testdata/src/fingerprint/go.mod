@@ -0,0 +1,3 @@
+module fingerprint
+
+go 1.23.0
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"database/sql"
+)
+
+var db *sql.DB
+
+func main() {
+	var err error
+	db, err = sql.Open("mysql", "user:password@/dbname")
+	if err != nil {
+		panic(err)
+	}
+}
+
+func getAdmin() {
+	_, _ = db.Query("SELECT * FROM users WHERE id = 1")
+}
+
+func getGuest() {
+	_, _ = db.Query("select * from USERS where ID = 2 /* guest */")
+}
+
+func getUser(id int) {
+	_, _ = db.Query("SELECT * FROM users WHERE id = ?", id)
+}
+
+func getUsers() {
+	_, _ = db.Query("SELECT * FROM users WHERE id IN (1, 2, 3)")
+	_, _ = db.Query("SELECT * FROM users WHERE id IN (4, 5)")
+}
+
+func createUsers() {
+	_, _ = db.Exec("INSERT INTO users (id, name) VALUES (1, 'alice'), (2, 'bob')")
+	_, _ = db.Exec("INSERT INTO users (id, name) VALUES (?, ?)", 3, "carol")
+}
+
+func countUsers() {
+	_, _ = db.Query("SELECT COUNT(*) FROM users WHERE created_at > NOW() - INTERVAL 1 DAY")
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+type User struct {
+	ID        int64 `gorm:"primaryKey"`
+	Name      string
+	Email     string `gorm:"column:mail_address"`
+	CreatedAt time.Time
+	Items     []Item
+}
+
+type Item struct {
+	gorm.Model
+	UserID int64
+	Title  string
+	Secret string `gorm:"-"`
+}
+
+type Category struct {
+	ID   int64
+	Name string
+}
+
+func (Category) TableName() string {
+	return "item_categories"
+}
+
+var db *gorm.DB
+
+func main() {
+	var err error
+	db, err = gorm.Open(mysql.Open("user:password@/dbname"), &gorm.Config{})
+	if err != nil {
+		panic(err)
+	}
+
+	http.HandleFunc("GET /users/{id}", getUser)
+	http.HandleFunc("POST /users", createUser)
+	http.HandleFunc("PUT /users/{id}", updateUser)
+	http.HandleFunc("DELETE /users/{id}", deleteUser)
+	http.HandleFunc("GET /items", listItems)
+	http.HandleFunc("GET /categories", listCategories)
+	http.HandleFunc("GET /stats", getStats)
+	_ = http.ListenAndServe(":8080", nil)
+}
+
+func getUser(w http.ResponseWriter, r *http.Request) {
+	var user User
+	db.Where("id = ?", r.PathValue("id")).First(&user)
+	w.WriteHeader(http.StatusOK)
+}
+
+func createUser(w http.ResponseWriter, r *http.Request) {
+	user := User{Name: r.FormValue("name")}
+	db.WithContext(r.Context()).Create(&user)
+	w.WriteHeader(http.StatusCreated)
+}
+
+func updateUser(w http.ResponseWriter, r *http.Request) {
+	var user User
+	db.First(&user, "id = ?", r.PathValue("id"))
+	user.Name = r.FormValue("name")
+	db.Save(&user)
+	db.Model(&User{}).Where("id = ?", user.ID).Update("name", user.Name)
+	w.WriteHeader(http.StatusOK)
+}
+
+func deleteUser(w http.ResponseWriter, r *http.Request) {
+	db.Where("user_id = ?", r.PathValue("id")).Delete(&Item{})
+	db.Delete(&User{}, r.PathValue("id"))
+	db.Unscoped().Where("deleted_at < ?", time.Now().Add(-30*24*time.Hour)).Delete(&Item{})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func listItems(w http.ResponseWriter, r *http.Request) {
+	var items []Item
+	db.Model(&Item{}).Joins("JOIN users ON users.id = items.user_id").Where("users.name = ?", r.FormValue("name")).Order("items.created_at DESC").Find(&items)
+	var count int64
+	db.Table("items").Where("user_id = ?", r.FormValue("user_id")).Count(&count)
+	db.Where("user_id = ?", r.FormValue("user_id")).Or("title = ?", r.FormValue("title")).Find(&items)
+	w.WriteHeader(http.StatusOK)
+}
+
+func listCategories(w http.ResponseWriter, r *http.Request) {
+	var categories []*Category
+	db.Find(&categories)
+	var names []string
+	db.Model(&Category{}).Pluck("name", &names)
+	w.WriteHeader(http.StatusOK)
+}
+
+func getStats(w http.ResponseWriter, r *http.Request) {
+	var total int64
+	db.Raw("SELECT COUNT(*) FROM users WHERE created_at > ?", time.Now().Add(-24*time.Hour)).Scan(&total)
+	db.Exec("UPDATE items SET title = ? WHERE user_id = ?", "", 0)
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+
+	"iface/repo"
+)
+
+var users repo.UserRepository
+
+func main() {
+	db, err := sql.Open("mysql", "user:password@/dbname")
+	if err != nil {
+		panic(err)
+	}
+	users = repo.NewUserRepository(db)
+
+	http.HandleFunc("GET /users/{id}", getUser)
+	http.HandleFunc("POST /users", postUser)
+	_ = http.ListenAndServe(":8080", nil)
+}
+
+func getUser(w http.ResponseWriter, r *http.Request) {
+	_, _ = users.Find(r.Context(), r.PathValue("id"))
+}
+
+func postUser(w http.ResponseWriter, r *http.Request) {
+	_ = users.Create(r.Context(), r.FormValue("name"))
+}
@@ -0,0 +1,46 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+)
+
+type UserRepository interface {
+	Find(ctx context.Context, id string) (string, error)
+	Create(ctx context.Context, name string) error
+}
+
+type userRepository struct {
+	db *sql.DB
+}
+
+func NewUserRepository(db *sql.DB) UserRepository {
+	return &userRepository{db: db}
+}
+
+func (r *userRepository) Find(ctx context.Context, id string) (string, error) {
+	var name string
+	err := r.db.QueryRowContext(ctx, "SELECT name FROM users WHERE id = ?", id).Scan(&name)
+	return name, err
+}
+
+func (r *userRepository) Create(ctx context.Context, name string) error {
+	_, err := r.db.ExecContext(ctx, "INSERT INTO users (name) VALUES (?)", name)
+	return err
+}
+
+// archivedUserRepository is not used by the application, so it is not reachable from main
+type archivedUserRepository struct {
+	db *sql.DB
+}
+
+func (r *archivedUserRepository) Find(ctx context.Context, id string) (string, error) {
+	var name string
+	err := r.db.QueryRowContext(ctx, "SELECT name FROM archived_users WHERE id = ?", id).Scan(&name)
+	return name, err
+}
+
+func (r *archivedUserRepository) Create(ctx context.Context, name string) error {
+	_, err := r.db.ExecContext(ctx, "INSERT INTO archived_users (name) VALUES (?)", name)
+	return err
+}
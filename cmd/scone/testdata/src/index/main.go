@@ -0,0 +1,62 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+)
+
+var db *sql.DB
+
+func main() {
+	var err error
+	db, err = sql.Open("mysql", "user:password@/dbname")
+	if err != nil {
+		panic(err)
+	}
+
+	http.HandleFunc("GET /users/{id}", getUser)
+	http.HandleFunc("GET /users/{id}/comments", getUserComments)
+	http.HandleFunc("GET /posts", getPosts)
+	http.HandleFunc("GET /posts/{id}", getPost)
+	http.HandleFunc("POST /posts/{id}", updatePost)
+	http.HandleFunc("DELETE /posts/{id}/comments", deleteComments)
+	http.HandleFunc("GET /tags/{name}", getTag)
+	_ = http.ListenAndServe(":8080", nil)
+}
+
+func getUser(w http.ResponseWriter, r *http.Request) {
+	_ = db.QueryRow("SELECT * FROM users WHERE id = ?", r.PathValue("id")).Scan()
+	_ = db.QueryRow("SELECT * FROM users WHERE name = ?", r.FormValue("name")).Scan()
+	_, _ = db.Query("SELECT * FROM posts WHERE user_id = ? ORDER BY created_at DESC", r.PathValue("id"))
+}
+
+func getUserComments(w http.ResponseWriter, r *http.Request) {
+	_, _ = db.Query("SELECT c.* FROM comments c JOIN users u ON c.user_id = u.id WHERE u.id = ?", r.PathValue("id"))
+}
+
+func getPosts(w http.ResponseWriter, r *http.Request) {
+	if r.FormValue("published") != "" {
+		_, _ = db.Query("SELECT * FROM posts WHERE published = 1 ORDER BY created_at DESC LIMIT 10")
+		return
+	}
+	_, _ = db.Query("SELECT * FROM posts ORDER BY created_at DESC LIMIT 20")
+}
+
+func getPost(w http.ResponseWriter, r *http.Request) {
+	_ = db.QueryRow("SELECT * FROM posts WHERE id = ?", r.PathValue("id")).Scan()
+	_, _ = db.Query("SELECT * FROM comments WHERE post_id = ? ORDER BY created_at", r.PathValue("id"))
+	_ = db.QueryRow("SELECT COUNT(*) FROM comments WHERE post_id = ?", r.PathValue("id")).Scan()
+	_, _ = db.Query("SELECT * FROM tags WHERE post_id = ?", r.PathValue("id"))
+}
+
+func updatePost(w http.ResponseWriter, r *http.Request) {
+	_, _ = db.Exec("UPDATE posts SET title = ? WHERE id = ?", r.FormValue("title"), r.PathValue("id"))
+}
+
+func deleteComments(w http.ResponseWriter, r *http.Request) {
+	_, _ = db.Exec("DELETE FROM comments WHERE post_id = ? AND user_id = ?", r.PathValue("id"), r.FormValue("user_id"))
+}
+
+func getTag(w http.ResponseWriter, r *http.Request) {
+	_, _ = db.Query("SELECT * FROM tags WHERE name = ?", r.PathValue("name"))
+}
@@ -0,0 +1,28 @@
+CREATE TABLE users (
+  id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  name VARCHAR(255) NOT NULL UNIQUE,
+  created_at DATETIME NOT NULL
+);
+
+CREATE TABLE posts (
+  id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  user_id BIGINT NOT NULL,
+  title VARCHAR(255) NOT NULL,
+  published TINYINT NOT NULL,
+  created_at DATETIME NOT NULL,
+  INDEX idx_user_id (user_id)
+);
+
+CREATE TABLE comments (
+  id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  post_id BIGINT NOT NULL,
+  user_id BIGINT NOT NULL,
+  body TEXT NOT NULL,
+  created_at DATETIME NOT NULL
+);
+
+CREATE TABLE tags (
+  id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  post_id BIGINT NOT NULL,
+  name VARCHAR(255) NOT NULL
+);
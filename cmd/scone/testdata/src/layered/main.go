@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+)
+
+var db *sql.DB
+
+func main() {
+	var err error
+	db, err = sql.Open("mysql", "user:password@/dbname")
+	if err != nil {
+		panic(err)
+	}
+
+	http.HandleFunc("GET /users/{id}", getUser)
+	http.HandleFunc("GET /orders", getOrders)
+	http.HandleFunc("POST /orders", postOrder)
+	_ = http.ListenAndServe(":8080", nil)
+}
+
+// handlers
+
+func getUser(w http.ResponseWriter, r *http.Request) {
+	_, _ = getUserService(r.Context(), r.PathValue("id"))
+}
+
+func getOrders(w http.ResponseWriter, r *http.Request) {
+	_, _ = listOrders(r.Context())
+}
+
+func postOrder(w http.ResponseWriter, r *http.Request) {
+	_ = createOrder(r.Context(), r.FormValue("user_id"), r.FormValue("item"))
+}
+
+// services
+
+func getUserService(ctx context.Context, id string) (string, error) {
+	return findUser(ctx, id)
+}
+
+func createOrder(ctx context.Context, userID, item string) error {
+	if _, err := findUser(ctx, userID); err != nil {
+		return err
+	}
+	return insertOrder(ctx, userID, item)
+}
+
+// repositories
+
+func findUser(ctx context.Context, id string) (string, error) {
+	var name string
+	err := db.QueryRowContext(ctx, "SELECT name FROM users WHERE id = ?", id).Scan(&name)
+	return name, err
+}
+
+func listOrders(ctx context.Context) ([]string, error) {
+	rows, err := db.QueryContext(ctx, "SELECT item FROM orders")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := make([]string, 0)
+	for rows.Next() {
+		var item string
+		if err := rows.Scan(&item); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func insertOrder(ctx context.Context, userID, item string) error {
+	_, err := db.ExecContext(ctx, "INSERT INTO orders (user_id, item) VALUES (?, ?)", userID, item)
+	return err
+}
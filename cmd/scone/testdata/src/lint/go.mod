@@ -0,0 +1,3 @@
+module lint
+
+go 1.23.0
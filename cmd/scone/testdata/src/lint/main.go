@@ -0,0 +1,60 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+)
+
+var db *sql.DB
+
+func main() {
+	var err error
+	db, err = sql.Open("mysql", "user:password@/dbname")
+	if err != nil {
+		panic(err)
+	}
+
+	http.HandleFunc("GET /users", getUsers)
+	http.HandleFunc("GET /users/search", searchUsers)
+	http.HandleFunc("GET /users/random", getRandomUser)
+	http.HandleFunc("POST /users/reset", resetUsers)
+	http.HandleFunc("GET /posts", getPosts)
+	_ = http.ListenAndServe(":8080", nil)
+}
+
+func getUsers(w http.ResponseWriter, r *http.Request) {
+	_, _ = db.Query("SELECT * FROM users ORDER BY id LIMIT ? OFFSET ?", 20, r.FormValue("offset"))
+	_, _ = db.Query("SELECT * FROM admins") // scone:ignore select-star
+}
+
+func searchUsers(w http.ResponseWriter, r *http.Request) {
+	_, _ = db.Query("SELECT id, name FROM users WHERE name LIKE CONCAT('%', ?, '%')", r.FormValue("q"))
+	_, _ = db.Query("SELECT id, name FROM users WHERE name MATCHES ?", r.FormValue("q"))
+}
+
+// scone:ignore
+func getRandomUser(w http.ResponseWriter, r *http.Request) {
+	_ = db.QueryRow("SELECT id, name FROM users ORDER BY RAND() LIMIT 1").Scan()
+}
+
+func resetUsers(w http.ResponseWriter, r *http.Request) {
+	_, _ = db.Exec("UPDATE users SET name = ''")
+	// scone:ignore missing-where
+	_, _ = db.Exec("DELETE FROM sessions")
+	_, _ = db.Exec("DELETE FROM tokens")
+}
+
+func getPosts(w http.ResponseWriter, r *http.Request) {
+	rows, _ := db.Query("SELECT id, user_id FROM posts")
+	for rows.Next() {
+		var id, userID int
+		_ = rows.Scan(&id, &userID)
+		_ = db.QueryRow("SELECT name FROM users WHERE id = ?", userID).Scan()
+		_ = getComments(id)
+	}
+}
+
+func getComments(postID int) error {
+	_, err := db.Query("SELECT id, body FROM comments WHERE post_id = ?", postID)
+	return err
+}
@@ -0,0 +1,3 @@
+module stmt
+
+go 1.23.0
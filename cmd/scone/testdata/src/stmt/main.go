@@ -0,0 +1,108 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+)
+
+var (
+	db             *sql.DB
+	getUserStmt    *sql.Stmt
+	insertUserStmt *sql.Stmt
+	stmts          = map[string]*sql.Stmt{}
+)
+
+type Repo struct {
+	updateNameStmt *sql.Stmt
+}
+
+var repo *Repo
+
+func main() {
+	var err error
+	db, err = sql.Open("mysql", "user:password@/dbname")
+	if err != nil {
+		panic(err)
+	}
+	getUserStmt, _ = db.Prepare("SELECT * FROM users WHERE id = ?")
+	insertUserStmt, _ = db.Prepare("INSERT INTO users (name) VALUES (?)")
+	stmts["count"], _ = db.Prepare("SELECT COUNT(*) FROM items")
+	repo = newRepo(db)
+
+	http.HandleFunc("GET /users/{id}", getUser)
+	http.HandleFunc("POST /users", createUser)
+	http.HandleFunc("PUT /users/{id}", updateUser)
+	http.HandleFunc("GET /items/count", countItems)
+	http.HandleFunc("DELETE /posts/{id}", deletePost)
+	http.HandleFunc("POST /posts/{id}/tags", addTags)
+	http.HandleFunc("GET /posts/{id}/comments", listComments)
+	http.HandleFunc("POST /logs", createLog)
+	_ = http.ListenAndServe(":8080", nil)
+}
+
+func newRepo(db *sql.DB) *Repo {
+	stmt, _ := db.Prepare("UPDATE users SET name = ? WHERE id = ?")
+	return &Repo{updateNameStmt: stmt}
+}
+
+func getUser(w http.ResponseWriter, r *http.Request) {
+	_ = getUserStmt.QueryRow(r.PathValue("id")).Scan()
+	w.WriteHeader(http.StatusOK)
+}
+
+func createUser(w http.ResponseWriter, r *http.Request) {
+	tx, _ := db.Begin()
+	_, _ = tx.Stmt(insertUserStmt).Exec(r.FormValue("name"))
+	_ = tx.Commit()
+	w.WriteHeader(http.StatusCreated)
+}
+
+func updateUser(w http.ResponseWriter, r *http.Request) {
+	_, _ = repo.updateNameStmt.Exec(r.FormValue("name"), r.PathValue("id"))
+	w.WriteHeader(http.StatusOK)
+}
+
+func countItems(w http.ResponseWriter, r *http.Request) {
+	_ = stmts["count"].QueryRow().Scan()
+	w.WriteHeader(http.StatusOK)
+}
+
+func deletePost(w http.ResponseWriter, r *http.Request) {
+	stmt, _ := db.Prepare("DELETE FROM posts WHERE id = ?")
+	defer stmt.Close()
+	_, _ = stmt.Exec(r.PathValue("id"))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func addTags(w http.ResponseWriter, r *http.Request) {
+	stmt, _ := db.Prepare("INSERT INTO tags (post_id, name) VALUES (?, ?)")
+	defer stmt.Close()
+	insertTags(stmt, r.PathValue("id"), r.Form["tag"])
+	w.WriteHeader(http.StatusCreated)
+}
+
+func insertTags(stmt *sql.Stmt, postID string, tags []string) {
+	for _, tag := range tags {
+		_, _ = stmt.Exec(postID, tag)
+	}
+}
+
+func listComments(w http.ResponseWriter, r *http.Request) {
+	stmt, _ := db.Prepare("SELECT * FROM comments WHERE post_id = ?")
+	defer stmt.Close()
+	func() {
+		_, _ = stmt.Query(r.PathValue("id"))
+	}()
+	w.WriteHeader(http.StatusOK)
+}
+
+var execStmt = func(stmt *sql.Stmt, args ...any) {
+	_, _ = stmt.Exec(args...)
+}
+
+func createLog(w http.ResponseWriter, r *http.Request) {
+	stmt, _ := db.Prepare("INSERT INTO logs (message) VALUES (?)")
+	defer stmt.Close()
+	execStmt(stmt, r.FormValue("message")) // the statement is not traced through the function value
+	w.WriteHeader(http.StatusCreated)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var db *sql.DB
+
+func main() {
+	var err error
+	db, err = sql.Open("mysql", "user:password@/dbname")
+	if err != nil {
+		panic(err)
+	}
+
+	http.HandleFunc("GET /users", listUsers)
+	http.HandleFunc("GET /{table}/{id}", getRecord)
+	http.HandleFunc("POST /conditions", postCondition)
+	_ = http.ListenAndServe(":8080", nil)
+}
+
+func listUsers(w http.ResponseWriter, r *http.Request) {
+	ids := strings.Split(r.URL.Query().Get("ids"), ",")
+	placeholders := strings.Repeat("?, ", len(ids)-1) + "?"
+	args := make([]any, 0, len(ids))
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	rows, _ := db.Query("SELECT * FROM users WHERE id IN ("+placeholders+")", args...)
+	defer rows.Close()
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	rows2, _ := db.Query(fmt.Sprintf("SELECT * FROM users ORDER BY %s LIMIT %d", r.URL.Query().Get("order"), limit))
+	defer rows2.Close()
+	w.WriteHeader(http.StatusOK)
+}
+
+func getRecord(w http.ResponseWriter, r *http.Request) {
+	_ = db.QueryRow(fmt.Sprintf("SELECT * FROM %s WHERE id = ?", r.PathValue("table")), r.PathValue("id")).Scan()
+	w.WriteHeader(http.StatusOK)
+}
+
+func postCondition(w http.ResponseWriter, r *http.Request) {
+	shard, _ := strconv.Atoi(r.FormValue("shard"))
+	_, _ = db.Exec(fmt.Sprintf("INSERT INTO isu_condition_%d (jia_isu_uuid, message) VALUES (?, ?)", shard), r.FormValue("uuid"), r.FormValue("message"))
+	_, _ = db.Exec(fmt.Sprintf("UPDATE %s SET %s = ? WHERE id = ?", "isu", "message"), r.FormValue("message"), 1)
+	w.WriteHeader(http.StatusCreated)
+}
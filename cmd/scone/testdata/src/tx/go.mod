@@ -0,0 +1,3 @@
+module tx
+
+go 1.23.0
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+)
+
+var (
+	db          *sql.DB
+	getItemStmt *sql.Stmt
+)
+
+func main() {
+	var err error
+	db, err = sql.Open("mysql", "user:password@/dbname")
+	if err != nil {
+		panic(err)
+	}
+	getItemStmt, _ = db.Prepare("SELECT * FROM items WHERE id = ?")
+
+	http.HandleFunc("POST /transfer", transfer)
+	http.HandleFunc("POST /orders", createOrder)
+	http.HandleFunc("GET /users/{id}", getUser)
+	http.HandleFunc("POST /login", login)
+	_ = http.ListenAndServe(":8080", nil)
+}
+
+func transfer(w http.ResponseWriter, r *http.Request) {
+	tx, err := db.Begin()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var balance int
+	if err := tx.QueryRow("SELECT balance FROM accounts WHERE id = ? FOR UPDATE", r.FormValue("from")).Scan(&balance); err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	_, _ = tx.Exec("UPDATE accounts SET balance = balance - ? WHERE id = ?", r.FormValue("amount"), r.FormValue("from"))
+	_, _ = tx.Exec("UPDATE accounts SET balance = balance + ? WHERE id = ?", r.FormValue("amount"), r.FormValue("to"))
+	addLog(tx, r.FormValue("from"), r.FormValue("to"))
+
+	if err := tx.Commit(); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func addLog(tx *sql.Tx, from, to string) {
+	_, _ = tx.Exec("INSERT INTO transfer_logs (from_id, to_id) VALUES (?, ?)", from, to)
+}
+
+func createOrder(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	var stock int
+	_ = tx.Stmt(getItemStmt).QueryRowContext(ctx, r.FormValue("item_id")).Scan(&stock)
+	if stock == 0 {
+		_ = tx.Rollback()
+		w.WriteHeader(http.StatusConflict)
+		return
+	}
+	if err := insertOrder(ctx, tx, r.FormValue("item_id")); err != nil {
+		_ = tx.Rollback()
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	func() {
+		_, _ = tx.ExecContext(ctx, "UPDATE items SET stock = stock - 1 WHERE id = ?", r.FormValue("item_id"))
+	}()
+	_ = tx.Commit()
+	w.WriteHeader(http.StatusCreated)
+}
+
+func insertOrder(ctx context.Context, tx *sql.Tx, itemID string) error {
+	_, err := tx.ExecContext(ctx, "INSERT INTO orders (item_id) VALUES (?)", itemID)
+	return err
+}
+
+func getUser(w http.ResponseWriter, r *http.Request) {
+	// not in a transaction
+	_ = db.QueryRow("SELECT * FROM users WHERE id = ?", r.PathValue("id")).Scan()
+	w.WriteHeader(http.StatusOK)
+}
+
+func login(w http.ResponseWriter, r *http.Request) {
+	// not in a transaction
+	_, _ = db.Exec("UPDATE users SET last_login_at = NOW() WHERE id = ?", r.FormValue("id"))
+	_, _ = db.Exec("INSERT INTO login_logs (user_id) VALUES (?)", r.FormValue("id"))
+	w.WriteHeader(http.StatusOK)
+}
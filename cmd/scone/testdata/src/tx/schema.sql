@@ -0,0 +1,21 @@
+CREATE TABLE accounts (
+  id BIGINT NOT NULL PRIMARY KEY,
+  balance BIGINT NOT NULL
+);
+
+CREATE TABLE transfer_logs (
+  id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  from_id BIGINT NOT NULL,
+  to_id BIGINT NOT NULL
+);
+
+CREATE TABLE items (
+  id BIGINT NOT NULL PRIMARY KEY,
+  name VARCHAR(255) NOT NULL,
+  stock INT NOT NULL
+);
+
+CREATE TABLE orders (
+  id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  item_id BIGINT NOT NULL
+);
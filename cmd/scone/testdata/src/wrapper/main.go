@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+
+	"wrapper/repo"
+)
+
+var r *repo.Repo
+
+type selecter interface {
+	Select(ctx context.Context, dst any, q string, args ...any) error
+}
+
+func main() {
+	db, err := sql.Open("mysql", "user:password@/dbname")
+	if err != nil {
+		panic(err)
+	}
+	r = repo.New(db)
+
+	http.HandleFunc("GET /users/{id}", getUser)
+	http.HandleFunc("POST /users", createUser)
+	http.HandleFunc("GET /items", listItems)
+	http.HandleFunc("GET /tags", listTags)
+	_ = http.ListenAndServe(":8080", nil)
+}
+
+func getUser(w http.ResponseWriter, req *http.Request) {
+	name, _ := r.UserName(req.Context(), 1)
+	var count int
+	_ = r.Get(req.Context(), &count, "SELECT COUNT(*) FROM posts WHERE user_id = ?", 1)
+	_, _ = w.Write([]byte(name))
+}
+
+func createUser(w http.ResponseWriter, req *http.Request) {
+	_ = r.Exec(req.Context(), "INSERT INTO users (name) VALUES (?)", req.FormValue("name"))
+	_ = exec(req, "UPDATE user_stats SET count = count + 1")
+	w.WriteHeader(http.StatusCreated)
+}
+
+func listItems(w http.ResponseWriter, req *http.Request) {
+	q := "SELECT * FROM items"
+	if req.URL.Query().Has("category") {
+		q = "SELECT * FROM items WHERE category_id = ?"
+	}
+	var id int64
+	_ = r.Get(req.Context(), &id, q)
+	w.WriteHeader(http.StatusOK)
+}
+
+// exec wraps Repo.Exec defined in another package
+func exec(req *http.Request, q string) error {
+	return r.Exec(req.Context(), q)
+}
+
+func listTags(w http.ResponseWriter, req *http.Request) {
+	var s selecter = r
+	var tags []string
+	_ = s.Select(req.Context(), &tags, "SELECT name FROM tags") // the query is not resolved through the interface
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,48 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+)
+
+type Repo struct {
+	db *sql.DB
+}
+
+func New(db *sql.DB) *Repo {
+	return &Repo{db: db}
+}
+
+func (r *Repo) get(ctx context.Context, dst any, q string, args ...any) error {
+	return r.db.QueryRowContext(ctx, q, args...).Scan(dst)
+}
+
+func (r *Repo) exec(ctx context.Context, q string, args ...any) error {
+	_, err := r.db.ExecContext(ctx, q, args...)
+	return err
+}
+
+// Get is a wrapper of a wrapper
+func (r *Repo) Get(ctx context.Context, dst any, q string, args ...any) error {
+	return r.get(ctx, dst, q, args...)
+}
+
+// Exec is a wrapper of a wrapper
+func (r *Repo) Exec(ctx context.Context, q string, args ...any) error {
+	return r.exec(ctx, q, args...)
+}
+
+func (r *Repo) UserName(ctx context.Context, id int64) (string, error) {
+	var name string
+	err := r.get(ctx, &name, "SELECT name FROM users WHERE id = ?", id)
+	return name, err
+}
+
+// Select is a wrapper called only through an interface
+func (r *Repo) Select(ctx context.Context, dst any, q string, args ...any) error {
+	rows, err := r.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+	return rows.Close()
+}
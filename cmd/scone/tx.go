@@ -0,0 +1,102 @@
+package main
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/haijima/scone/internal/analysis"
+	"github.com/haijima/scone/internal/sql"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func NewTxCommand(v *viper.Viper, fs afero.Fs) *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = "tx"
+	cmd.Aliases = []string{"transaction", "transactions"}
+	cmd.Short = "List transactions and their queries"
+	cmd.Long = "List transactions from Begin to Commit or Rollback with their queries in order.\nQueries executed in functions which take the transaction as a parameter are also listed."
+	cmd.Args = cobra.NoArgs
+	cmd.RunE = func(cmd *cobra.Command, _ []string) error { return runTx(cmd, v, fs) }
+
+	cmd.Flags().String("format", "table", formatUsage())
+
+	return cmd
+}
+
+func runTx(cmd *cobra.Command, v *viper.Viper, fs afero.Fs) error {
+	dir := v.GetString("dir")
+	pattern := v.GetString("pattern")
+	format := v.GetString("format")
+
+	if err := validateFormat(format); err != nil {
+		return err
+	}
+
+	opt, err := newOption(v, fs)
+	if err != nil {
+		return err
+	}
+	result, err := analysis.Analyze(cmd.Context(), dir, pattern, opt)
+	if err != nil {
+		return err
+	}
+
+	txs := result.Transactions
+	if slices.Contains(jsonFormats, format) {
+		w, err := newJSONWriter("tx", dir)
+		if err != nil {
+			return err
+		}
+		for _, tx := range txs {
+			r := &jsonTransaction{Type: "transaction", Package: tx.Posx.Package().Name(), PackagePath: tx.Posx.Package().Path(), Function: analysis.FuncName(tx.Posx.Func), Position: w.position(tx.Posx), LockedTables: nonNil(tx.LockedTables()), Tables: nonNil(tx.Tables()), Queries: make([]*jsonQuery, 0), Ends: make([]*jsonTxEnd, 0)}
+			for _, qr := range tx.Queries {
+				for _, q := range qr.Queries() {
+					r.Queries = append(r.Queries, w.query(0, q, qr))
+				}
+			}
+			for _, end := range tx.Ends {
+				r.Ends = append(r.Ends, &jsonTxEnd{Statement: end.String(), Deferred: end.Deferred, Function: analysis.FuncName(end.Posx.Func), Position: w.position(end.Posx)})
+			}
+			w.add(r)
+		}
+		return w.write(cmd.OutOrStdout(), format)
+	}
+
+	t := table.NewWriter()
+	t.SetOutputMirror(cmd.OutOrStdout())
+	t.AppendHeader(table.Row{"#", "Function", "Statement", "Lock", "Tables", "Query", "Position"})
+	for i, tx := range txs {
+		no := strconv.Itoa(i + 1)
+		t.AppendRow(table.Row{no, analysis.FuncName(tx.Posx.Func), "BEGIN", strings.Join(tx.LockedTables(), ", "), strings.Join(tx.Tables(), ", "), "", tx.Posx.PositionString()})
+		for _, qr := range tx.Queries {
+			for _, q := range qr.Queries() {
+				t.AppendRow(table.Row{no, analysis.FuncName(qr.Posx.Func), q.Kind.ColoredString(), lockString(q), strings.Join(q.Tables, ", "), q.String(), qr.Posx.PositionString()})
+			}
+		}
+		for _, end := range tx.Ends {
+			stmt := end.String()
+			if end.Deferred {
+				stmt += " (deferred)"
+			}
+			t.AppendRow(table.Row{no, analysis.FuncName(end.Posx.Func), stmt, "", "", "", end.Posx.PositionString()})
+		}
+		t.AppendSeparator()
+	}
+
+	renderTable(t, format)
+	return nil
+}
+
+// lockString returns the lock which the query takes. e.g. "FOR UPDATE" for locking reads, "WRITE" for writes
+func lockString(q *sql.Query) string {
+	if q.Lock != "" {
+		return q.Lock
+	} else if q.TakesLock() {
+		return "WRITE"
+	}
+	return ""
+}
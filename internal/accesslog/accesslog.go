@@ -0,0 +1,133 @@
+package accesslog
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+)
+
+// Entry is a request in the access log.
+type Entry struct {
+	Method string
+	URI    string
+	Status int
+	Time   time.Time // zero if the time is not logged
+}
+
+// Keys of the fields. The keys of alp and the variable names of nginx are accepted.
+var (
+	methodKeys  = []string{"method", "request_method"}
+	uriKeys     = []string{"uri", "request_uri"}
+	requestKeys = []string{"req", "request"} // e.g. "GET /users?page=1 HTTP/1.1"
+	statusKeys  = []string{"status"}
+	timeKeys    = []string{"time", "time_iso8601", "time_local"}
+)
+
+var timeLayouts = []string{time.RFC3339, "02/Jan/2006:15:04:05 -0700"}
+
+// Parse parses the nginx access log in LTSV or JSON.
+// The format is detected line by line, so JSON lines start with `{` and the others are LTSV.
+func Parse(r io.Reader) ([]*Entry, error) {
+	entries := make([]*Entry, 0)
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		var fields map[string]string
+		var err error
+		if strings.HasPrefix(line, "{") {
+			fields, err = parseJSON(line)
+		} else {
+			fields, err = parseLTSV(line)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d", n)
+		}
+		e, err := newEntry(fields)
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d", n)
+		}
+		entries = append(entries, e)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read access log")
+	}
+	return entries, nil
+}
+
+func parseLTSV(line string) (map[string]string, error) {
+	fields := make(map[string]string)
+	for _, f := range strings.Split(line, "\t") {
+		k, v, ok := strings.Cut(f, ":")
+		if !ok {
+			return nil, errors.Newf("invalid LTSV field: %q", f)
+		}
+		fields[k] = v
+	}
+	return fields, nil
+}
+
+func parseJSON(line string) (map[string]string, error) {
+	var raw map[string]any
+	if err := json.Unmarshal([]byte(line), &raw); err != nil {
+		return nil, errors.Wrap(err, "invalid JSON")
+	}
+	fields := make(map[string]string, len(raw))
+	for k, v := range raw {
+		switch v := v.(type) {
+		case string:
+			fields[k] = v
+		case nil:
+		default:
+			fields[k] = fmt.Sprint(v)
+		}
+	}
+	return fields, nil
+}
+
+func newEntry(fields map[string]string) (*Entry, error) {
+	e := &Entry{Method: lookup(fields, methodKeys), URI: lookup(fields, uriKeys)}
+	if req := lookup(fields, requestKeys); req != "" && (e.Method == "" || e.URI == "") {
+		s := strings.Fields(req)
+		if len(s) >= 2 {
+			e.Method, e.URI = s[0], s[1]
+		}
+	}
+	if e.Method == "" || e.URI == "" {
+		return nil, errors.New("method and uri are not found")
+	}
+	if status := lookup(fields, statusKeys); status != "" {
+		s, err := strconv.Atoi(status)
+		if err != nil {
+			return nil, errors.Newf("invalid status: %q", status)
+		}
+		e.Status = s
+	}
+	if t := lookup(fields, timeKeys); t != "" {
+		for _, layout := range timeLayouts {
+			if parsed, err := time.Parse(layout, t); err == nil {
+				e.Time = parsed
+				break
+			}
+		}
+	}
+	return e, nil
+}
+
+func lookup(fields map[string]string, keys []string) string {
+	for _, k := range keys {
+		if v, ok := fields[k]; ok && v != "-" {
+			return v
+		}
+	}
+	return ""
+}
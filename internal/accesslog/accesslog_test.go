@@ -0,0 +1,97 @@
+package accesslog
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	log := "time:01/Mar/2024:12:00:00 +0900\tmethod:GET\turi:/users/1?page=2\tstatus:200\treqtime:0.010\n" +
+		"\n" +
+		"time:2024-03-01T12:00:10+09:00\treq:POST /users HTTP/1.1\tstatus:201\n" +
+		`{"time":"2024-03-01T12:01:00+09:00","method":"GET","uri":"/users/random","status":200,"response_time":0.002}` + "\n" +
+		`{"request":"DELETE /users/2 HTTP/1.1","status":"204"}` + "\n"
+	entries, err := Parse(strings.NewReader(log))
+	require.NoError(t, err)
+	require.Len(t, entries, 4)
+
+	jst := time.FixedZone("", 9*60*60)
+	assert.Equal(t, &Entry{Method: "GET", URI: "/users/1?page=2", Status: 200, Time: time.Date(2024, 3, 1, 12, 0, 0, 0, jst)}, entries[0])
+	assert.Equal(t, "POST", entries[1].Method)
+	assert.Equal(t, "/users", entries[1].URI)
+	assert.Equal(t, 201, entries[1].Status)
+	assert.Equal(t, "/users/random", entries[2].URI)
+	assert.Equal(t, 200, entries[2].Status)
+	assert.Equal(t, &Entry{Method: "DELETE", URI: "/users/2", Status: 204}, entries[3])
+}
+
+func TestParse_invalid(t *testing.T) {
+	_, err := Parse(strings.NewReader("status:200\n"))
+	assert.ErrorContains(t, err, "line 1: method and uri are not found")
+
+	_, err = Parse(strings.NewReader("method:GET\turi:/\n{\"method\":\n"))
+	assert.ErrorContains(t, err, "line 2: invalid JSON")
+}
+
+func TestNormalize(t *testing.T) {
+	routes := make([]*Route, 0)
+	for _, r := range [][]string{
+		{"GET", "/users/:id", "^/users/([^/]+)$"},
+		{"GET", "/users/random", "^/users/random$"},
+		{"ANY", "/users/{id}", "^/users/([^/]+)$"},
+		{"-", "/static/", "^/static/(.*)$"},
+	} {
+		route, err := NewRoute(r[0], r[1], r[2])
+		require.NoError(t, err)
+		routes = append(routes, route)
+	}
+
+	tests := []struct {
+		method string
+		uri    string
+		want   string
+	}{
+		{"GET", "/users/1?page=2", "GET /users/:id"},
+		{"GET", "/users/random", "GET /users/random"},
+		{"DELETE", "/users/1", "ANY /users/{id}"},
+		{"GET", "/static/css/main.css", "- /static/"},
+		{"GET", "/posts", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.uri, func(t *testing.T) {
+			r, ok := Normalize(routes, tt.method, tt.uri)
+			if tt.want == "" {
+				assert.False(t, ok)
+				return
+			}
+			require.True(t, ok)
+			assert.Equal(t, tt.want, r.String())
+		})
+	}
+}
+
+func TestCount(t *testing.T) {
+	users, err := NewRoute("GET", "/users/:id", "^/users/([^/]+)$")
+	require.NoError(t, err)
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	entries := []*Entry{
+		{Method: "GET", URI: "/users/1", Time: start.Add(time.Minute)},
+		{Method: "GET", URI: "/users/2", Time: start},
+		{Method: "GET", URI: "/posts"},
+	}
+
+	traffic := Count(entries, []*Route{users})
+	assert.Equal(t, map[*Route]int64{users: 2}, traffic.Requests)
+	assert.Equal(t, int64(1), traffic.Unmatched)
+	assert.Equal(t, time.Minute, traffic.Duration)
+	rate, ok := traffic.PerSecond(120)
+	assert.True(t, ok)
+	assert.Equal(t, 2.0, rate)
+
+	_, ok = Count(entries[2:], []*Route{users}).PerSecond(1)
+	assert.False(t, ok)
+}
@@ -0,0 +1,106 @@
+package accesslog
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+)
+
+// Route is a route pattern of the application. e.g. GET /users/:id
+type Route struct {
+	Method  string
+	Path    string
+	pattern *regexp.Regexp
+	literal int // the length of the path without parameters. The route with the longer literal is preferred
+}
+
+// anyMethods are the methods of the routes which accept any method.
+var anyMethods = []string{"", "ANY", "*", "-"}
+
+var paramGroupRegexp = regexp.MustCompile(`\([^)]*\)`)
+
+// NewRoute returns the route of the method and path. pattern is the regular expression which matches the path of the requests.
+func NewRoute(method, path, pattern string) (*Route, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid route pattern of %s %s", method, path)
+	}
+	return &Route{Method: method, Path: path, pattern: re, literal: len(paramGroupRegexp.ReplaceAllString(pattern, ""))}, nil
+}
+
+func (r *Route) String() string {
+	return r.Method + " " + r.Path
+}
+
+func (r *Route) match(method, path string) bool {
+	if r.Method != method && !r.anyMethod() {
+		return false
+	}
+	return r.pattern.MatchString(path)
+}
+
+func (r *Route) anyMethod() bool {
+	for _, m := range anyMethods {
+		if r.Method == m {
+			return true
+		}
+	}
+	return false
+}
+
+// Normalize returns the route which the request is routed to like alp's `--matching-groups`.
+// If multiple routes match, the route with the exact method and the longest literal path is chosen. e.g. /users/random rather than /users/:id
+func Normalize(routes []*Route, method, uri string) (*Route, bool) {
+	path, _, _ := strings.Cut(uri, "?")
+	var found *Route
+	for _, r := range routes {
+		if !r.match(method, path) {
+			continue
+		}
+		if found == nil || (found.anyMethod() && !r.anyMethod()) || (found.anyMethod() == r.anyMethod() && r.literal > found.literal) {
+			found = r
+		}
+	}
+	return found, found != nil
+}
+
+// Traffic is the number of requests of each route.
+type Traffic struct {
+	Requests  map[*Route]int64
+	Unmatched int64
+	Duration  time.Duration // the time span of the requests. zero if the time is not logged
+}
+
+// Count counts the requests of each route.
+func Count(entries []*Entry, routes []*Route) *Traffic {
+	t := &Traffic{Requests: make(map[*Route]int64)}
+	var first, last time.Time
+	for _, e := range entries {
+		if r, ok := Normalize(routes, e.Method, e.URI); ok {
+			t.Requests[r]++
+		} else {
+			t.Unmatched++
+		}
+		if e.Time.IsZero() {
+			continue
+		}
+		if first.IsZero() || e.Time.Before(first) {
+			first = e.Time
+		}
+		if last.IsZero() || e.Time.After(last) {
+			last = e.Time
+		}
+	}
+	t.Duration = last.Sub(first)
+	return t
+}
+
+// PerSecond returns the rate of n in the time span. It returns false if the time span is unknown.
+func (t *Traffic) PerSecond(n int64) (float64, bool) {
+	if t.Duration <= 0 {
+		return 0, false
+	}
+	return float64(n) / t.Duration.Seconds(), true
+}
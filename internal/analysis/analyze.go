@@ -2,53 +2,157 @@ package analysis
 
 import (
 	"context"
+	"fmt"
+	"go/ast"
+	"go/types"
 	"slices"
 
 	"github.com/haijima/analysisutil"
 	"github.com/haijima/analysisutil/ssautil"
+	"golang.org/x/tools/go/analysis/passes/buildssa"
+	"golang.org/x/tools/go/packages"
 	"golang.org/x/tools/go/ssa"
+	xssautil "golang.org/x/tools/go/ssa/ssautil"
 )
 
-func Analyze(ctx context.Context, dir, pattern string, opt *Option) (QueryResults, map[string]*CallGraph, error) {
-	results, err := analyzeSSA(ctx, dir, pattern, opt)
-	if err != nil {
-		return nil, nil, err
-	}
-	qrsByPkg := make(map[*ssa.Package]QueryResults)
-	for _, qr := range results {
-		qrsByPkg[qr.Posx.Func.Pkg] = append(qrsByPkg[qr.Posx.Func.Pkg], qr)
+// Result is the result of the analysis.
+type Result struct {
+	QueryResults   QueryResults
+	CallGraph      *CallGraph // nil if the result is of AnalyzePackage
+	Transactions   []*Transaction
+	ParseFailures  []*ParseFailure              // queries which could not be analyzed. Queries commented by `scone:ignore` are not included.
+	NonTargetCalls []*NonTargetCall             // collected only with Option.CollectNonTargetCalls
+	Wrappers       map[*ssa.Function]TargetCall // wrapper functions of target functions found in the analyzed code
+	wrapperFuncs   []TargetCall
+	ignoreComments []*ignoreComment
+}
+
+// IsIgnored returns true if the position is in the node commented by `scone:ignore` without rules or with the rule.
+func (r *Result) IsIgnored(rule string, pos *ssautil.Posx) bool {
+	if pos == nil || pos.Package().Path() == "" {
+		return false
 	}
-	cgs := make(map[string]*CallGraph)
-	for pkg, qrs := range qrsByPkg {
-		cg, err := BuildCallGraph(pkg, qrs)
-		if err != nil {
-			return nil, nil, err
+	for _, c := range r.ignoreComments {
+		if c.Package == nil || c.Package.Path() != pos.Package().Path() || (c.rules != nil && !slices.Contains(c.rules, rule)) {
+			continue
+		}
+		for _, p := range pos.Pos {
+			if p.IsValid() && c.Pos() <= p && p < c.End() {
+				return true
+			}
 		}
-		cgs[pkg.Pkg.Path()] = cg
 	}
-	return results, cgs, nil
+	return false
 }
 
-func analyzeSSA(ctx context.Context, dir, pattern string, opt *Option) (QueryResults, error) {
-	pkgs, err := analysisutil.LoadPackages(dir, pattern)
+// CheckIfTargetFunction is like the package-level CheckIfTargetFunction, but also matches the wrapper functions found in the analysis.
+func (r *Result) CheckIfTargetFunction(ctx context.Context, call *ssa.CallCommon, opt *Option) (ssa.Value, bool) {
+	if v, ok := CheckIfTargetFunction(ctx, call, opt); ok {
+		return v, true
+	}
+	return matchTargetCalls(call, r.wrapperFuncs)
+}
+
+// Analyze extracts the queries from the packages, and builds the call graph of the whole program by the algorithm of opt.CallGraph.
+func Analyze(ctx context.Context, dir, pattern string, opt *Option) (*Result, error) {
+	res, prog, err := analyzeSSA(ctx, dir, pattern, opt)
 	if err != nil {
 		return nil, err
 	}
+	res.CallGraph, err = BuildCallGraph(prog, res.QueryResults, opt.CallGraph)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
 
-	results := make([]*QueryResult, 0, len(pkgs))
-	for _, pkg := range pkgs {
-		ssaProg, err := ssautil.BuildSSA(pkg)
-		if err != nil {
-			return nil, err
+func analyzeSSA(ctx context.Context, dir, pattern string, opt *Option) (*Result, *ssa.Program, error) {
+	pkgs, err := analysisutil.LoadPackages(dir, pattern)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Build the packages in one program so that the functions are shared between the packages
+	prog, ssaPkgs := xssautil.Packages(pkgs, ssa.BuilderMode(0))
+	ssaProgs := make([]*buildssa.SSA, 0, len(pkgs))
+	srcFuncs := make([]*ssa.Function, 0)
+	initFuncs := make([]*ssa.Function, 0)
+	for i, pkg := range pkgs {
+		if ssaPkgs[i] == nil {
+			return nil, nil, fmt.Errorf("failed to build SSA of %s", pkg.PkgPath)
+		}
+		ssaPkgs[i].Build()
+		ssaProg := newSSA(pkg, ssaPkgs[i])
+		ssaProgs = append(ssaProgs, ssaProg)
+		srcFuncs = slices.Concat(srcFuncs, ssaProg.SrcFuncs)
+		if init := ssaProg.Pkg.Func("init"); init != nil {
+			initFuncs = append(initFuncs, init) // initializers of package globals
 		}
+	}
+
+	st := newState(opt)
+	// Find wrapper functions of target functions in advance, since they can be called from other packages
+	findWrapperFuncs(ctx, srcFuncs, st)
+	// Index prepared statements in advance, since they can be stored in other packages
+	st.stmtStores = IndexStmtStores(slices.Concat(srcFuncs, initFuncs))
+	st.callSites = IndexCallSites(srcFuncs)
 
-		queryResults, err := ExtractQuery(ctx, ssaProg, pkg.Syntax, opt)
+	results := make([]*QueryResult, 0, len(pkgs))
+	for i, pkg := range pkgs {
+		queryResults, err := extractQuery(ctx, ssaProgs[i], pkg.Syntax, st)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
 		results = slices.Concat(results, queryResults)
 	}
 
-	return results, nil
+	return st.result(ctx, results), prog, nil
+}
+
+// newSSA returns the SSA of the package with the source functions including function literals in source order like buildssa.Analyzer.
+func newSSA(pkg *packages.Package, ssaPkg *ssa.Package) *buildssa.SSA {
+	var funcs []*ssa.Function
+	var addAnons func(f *ssa.Function)
+	addAnons = func(f *ssa.Function) {
+		funcs = append(funcs, f)
+		for _, anon := range f.AnonFuncs {
+			addAnons(anon)
+		}
+	}
+	for _, f := range pkg.Syntax {
+		for _, decl := range f.Decls {
+			if fdecl, ok := decl.(*ast.FuncDecl); ok {
+				if fn, ok := pkg.TypesInfo.Defs[fdecl.Name].(*types.Func); ok {
+					if f := ssaPkg.Prog.FuncValue(fn); f != nil {
+						addAnons(f)
+					}
+				}
+			}
+		}
+	}
+	return &buildssa.SSA{Pkg: ssaPkg, SrcFuncs: funcs}
+}
+
+// AnalyzePackage extracts queries from a single package, e.g. in a pass of go/analysis.
+// Wrapper functions defined in other packages should be given by opt.AdditionalFuncs, and prepared statements stored in other packages are not resolved.
+func AnalyzePackage(ctx context.Context, ssaProg *buildssa.SSA, files []*ast.File, opt *Option) (*Result, error) {
+	st := newState(opt)
+	findWrapperFuncs(ctx, ssaProg.SrcFuncs, st)
+	funcs := slices.Clip(ssaProg.SrcFuncs)
+	if init := ssaProg.Pkg.Func("init"); init != nil {
+		funcs = append(funcs, init) // initializers of package globals
+	}
+	st.stmtStores = IndexStmtStores(funcs)
+	st.callSites = IndexCallSites(funcs)
+	qrs, err := extractQuery(ctx, ssaProg, files, st)
+	if err != nil {
+		return nil, err
+	}
+	for fn, t := range st.wrappers {
+		if obj := fn.Object(); obj != nil && obj.Exported() {
+			st.resolvedWrappers[t] = true // may be called from other packages
+		}
+	}
+	return st.result(ctx, qrs), nil
 }
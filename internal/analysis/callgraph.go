@@ -1,52 +1,110 @@
 package analysis
 
 import (
+	"fmt"
 	"slices"
 
 	"github.com/haijima/scone/internal/sql"
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/callgraph/cha"
+	"golang.org/x/tools/go/callgraph/rta"
 	"golang.org/x/tools/go/callgraph/static"
+	"golang.org/x/tools/go/callgraph/vta"
 	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
 )
 
+// Algorithms to build the call graph of the whole program
+const (
+	CallGraphStatic = "static" // only static calls
+	CallGraphCHA    = "cha"    // Class Hierarchy Analysis. Calls through interfaces and function values reach all the possible callees
+	CallGraphRTA    = "rta"    // Rapid Type Analysis from the entry points of the program. More precise than CHA
+	CallGraphVTA    = "vta"    // Variable Type Analysis refining CHA. The most precise but the slowest
+)
+
+var CallGraphAlgorithms = []string{CallGraphStatic, CallGraphCHA, CallGraphRTA, CallGraphVTA}
+
+// CallGraph is the graph of the functions which execute queries directly or indirectly, and the tables.
+// Nodes are keyed by Node.ID.
 type CallGraph struct {
 	Nodes map[string]*Node
 }
 
+// Lookup returns the node of the function. The function may belong to another SSA program built from the same package.
+func (r *CallGraph) Lookup(fn *ssa.Function) (*Node, bool) {
+	if fn == nil {
+		return nil, false
+	}
+	n, ok := r.Nodes[FuncID(fn)]
+	if !ok || !n.IsFunc() {
+		return nil, false
+	}
+	return n, true
+}
+
 func (r *CallGraph) AddNode(n *Node) {
-	if _, ok := r.Nodes[n.Name]; !ok {
-		r.Nodes[n.Name] = n
+	if _, ok := r.Nodes[n.ID]; !ok {
+		r.Nodes[n.ID] = n
 	}
 }
 
 func (r *CallGraph) AddFuncCallEdge(callerFunc, calleeFunc *ssa.Function) {
-	r.add(&Node{Name: callerFunc.Name(), Func: callerFunc}, &Node{Name: calleeFunc.Name(), Func: calleeFunc}, &Edge{})
+	r.add(newFuncNode(callerFunc), newFuncNode(calleeFunc), &Edge{})
 }
 
 func (r *CallGraph) AddQueryEdge(callerFunc *ssa.Function, calleeTable string, sqlValue *SqlValue) {
-	r.add(&Node{Name: callerFunc.Name(), Func: callerFunc}, &Node{Name: calleeTable}, &Edge{SqlValue: sqlValue})
+	r.add(newFuncNode(callerFunc), &Node{ID: calleeTable, Name: calleeTable}, &Edge{SqlValue: sqlValue})
 }
 
 func (r *CallGraph) add(caller, callee *Node, edge *Edge) {
-	if _, ok := r.Nodes[caller.Name]; !ok {
-		r.Nodes[caller.Name] = caller
+	if _, ok := r.Nodes[caller.ID]; !ok {
+		r.Nodes[caller.ID] = caller
 	}
-	if _, ok := r.Nodes[callee.Name]; !ok {
-		r.Nodes[callee.Name] = callee
+	if _, ok := r.Nodes[callee.ID]; !ok {
+		r.Nodes[callee.ID] = callee
 	}
 
-	edge.Caller = caller.Name
-	edge.Callee = callee.Name
+	edge.Caller = caller.ID
+	edge.Callee = callee.ID
 
-	contains := slices.ContainsFunc(r.Nodes[caller.Name].Out, func(e *Edge) bool {
-		if e.Callee != callee.Name {
+	contains := slices.ContainsFunc(r.Nodes[caller.ID].Out, func(e *Edge) bool {
+		if e.Callee != callee.ID {
 			return false
 		}
 		return (e.IsFuncCall() && edge.IsFuncCall()) || (e.IsQuery() && edge.IsQuery() && e.SqlValue.Kind == edge.SqlValue.Kind)
 	})
 	if !contains {
-		r.Nodes[caller.Name].Out = append(r.Nodes[caller.Name].Out, edge)
-		r.Nodes[callee.Name].In = append(r.Nodes[callee.Name].In, edge)
+		r.Nodes[caller.ID].Out = append(r.Nodes[caller.ID].Out, edge)
+		r.Nodes[callee.ID].In = append(r.Nodes[callee.ID].In, edge)
+	}
+}
+
+func newFuncNode(fn *ssa.Function) *Node {
+	return &Node{ID: FuncID(fn), Name: FuncName(fn), Func: fn}
+}
+
+// FuncID returns the identity of the function which is unique in the program and the same between SSA programs.
+// It consists of the package path, the receiver type, the name and the index of the closure.
+// e.g. "example.com/app.getUser", "(*example.com/app/repo.UserRepo).Get", "example.com/app.main$1"
+func FuncID(fn *ssa.Function) string {
+	if fn.Signature == nil {
+		// not a real function. e.g. the function of queries in comments outside functions
+		// The prefix keeps the ID apart from the table names.
+		return "func:" + fn.Name()
 	}
+	return fn.String()
+}
+
+// FuncName returns the name of the function relative to its package to display.
+// e.g. "getUser", "(*UserRepo).Get", "main$1"
+func FuncName(fn *ssa.Function) string {
+	if fn.Signature == nil {
+		return fn.Name()
+	}
+	if fn.Pkg != nil {
+		return fn.RelString(fn.Pkg.Pkg)
+	}
+	return fn.String()
 }
 
 func TopologicalSort(nodes map[string]*Node) []*Node {
@@ -70,7 +128,8 @@ func TopologicalSort(nodes map[string]*Node) []*Node {
 }
 
 type Node struct {
-	Name string
+	ID   string // FuncID for functions, and the table name for tables
+	Name string // FuncName for functions, and the table name for tables
 	In   []*Edge
 	Out  []*Edge
 	Func *ssa.Function
@@ -94,8 +153,8 @@ func (n *Node) IsNotRoot() bool {
 
 type Edge struct {
 	SqlValue *SqlValue
-	Caller   string
-	Callee   string
+	Caller   string // ID of the caller node
+	Callee   string // ID of the callee node
 }
 
 func (e *Edge) IsFuncCall() bool {
@@ -111,27 +170,54 @@ type SqlValue struct {
 	RawSQL string
 }
 
-func Walk(cg *CallGraph, in *Node, fn func(node *Node) bool) {
-	queue := []string{in.Name}
+// WalkDirection is the direction to follow the edges of the call graph
+type WalkDirection int
+
+const (
+	Callees WalkDirection = iota // from callers to callees and tables
+	Callers                      // from callees and tables to callers
+)
+
+// Walk visits the nodes reachable from the node in breadth-first order. The node itself is visited first.
+// depth is the number of function calls from the node. Queries are not counted, so the tables are visited at the same depth as the functions which query them.
+// If fn returns true, the nodes beyond the visited node are skipped.
+func Walk(cg *CallGraph, in *Node, dir WalkDirection, fn func(node *Node, depth int) bool) {
+	type item struct {
+		id    string
+		depth int
+	}
+	queue := []item{{in.ID, 0}}
 	seen := make(map[string]bool)
 	for len(queue) > 0 {
-		callee := queue[0]
+		it := queue[0]
 		queue = queue[1:]
-		if seen[callee] {
+		if seen[it.id] {
 			continue
 		}
-		seen[callee] = true
-		if n, exists := cg.Nodes[callee]; exists {
-			if skip := fn(n); !skip {
-				for _, e := range n.Out {
-					queue = append(queue, e.Callee)
-				}
+		seen[it.id] = true
+		n, exists := cg.Nodes[it.id]
+		if !exists {
+			continue
+		}
+		if skip := fn(n, it.depth); skip {
+			continue
+		}
+		edges, next := n.Out, func(e *Edge) string { return e.Callee }
+		if dir == Callers {
+			edges, next = n.In, func(e *Edge) string { return e.Caller }
+		}
+		for _, e := range edges {
+			d := it.depth
+			if e.IsFuncCall() {
+				d++
 			}
+			queue = append(queue, item{next(e), d})
 		}
 	}
 }
 
-func BuildCallGraph(pkg *ssa.Package, qrs []*QueryResult) (*CallGraph, error) {
+// BuildCallGraph builds the call graph of the whole program by the algorithm, and extracts the callers of the functions which execute the queries.
+func BuildCallGraph(prog *ssa.Program, qrs []*QueryResult, algorithm string) (*CallGraph, error) {
 	result := &CallGraph{Nodes: make(map[string]*Node)}
 	callerFuncs := make([]*ssa.Function, 0, len(qrs))
 	for _, qr := range qrs {
@@ -147,7 +233,10 @@ func BuildCallGraph(pkg *ssa.Package, qrs []*QueryResult) (*CallGraph, error) {
 		}
 	}
 
-	cg := static.CallGraph(pkg.Prog)
+	cg, err := programCallGraph(prog, algorithm)
+	if err != nil {
+		return nil, err
+	}
 	seen := make(map[*ssa.Function]bool)
 	for len(callerFuncs) > 0 {
 		fn := callerFuncs[0]
@@ -159,6 +248,9 @@ func BuildCallGraph(pkg *ssa.Package, qrs []*QueryResult) (*CallGraph, error) {
 		if node, ok := cg.Nodes[fn]; ok {
 			for _, edge := range node.In {
 				caller := edge.Caller.Func
+				if caller == nil || caller.Blocks == nil {
+					continue // the root of the call graph, or functions of the dependencies (e.g. reflect.Value.Call in RTA)
+				}
 				result.AddFuncCallEdge(caller, fn)
 
 				callerFuncs = append(callerFuncs, caller)
@@ -167,3 +259,64 @@ func BuildCallGraph(pkg *ssa.Package, qrs []*QueryResult) (*CallGraph, error) {
 	}
 	return result, nil
 }
+
+func programCallGraph(prog *ssa.Program, algorithm string) (*callgraph.Graph, error) {
+	switch algorithm {
+	case CallGraphStatic, "":
+		return static.CallGraph(prog), nil
+	case CallGraphCHA:
+		return cha.CallGraph(prog), nil
+	case CallGraphRTA:
+		return rtaCallGraph(entryPoints(prog)), nil
+	case CallGraphVTA:
+		return vta.CallGraph(ssautil.AllFunctions(prog), cha.CallGraph(prog)), nil
+	default:
+		return nil, fmt.Errorf("unknown callgraph algorithm: %s", algorithm)
+	}
+}
+
+// rtaCallGraph analyzes the program from the roots by RTA.
+// Since the bodies of the dependencies are not built, address-taken functions (e.g. HTTP handlers passed to net/http) are never called in the analysis.
+// So reachable address-taken functions in the analyzed packages are added to the roots until no more functions are found.
+func rtaCallGraph(roots []*ssa.Function) *callgraph.Graph {
+	seen := make(map[*ssa.Function]bool)
+	for _, fn := range roots {
+		seen[fn] = true
+	}
+	for {
+		res := rta.Analyze(roots, true)
+		added := false
+		for fn, r := range res.Reachable {
+			if r.AddrTaken && !seen[fn] && fn.Pkg != nil && fn.Syntax() != nil {
+				seen[fn] = true
+				roots = append(roots, fn)
+				added = true
+			}
+		}
+		if !added {
+			return res.CallGraph
+		}
+	}
+}
+
+// entryPoints returns main and init functions of the main packages.
+// If there is no main package (e.g. a library), all the functions in the analyzed packages are the entry points.
+func entryPoints(prog *ssa.Program) []*ssa.Function {
+	roots := make([]*ssa.Function, 0)
+	for _, pkg := range ssautil.MainPackages(prog.AllPackages()) {
+		for _, name := range []string{"main", "init"} {
+			if fn := pkg.Func(name); fn != nil {
+				roots = append(roots, fn)
+			}
+		}
+	}
+	if len(roots) > 0 {
+		return roots
+	}
+	for fn := range ssautil.AllFunctions(prog) {
+		if fn.Pkg != nil && fn.Synthetic == "" && len(fn.Blocks) > 0 {
+			roots = append(roots, fn)
+		}
+	}
+	return roots
+}
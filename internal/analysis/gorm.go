@@ -0,0 +1,503 @@
+package analysis
+
+import (
+	"context"
+	"fmt"
+	"go/types"
+	"log/slog"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/haijima/analysisutil/ssautil"
+	"golang.org/x/tools/go/ssa"
+)
+
+const gormPkgPath = "gorm.io/gorm"
+
+// gormChain is the state of a GORM method chain such as db.Model(&User{}).Where("id = ?", id).Find(&user)
+type gormChain struct {
+	model    ssa.Value // argument of Model()
+	table    string    // argument of Table()
+	selects  []string
+	joins    []string
+	conds    string
+	groups   []string
+	orders   []string
+	hasLimit bool
+	hasRaw   bool
+	unscoped bool // soft-deleted rows are included, and Delete deletes the rows
+}
+
+// SynthesizeGormQuery synthesizes SQL strings from the finisher method call of GORM.
+// e.g. db.Model(&User{}).Where("id = ?", id).Find(&user) => "SELECT * FROM users WHERE (id = ?)"
+// If the model has gorm.DeletedAt field (e.g. embedding gorm.Model), reads and updates exclude the soft-deleted rows, and Delete updates the field like GORM.
+func SynthesizeGormQuery(ctx context.Context, call *ssa.CallCommon) ([]string, bool) {
+	method, ok := gormMethodName(call)
+	if !ok {
+		return nil, false
+	}
+	var kinds []string
+	switch method {
+	case "First", "Take", "Last", "Find", "FindInBatches", "FirstOrInit", "Scan", "Row", "Rows", "Count", "Pluck":
+		kinds = []string{"SELECT"}
+	case "FirstOrCreate":
+		kinds = []string{"SELECT", "INSERT"}
+	case "Create", "CreateInBatches":
+		kinds = []string{"INSERT"}
+	case "Save", "Update", "Updates", "UpdateColumn", "UpdateColumns":
+		kinds = []string{"UPDATE"}
+	case "Delete":
+		kinds = []string{"DELETE"}
+	default:
+		return nil, false // chainable methods, Raw, Exec and so on
+	}
+
+	chain := walkGormChain(call.Args[0])
+	if chain.hasRaw {
+		return nil, false // the query is extracted from Raw()
+	}
+
+	// The model is given by Model(), or by the first argument of the finisher method
+	model := chain.model
+	if model == nil && !slices.Contains([]string{"Count", "Pluck", "Scan", "Update", "UpdateColumn"}, method) && len(call.Args) > 1 {
+		model = call.Args[1]
+	}
+	if model == nil && method == "Pluck" && len(call.Args) > 2 {
+		model = call.Args[2]
+	}
+
+	var named *types.Named
+	if model != nil {
+		named, _ = gormModelType(model)
+	}
+	table := chain.table
+	if table == "" && named != nil {
+		table = gormTableName(call.StaticCallee().Prog, named)
+	}
+	if table == "" {
+		slog.DebugContext(ctx, "Failed to detect the table of GORM query", slog.String("method", method))
+		return []string{""}, true // unknown query
+	}
+	var cols []string
+	pk := "id"
+	var deletedAt string
+	if named != nil {
+		cols, pk, deletedAt = gormColumns(named)
+	}
+	if chain.unscoped {
+		deletedAt = ""
+	}
+	// withNotDeleted adds the implicit condition of soft delete. e.g. "(id = ?) AND (items.deleted_at IS NULL)"
+	withNotDeleted := func(conds string) string {
+		if deletedAt == "" {
+			return conds
+		}
+		return andCond(conds, table+"."+deletedAt+" IS NULL")
+	}
+
+	// Inline conditions: e.g. db.First(&user, "id = ?", id), db.Delete(&User{}, id)
+	conds := chain.conds
+	switch method {
+	case "First", "Take", "Last", "Find", "FirstOrInit", "FirstOrCreate", "Delete":
+		if inline := sliceElems(call.Args[len(call.Args)-1]); len(inline) > 0 {
+			if strs, ok := ssautil.ValueToStrings(unwrapInterface(inline[0])); ok && len(strs) == 1 {
+				conds = andCond(conds, strs[0])
+			} else {
+				conds = andCond(conds, pk+" = ?")
+			}
+		}
+	}
+
+	res := make([]string, 0, len(kinds))
+	for _, kind := range kinds {
+		var b strings.Builder
+		switch kind {
+		case "SELECT":
+			b.WriteString("SELECT ")
+			switch {
+			case method == "Count":
+				b.WriteString("COUNT(*)")
+			case method == "Pluck":
+				if col, ok := ssautil.ValueToStrings(call.Args[1]); ok && len(col) == 1 {
+					b.WriteString(col[0])
+				} else {
+					b.WriteString("*")
+				}
+			case len(chain.selects) > 0:
+				b.WriteString(strings.Join(chain.selects, ", "))
+			default:
+				b.WriteString("*")
+			}
+			b.WriteString(" FROM " + table)
+			for _, j := range chain.joins {
+				b.WriteString(" " + j)
+			}
+			if where := withNotDeleted(conds); where != "" {
+				b.WriteString(" WHERE " + where)
+			}
+			if len(chain.groups) > 0 {
+				b.WriteString(" GROUP BY " + strings.Join(chain.groups, ", "))
+			}
+			if len(chain.orders) > 0 {
+				b.WriteString(" ORDER BY " + strings.Join(chain.orders, ", "))
+			} else if method == "First" || method == "Last" {
+				b.WriteString(" ORDER BY " + table + "." + pk)
+			}
+			if chain.hasLimit || method == "First" || method == "Take" || method == "Last" {
+				b.WriteString(" LIMIT ?")
+			}
+		case "INSERT":
+			placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(cols)), ", ")
+			b.WriteString(fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(cols, ", "), placeholders))
+		case "UPDATE":
+			setCols := slices.DeleteFunc(slices.Clone(cols), func(c string) bool { return c == pk })
+			if method == "Update" || method == "UpdateColumn" {
+				if col, ok := ssautil.ValueToStrings(call.Args[1]); ok && len(col) == 1 {
+					setCols = col
+				}
+			}
+			if len(setCols) == 0 {
+				slog.DebugContext(ctx, "Failed to detect the columns of GORM query", slog.String("method", method), slog.String("table", table))
+				return []string{""}, true // unknown query
+			}
+			sets := make([]string, 0, len(setCols))
+			for _, c := range setCols {
+				sets = append(sets, c+" = ?")
+			}
+			b.WriteString(fmt.Sprintf("UPDATE %s SET %s", table, strings.Join(sets, ", ")))
+			where := conds
+			if method == "Save" && where == "" {
+				where = pk + " = ?"
+			}
+			if where = withNotDeleted(where); where != "" {
+				b.WriteString(" WHERE " + where)
+			}
+		case "DELETE":
+			if conds == "" {
+				conds = pk + " = ?"
+			}
+			if deletedAt != "" {
+				b.WriteString(fmt.Sprintf("UPDATE %s SET %s = ? WHERE %s", table, deletedAt, withNotDeleted(conds))) // soft delete
+			} else {
+				b.WriteString(fmt.Sprintf("DELETE FROM %s WHERE %s", table, conds))
+			}
+		}
+		res = append(res, b.String())
+	}
+	return res, true
+}
+
+func gormMethodName(call *ssa.CallCommon) (string, bool) {
+	if call.IsInvoke() {
+		return "", false
+	}
+	fn := call.StaticCallee()
+	if fn == nil || fn.Signature.Recv() == nil || fn.Object() == nil || fn.Object().Pkg() == nil || fn.Object().Pkg().Path() != gormPkgPath {
+		return "", false
+	}
+	if ptr, ok := fn.Signature.Recv().Type().(*types.Pointer); !ok {
+		return "", false
+	} else if named, ok := ptr.Elem().(*types.Named); !ok || named.Obj().Name() != "DB" {
+		return "", false
+	}
+	return fn.Name(), true
+}
+
+// walkGormChain walks the receivers of the chainable methods backward.
+func walkGormChain(v ssa.Value) *gormChain {
+	chain := &gormChain{}
+	var calls []*ssa.CallCommon
+	for {
+		call, ok := v.(*ssa.Call)
+		if !ok {
+			break
+		}
+		if _, ok := gormMethodName(call.Common()); !ok {
+			break
+		}
+		calls = append(calls, call.Common())
+		v = call.Call.Args[0]
+	}
+	slices.Reverse(calls)
+
+	for _, call := range calls {
+		method, _ := gormMethodName(call)
+		switch method {
+		case "Model":
+			chain.model = call.Args[1]
+		case "Table":
+			if strs, ok := ssautil.ValueToStrings(call.Args[1]); ok && len(strs) == 1 {
+				chain.table = strs[0]
+			}
+		case "Select":
+			if strs, ok := ssautil.ValueToStrings(unwrapInterface(call.Args[1])); ok && len(strs) == 1 {
+				chain.selects = append(chain.selects, strs[0])
+			}
+		case "Joins", "InnerJoins":
+			if strs, ok := ssautil.ValueToStrings(call.Args[1]); ok && len(strs) == 1 {
+				j := strs[0]
+				if !strings.Contains(strings.ToUpper(j), "JOIN") {
+					continue // association name. e.g. db.Joins("Company")
+				}
+				chain.joins = append(chain.joins, j)
+			}
+		case "Where", "Not", "Or":
+			strs, ok := ssautil.ValueToStrings(unwrapInterface(call.Args[1]))
+			if !ok || len(strs) != 1 {
+				continue // struct or map conditions
+			}
+			switch method {
+			case "Where":
+				chain.conds = andCond(chain.conds, strs[0])
+			case "Not":
+				chain.conds = andCond(chain.conds, "NOT ("+gormInPlaceholder(strs[0])+")")
+			case "Or":
+				if chain.conds == "" {
+					chain.conds = andCond(chain.conds, strs[0])
+				} else {
+					chain.conds = fmt.Sprintf("%s OR (%s)", chain.conds, gormInPlaceholder(strs[0]))
+				}
+			}
+		case "Group":
+			if strs, ok := ssautil.ValueToStrings(call.Args[1]); ok && len(strs) == 1 {
+				chain.groups = append(chain.groups, strs[0])
+			}
+		case "Order":
+			if strs, ok := ssautil.ValueToStrings(unwrapInterface(call.Args[1])); ok && len(strs) == 1 {
+				chain.orders = append(chain.orders, strs[0])
+			}
+		case "Limit":
+			chain.hasLimit = true
+		case "Raw":
+			chain.hasRaw = true
+		case "Unscoped":
+			chain.unscoped = true
+		}
+	}
+	return chain
+}
+
+func andCond(conds, cond string) string {
+	cond = gormInPlaceholder(cond)
+	if conds == "" {
+		return "(" + cond + ")"
+	}
+	return groupOr(conds) + " AND (" + cond + ")"
+}
+
+// groupOr wraps the conditions in parentheses if they are joined by OR, so that the following condition applies to all of them like GORM.
+// e.g. "(a = ?) OR (b = ?)" -> "((a = ?) OR (b = ?))"
+func groupOr(conds string) string {
+	depth := 0
+	for i := 0; i < len(conds); i++ {
+		switch conds[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ' ':
+			if depth == 0 && strings.HasPrefix(conds[i:], " OR ") {
+				return "(" + conds + ")"
+			}
+		}
+	}
+	return conds
+}
+
+var gormInPlaceholderRegexp = regexp.MustCompile(`(?i)\bIN\s+\?`)
+
+// gormInPlaceholder replaces "IN ?" with "IN (?)" because GORM expands slice arguments
+func gormInPlaceholder(cond string) string {
+	return gormInPlaceholderRegexp.ReplaceAllString(cond, "IN (?)")
+}
+
+// gormModelType returns the struct type of the model. e.g. &User{}, &[]User{}, &[]*User{}
+func gormModelType(v ssa.Value) (*types.Named, bool) {
+	t := unwrapInterface(v).Type()
+	for range 10 {
+		switch u := types.Unalias(t).(type) {
+		case *types.Pointer:
+			t = u.Elem()
+		case *types.Slice:
+			t = u.Elem()
+		case *types.Named:
+			if _, ok := u.Underlying().(*types.Struct); ok {
+				return u, true
+			}
+			t = u.Underlying()
+		default:
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+// gormTableName returns the table name of the model.
+// If the model has TableName() method which returns a constant, it is used.
+// Otherwise, the table name is the plural snake case of the type name as GORM's default naming strategy.
+func gormTableName(prog *ssa.Program, named *types.Named) string {
+	if prog != nil && prog.Package(named.Obj().Pkg()) != nil {
+		for _, t := range []types.Type{named, types.NewPointer(named)} {
+			sel := prog.MethodSets.MethodSet(t).Lookup(named.Obj().Pkg(), "TableName")
+			if sel == nil {
+				continue
+			}
+			if fn := prog.MethodValue(sel); fn != nil {
+				for _, block := range fn.Blocks {
+					for _, instr := range block.Instrs {
+						if ret, ok := instr.(*ssa.Return); ok && len(ret.Results) == 1 {
+							if strs, ok := ssautil.ValueToStrings(ret.Results[0]); ok && len(strs) == 1 {
+								return strs[0]
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	return pluralize(toSnakeCase(named.Obj().Name()))
+}
+
+// gormColumns returns the column names, the primary key column and the column of gorm.DeletedAt of the model.
+// The column of gorm.DeletedAt is empty if the model is not soft-deletable.
+func gormColumns(named *types.Named) ([]string, string, string) {
+	cols := make([]string, 0)
+	pk := ""
+	deletedAt := ""
+	var walk func(st *types.Struct)
+	walk = func(st *types.Struct) {
+		for i := range st.NumFields() {
+			f := st.Field(i)
+			settings := gormTagSettings(reflect.StructTag(st.Tag(i)).Get("gorm"))
+			if _, ok := settings["-"]; ok || !f.Exported() {
+				continue
+			}
+			ft := types.Unalias(f.Type())
+			if ptr, ok := ft.(*types.Pointer); ok {
+				ft = ptr.Elem()
+			}
+			_, embedded := settings["embedded"]
+			if s, ok := ft.Underlying().(*types.Struct); ok && (f.Anonymous() || embedded) {
+				walk(s)
+				continue
+			}
+			if !isGormColumnType(ft) {
+				continue // associations
+			}
+			col := toSnakeCase(f.Name())
+			if c, ok := settings["column"]; ok && c != "" {
+				col = c
+			}
+			_, isPK := settings["primarykey"]
+			_, isPK2 := settings["primary_key"]
+			if isPK || isPK2 || (pk == "" && f.Name() == "ID") {
+				pk = col
+			}
+			if isGormDeletedAt(ft) && deletedAt == "" {
+				deletedAt = col
+			}
+			cols = append(cols, col)
+		}
+	}
+	if st, ok := named.Underlying().(*types.Struct); ok {
+		walk(st)
+	}
+	if pk == "" {
+		pk = "id"
+	}
+	return cols, pk, deletedAt
+}
+
+// isGormDeletedAt returns true if the type is gorm.DeletedAt, which enables soft delete.
+func isGormDeletedAt(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == gormPkgPath && named.Obj().Name() == "DeletedAt"
+}
+
+func isGormColumnType(t types.Type) bool {
+	switch u := t.Underlying().(type) {
+	case *types.Slice:
+		b, ok := u.Elem().Underlying().(*types.Basic)
+		return ok && b.Kind() == types.Byte // []byte
+	case *types.Map, *types.Chan, *types.Signature, *types.Interface:
+		return false
+	case *types.Struct:
+		if named, ok := t.(*types.Named); ok && named.Obj().Pkg() != nil {
+			switch named.Obj().Pkg().Path() {
+			case "time", "database/sql", gormPkgPath:
+				return true // time.Time, sql.NullString, gorm.DeletedAt and so on
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// gormTagSettings parses the gorm struct tag. e.g. `gorm:"column:name;primaryKey"`
+func gormTagSettings(tag string) map[string]string {
+	settings := make(map[string]string)
+	for _, s := range strings.Split(tag, ";") {
+		if k, v, _ := strings.Cut(s, ":"); strings.TrimSpace(k) != "" {
+			settings[strings.ToLower(strings.TrimSpace(k))] = strings.TrimSpace(v)
+		}
+	}
+	return settings
+}
+
+func toSnakeCase(s string) string {
+	var b strings.Builder
+	runes := []rune(s)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+var uncountables = []string{"equipment", "information", "rice", "money", "species", "series", "fish", "sheep", "jeans", "police"}
+
+var irregulars = map[string]string{"person": "people", "man": "men", "woman": "women", "child": "children", "sex": "sexes", "move": "moves", "mouse": "mice"}
+
+var pluralRules = []struct {
+	pattern *regexp.Regexp
+	replace string
+}{
+	{regexp.MustCompile(`(quiz)$`), "${1}zes"},
+	{regexp.MustCompile(`(matr|vert|ind)(?:ix|ex)$`), "${1}ices"},
+	{regexp.MustCompile(`(x|ch|ss|sh)$`), "${1}es"},
+	{regexp.MustCompile(`([^aeiouy]|qu)y$`), "${1}ies"},
+	{regexp.MustCompile(`(?:([^f])fe|([lr])f)$`), "${1}${2}ves"},
+	{regexp.MustCompile(`sis$`), "ses"},
+	{regexp.MustCompile(`([ti])um$`), "${1}a"},
+	{regexp.MustCompile(`(octop|vir)us$`), "${1}i"},
+	{regexp.MustCompile(`(alias|status|bus)$`), "${1}es"},
+	{regexp.MustCompile(`s$`), "s"},
+	{regexp.MustCompile(`$`), "s"},
+}
+
+// pluralize returns the plural form of the last word of the snake case string like github.com/jinzhu/inflection
+func pluralize(s string) string {
+	i := strings.LastIndex(s, "_")
+	prefix, word := s[:i+1], s[i+1:]
+	if slices.Contains(uncountables, word) {
+		return s
+	}
+	if p, ok := irregulars[word]; ok {
+		return prefix + p
+	}
+	for _, r := range pluralRules {
+		if r.pattern.MatchString(word) {
+			return prefix + r.pattern.ReplaceAllString(word, r.replace)
+		}
+	}
+	return s
+}
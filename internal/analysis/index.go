@@ -0,0 +1,176 @@
+package analysis
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"github.com/haijima/analysisutil/ssautil"
+	"github.com/haijima/scone/internal/sql"
+)
+
+// IndexSite is a lookup of a table by a query.
+type IndexSite struct {
+	Table   string
+	Columns []string // columns of the table compared by equality in WHERE and ON clauses
+	OrderBy []string // columns of the table in ORDER BY clause
+	Query   *sql.Query
+	Posx    *ssautil.Posx
+}
+
+// keys returns the columns which an index must start with to serve the site.
+func (s *IndexSite) keys() []string {
+	if len(s.Columns) > 0 {
+		return s.Columns
+	}
+	return s.OrderBy[:1]
+}
+
+// servedBy returns true if the index can be used for the lookup, i.e. the first column of the index is one of the keys.
+func (s *IndexSite) servedBy(idx *sql.Index) bool {
+	return slices.Contains(s.keys(), idx.Columns[0])
+}
+
+// IndexProposal is an index which serves the unindexed sites.
+type IndexProposal struct {
+	*sql.Index
+	Sites []*IndexSite
+}
+
+// UnindexedSites returns the lookups which are not served by any index prefix in the schema.
+// Tables which are not defined in the schema are skipped.
+func UnindexedSites(qrs QueryResults, schema *sql.Schema) []*IndexSite {
+	res := make([]*IndexSite, 0)
+	seen := make(map[string]bool)
+	for _, qr := range qrs {
+		for _, q := range qr.Queries() {
+			for _, site := range indexSites(q, qr.Posx, schema) {
+				key := qr.Posx.PositionString() + "|" + site.Table + "|" + strings.Join(site.keys(), ",")
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				if !slices.ContainsFunc(schema.Indexes(site.Table), site.servedBy) {
+					res = append(res, site)
+				}
+			}
+		}
+	}
+	return res
+}
+
+// indexSites returns the lookups of each table by the query.
+// A table is looked up by the equality columns, or scanned in order of the ORDER BY columns if the table drives the query.
+func indexSites(q *sql.Query, posx *ssautil.Posx, schema *sql.Schema) []*IndexSite {
+	if q.Kind == sql.Unknown {
+		return nil
+	}
+	res := make([]*IndexSite, 0, len(q.Tables))
+	for _, table := range q.Tables {
+		if _, ok := schema.Columns(table); !ok {
+			continue
+		}
+		site := &IndexSite{Table: table, Query: q, Posx: posx}
+		if cols, ok := q.FilterColumnMap[table]; ok {
+			site.Columns = cols.ToSlice()
+		}
+		site.Columns = appendTableColumns(site.Columns, table, q.JoinColumns)
+		slices.Sort(site.Columns)
+		if table == q.MainTable && slices.ContainsFunc(q.OrderByColumns, func(c string) bool { return strings.HasPrefix(c, table+".") }) {
+			site.OrderBy = appendTableColumns(nil, table, q.OrderByColumns)
+			if len(site.OrderBy) < len(q.OrderByColumns) {
+				site.OrderBy = nil // ordered by columns of other tables
+			}
+		}
+		if len(site.Columns) > 0 || len(site.OrderBy) > 0 {
+			res = append(res, site)
+		}
+	}
+	return res
+}
+
+// appendTableColumns appends the column names in "table.column" format which belong to the table.
+func appendTableColumns(cols []string, table string, tableColumns []string) []string {
+	for _, c := range tableColumns {
+		if name, ok := strings.CutPrefix(c, table+"."); ok && !slices.Contains(cols, name) {
+			cols = append(cols, name)
+		}
+	}
+	return cols
+}
+
+// ProposeIndexes returns composite indexes which serve the sites.
+// Indexes are chosen greedily so that each index starts with the column shared by the most sites,
+// followed by the other equality columns common to all of those sites and their ORDER BY columns.
+func ProposeIndexes(sites []*IndexSite) []*IndexProposal {
+	byTable := make(map[string][]*IndexSite)
+	for _, s := range sites {
+		byTable[s.Table] = append(byTable[s.Table], s)
+	}
+
+	res := make([]*IndexProposal, 0)
+	for table, rest := range byTable {
+		for len(rest) > 0 {
+			counts := make(map[string]int)
+			for _, s := range rest {
+				for _, c := range s.keys() {
+					counts[c]++
+				}
+			}
+			first := ""
+			for c, n := range counts {
+				if n > counts[first] || (n == counts[first] && c < first) {
+					first = c
+				}
+			}
+
+			covered := make([]*IndexSite, 0)
+			remaining := make([]*IndexSite, 0)
+			for _, s := range rest {
+				if slices.Contains(s.keys(), first) {
+					covered = append(covered, s)
+				} else {
+					remaining = append(remaining, s)
+				}
+			}
+			rest = remaining
+
+			res = append(res, &IndexProposal{Index: &sql.Index{Table: table, Columns: indexColumns(first, covered)}, Sites: covered})
+		}
+	}
+	for _, p := range res {
+		p.Name = "idx_" + p.Table + "_" + strings.Join(p.Columns, "_") // index names must be unique in the schema in PostgreSQL
+	}
+	slices.SortFunc(res, func(a, b *IndexProposal) int {
+		return cmp.Or(cmp.Compare(len(b.Sites), len(a.Sites)), strings.Compare(a.Table, b.Table), strings.Compare(a.Name, b.Name))
+	})
+	return res
+}
+
+// indexColumns returns the columns of the index which starts with the first column and serves all the sites.
+func indexColumns(first string, sites []*IndexSite) []string {
+	cols := []string{first}
+	common := slices.Clone(sites[0].Columns)
+	for _, s := range sites[1:] {
+		common = slices.DeleteFunc(common, func(c string) bool { return !slices.Contains(s.Columns, c) })
+	}
+	for _, c := range common {
+		if c != first {
+			cols = append(cols, c)
+		}
+	}
+
+	// the rows are sorted by the index if all the sites look up by the same columns and order by the same columns
+	orderBy := sites[0].OrderBy
+	for _, s := range sites {
+		if !slices.Equal(s.OrderBy, orderBy) || len(s.Columns) != len(common) {
+			return cols
+		}
+	}
+	for _, c := range orderBy {
+		if !slices.Contains(cols, c) {
+			cols = append(cols, c)
+		}
+	}
+	return cols
+}
@@ -4,7 +4,6 @@ import (
 	"errors"
 	"fmt"
 	"go/ast"
-	"go/token"
 	"go/types"
 	"log/slog"
 	"strconv"
@@ -17,11 +16,15 @@ import (
 
 type AnalyzeMode int
 
+// Option is the configuration of the analysis. It is not modified by the analysis, and the findings are returned as Result.
 type Option struct {
-	Code            string
-	AdditionalFuncs []string
-	expr            *FilterExpr
-	commentedNodes  []*NodeWithPackage
+	Code                  string
+	AdditionalFuncs       []string
+	Dialect               sql.Dialect
+	Schema                *sql.Schema
+	CollectNonTargetCalls bool
+	CallGraph             string // the algorithm to build the call graph. One of CallGraphAlgorithms
+	expr                  *FilterExpr
 }
 
 type NodeWithPackage struct {
@@ -29,6 +32,19 @@ type NodeWithPackage struct {
 	Package *types.Package
 }
 
+// ignoreComment is a `scone:ignore` comment which suppresses lint rules for the commented node.
+type ignoreComment struct {
+	*NodeWithPackage
+	rules []string // nil means all the rules
+}
+
+// ParseFailure is a query which could not be analyzed.
+type ParseFailure struct {
+	Message string
+	SQL     string // the string which failed to be parsed, or empty if the query could not be determined
+	Posx    *ssautil.Posx
+}
+
 func NewOption(code string, additionalFuncs []string) *Option {
 	opt := &Option{
 		Code:            code,
@@ -42,20 +58,16 @@ func NewOption(code string, additionalFuncs []string) *Option {
 	return opt
 }
 
-func (o *Option) IsCommented(pkg *types.Package, pos ...token.Pos) bool {
-	if pkg == nil || pkg.Path() == "" {
-		return false
-	}
-	for _, p := range pos {
-		if p.IsValid() {
-			for _, n := range o.commentedNodes {
-				if n.Package != nil && n.Node != nil && n.Pos() <= p && p < n.End() {
-					return true
-				}
-			}
-		}
-	}
-	return false
+func (o *Option) ParseString(str string) (*sql.Query, bool) {
+	return o.parser().ParseString(str)
+}
+
+func (o *Option) ParseTemplate(tmpl string) (*sql.Query, bool) {
+	return o.parser().ParseTemplate(tmpl)
+}
+
+func (o *Option) parser() *sql.Parser {
+	return &sql.Parser{Dialect: o.Dialect, Schema: o.Schema}
 }
 
 func (o *Option) AdditionalFuncSlice() []TargetCall {
@@ -102,6 +114,7 @@ func NewFilterExpr(code string) (*FilterExpr, error) {
 		cel.Variable("queryType", cel.StringType),
 		cel.Variable("tables", cel.ListType(cel.StringType)),
 		cel.Variable("hash", cel.StringType),
+		cel.Variable("fingerprint", cel.StringType),
 	)
 	if err != nil {
 		return nil, err
@@ -122,19 +135,21 @@ func (f *FilterExpr) Run(q *sql.Query, pos *ssautil.Posx) (bool, error) {
 	pkgName := pos.Package().Name()
 	pkgPath := pos.Package().Path()
 	file := pos.Position().Filename
-	funcName := pos.Func.Name()
+	funcName := FuncName(pos.Func)
 	queryType := q.Kind.String()
 	tables := q.Tables
 	hash := q.Hash()
+	fingerprint := q.Fingerprint()
 
 	out, _, err := f.program.Eval(map[string]any{
-		"pkgName":   pkgName,
-		"pkgPath":   pkgPath,
-		"file":      file,
-		"func":      funcName,
-		"queryType": queryType,
-		"tables":    tables,
-		"hash":      hash,
+		"pkgName":     pkgName,
+		"pkgPath":     pkgPath,
+		"file":        file,
+		"func":        funcName,
+		"queryType":   queryType,
+		"tables":      tables,
+		"hash":        hash,
+		"fingerprint": fingerprint,
 	})
 	if err != nil {
 		return false, err
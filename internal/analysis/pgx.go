@@ -0,0 +1,49 @@
+package analysis
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/haijima/analysisutil/ssautil"
+	"golang.org/x/tools/go/ssa"
+)
+
+var pgxCopyFromCalls = []string{
+	"(*github.com/jackc/pgx/v5.Conn).CopyFrom",
+	"(*github.com/jackc/pgx/v5/pgxpool.*).CopyFrom",
+	"github.com/jackc/pgx/v5.*.CopyFrom", // pgx.Tx
+}
+
+// SynthesizePgxCopyFrom synthesizes a bulk INSERT from CopyFrom of pgx.
+// e.g. conn.CopyFrom(ctx, pgx.Identifier{"users"}, []string{"id", "name"}, src) => "INSERT INTO users (id, name) VALUES (?, ?)"
+func SynthesizePgxCopyFrom(call *ssa.CallCommon) ([]string, bool) {
+	c := ssautil.GetCallInfo(call)
+	matched := false
+	for _, p := range pgxCopyFromCalls {
+		matched = matched || c.Match(p)
+	}
+	if !matched || c.ArgsLen() < 3 {
+		return nil, false
+	}
+
+	idents := make([]string, 0)
+	for _, v := range sliceElems(c.Arg(1)) {
+		strs, ok := ssautil.ValueToStrings(v)
+		if !ok || len(strs) != 1 {
+			return []string{""}, true // unknown query
+		}
+		idents = append(idents, strs[0])
+	}
+	if len(idents) == 0 {
+		return []string{""}, true // unknown query
+	}
+	cols := make([]string, 0)
+	for _, v := range sliceElems(c.Arg(2)) {
+		if strs, ok := ssautil.ValueToStrings(v); ok && len(strs) == 1 {
+			cols = append(cols, strs[0])
+		}
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(cols)), ", ")
+	return []string{fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", strings.Join(idents, "."), strings.Join(cols, ", "), placeholders)}, true
+}
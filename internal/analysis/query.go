@@ -5,6 +5,7 @@ import (
 	"go/ast"
 	"log/slog"
 	"slices"
+	"strings"
 
 	"github.com/haijima/analysisutil/astutil"
 	"github.com/haijima/analysisutil/ssautil"
@@ -13,14 +14,14 @@ import (
 	"golang.org/x/tools/go/ssa"
 )
 
-// ExtractQuery extracts queries from the given package.
-func ExtractQuery(ctx context.Context, ssaProg *buildssa.SSA, files []*ast.File, opt *Option) (QueryResults, error) {
+// extractQuery extracts queries from the given package.
+func extractQuery(ctx context.Context, ssaProg *buildssa.SSA, files []*ast.File, st *state) (QueryResults, error) {
 	// Get queries from comments
-	foundQueryResults := handleComments(ctx, ssaProg, files, opt)
+	foundQueryResults := handleComments(ctx, ssaProg, files, st)
 
 	// Get queries from source code
 	for _, member := range ssaProg.SrcFuncs {
-		foundQueryResults = slices.Concat(foundQueryResults, AnalyzeFunc(ctx, member, opt))
+		foundQueryResults = slices.Concat(foundQueryResults, analyzeFunc(ctx, member, st))
 	}
 
 	// Sort and compact
@@ -28,7 +29,7 @@ func ExtractQuery(ctx context.Context, ssaProg *buildssa.SSA, files []*ast.File,
 	return slices.CompactFunc(foundQueryResults, func(a, b *QueryResult) bool { return a.Compare(b) == 0 }), nil
 }
 
-func handleComments(ctx context.Context, ssaProg *buildssa.SSA, files []*ast.File, opt *Option) []*QueryResult {
+func handleComments(ctx context.Context, ssaProg *buildssa.SSA, files []*ast.File, st *state) []*QueryResult {
 	foundQueryResults := make([]*QueryResult, 0)
 	astutil.WalkCommentGroup(ssaProg.Pkg.Prog.Fset, files, func(n ast.Node, cg *ast.CommentGroup) bool {
 		qr := NewQueryResult(ssautil.NewPos(&ssa.Function{}, cg.Pos()))
@@ -41,14 +42,23 @@ func handleComments(ctx context.Context, ssaProg *buildssa.SSA, files []*ast.Fil
 			v, arg, _ := astutil.GetCommentVerb(comment, "scone")
 			switch v {
 			case "sql":
-				if q, ok := sql.ParseString(arg); ok && opt.Filter(q, qr.Posx) {
+				if q, ok := st.ParseString(arg); ok && st.Filter(q, qr.Posx) {
+					reportSchemaErrors(ctx, q, qr.Posx)
 					qr.Append(q)
-					opt.commentedNodes = append(opt.commentedNodes, &NodeWithPackage{Node: n, Package: ssaProg.Pkg.Pkg})
+					st.commentedNodes = append(st.commentedNodes, &NodeWithPackage{Node: n, Package: ssaProg.Pkg.Pkg})
 				} else {
 					slog.WarnContext(ctx, "Failed to parse string as SQL in scone:sql comment", slog.Any("", qr.Posx), slog.Any("string", arg))
+					st.addParseFailure("Failed to parse string as SQL in scone:sql comment", arg, qr.Posx)
 				}
 			case "ignore":
-				opt.commentedNodes = append(opt.commentedNodes, &NodeWithPackage{Node: n, Package: ssaProg.Pkg.Pkg})
+				// `scone:ignore` suppresses the warnings and all the lint rules, `scone:ignore <rule>...` suppresses only the rules
+				node := &NodeWithPackage{Node: n, Package: ssaProg.Pkg.Pkg}
+				rules := strings.Fields(strings.ReplaceAll(arg, ",", " "))
+				if len(rules) == 0 {
+					rules = nil
+					st.commentedNodes = append(st.commentedNodes, node)
+				}
+				st.ignoreComments = append(st.ignoreComments, &ignoreComment{NodeWithPackage: node, rules: rules})
 				return true
 			}
 		}
@@ -58,11 +68,11 @@ func handleComments(ctx context.Context, ssaProg *buildssa.SSA, files []*ast.Fil
 	return slices.DeleteFunc(foundQueryResults, func(qr *QueryResult) bool { return len(qr.Queries()) == 0 })
 }
 
-func AnalyzeFunc(ctx context.Context, fn *ssa.Function, opt *Option) QueryResults {
+func analyzeFunc(ctx context.Context, fn *ssa.Function, st *state) QueryResults {
 	foundQueryResults := make([]*QueryResult, 0)
 	// Analyze anonymous functions recursively
 	for _, anon := range fn.AnonFuncs {
-		foundQueryResults = slices.Concat(foundQueryResults, AnalyzeFunc(ctx, anon, opt))
+		foundQueryResults = slices.Concat(foundQueryResults, analyzeFunc(ctx, anon, st))
 	}
 
 	seen := map[*ssa.CallCommon]bool{}
@@ -74,21 +84,63 @@ func AnalyzeFunc(ctx context.Context, fn *ssa.Function, opt *Option) QueryResult
 				continue
 			}
 			seen[callCommon] = true
+			st.trackTx(fn, instr, callCommon)
+
+			// 1'. Check if the call executes a prepared statement and trace the statement back to Prepare
+			if stmt, ok := stmtCallRecv(callCommon); ok {
+				pos := ssautil.NewPos(fn, callCommon.Pos(), instr.Pos(), fn.Pos())
+				qr := NewQueryResult(pos)
+				for _, pq := range tracePreparedQuery(ctx, stmt, st, 0) {
+					if r := valueToValidQuery(ctx, pq.arg, st, pos); r != nil && len(r.Queries()) > 0 {
+						qr.Append(r.Queries()...)
+						if !slices.ContainsFunc(qr.PreparedAt, pq.pos.Equal) {
+							qr.PreparedAt = append(qr.PreparedAt, pq.pos)
+						}
+						st.tracedPrepares[pq.call] = true
+					}
+				}
+				if len(qr.Queries()) == 0 {
+					if reason := unknownQueryIfNotSkipped(stmt, st, pos); reason != "" {
+						slog.DebugContext(ctx, "Failed to trace the prepared statement: but warning is suppressed", slog.Any("", pos), slog.String("reason", string(reason)), slog.Any("stmt", stmt))
+						continue
+					}
+					slog.WarnContext(ctx, "Failed to trace the prepared statement", slog.Any("", pos), slog.Any("stmt", stmt))
+					st.addParseFailure("Failed to trace the prepared statement", "", pos)
+					qr.Append(&sql.Query{Kind: sql.Unknown})
+				}
+				qr.tx = callTx(callCommon)
+				foundQueryResults = append(foundQueryResults, qr)
+				continue
+			}
 
 			// 2. Check if the call is a target function and extract the target argument
-			targetArg, ok := CheckIfTargetFunction(ctx, callCommon, opt)
+			targetArg, ok := st.checkIfTargetFunction(callCommon)
 			if !ok {
-				if slog.Default().Enabled(ctx, slog.LevelWarn) {
+				// 2'. Check if the call is a finisher of a query builder and synthesize queries
+				if strs, ok := SynthesizeQuery(ctx, callCommon); ok {
+					pos := ssautil.NewPos(fn, callCommon.Pos(), instr.Pos(), fn.Pos())
+					qr := stringsToValidQuery(ctx, strs, nil, st, pos)
+					if qr != nil && len(qr.Queries()) > 0 {
+						qr.tx = callTx(callCommon)
+						foundQueryResults = append(foundQueryResults, qr)
+					}
+					continue
+				}
+				if st.CollectNonTargetCalls || slog.Default().Enabled(ctx, slog.LevelWarn) {
 					c := ssautil.GetCallInfo(callCommon)
 					for i := 0; i < c.ArgsLen(); i++ {
 						if strs, ok := ssautil.ValueToStrings(c.Arg(i)); ok {
+							pos := ssautil.NewPos(fn, c.Arg(i).Pos(), callCommon.Pos(), instr.Pos(), fn.Pos())
+							queries := make([]*sql.Query, 0, len(strs))
 							for _, str := range strs {
-								if q, ok := sql.ParseString(str); ok {
-									pos := ssautil.NewPos(fn, c.Arg(i).Pos(), callCommon.Pos(), instr.Pos(), fn.Pos())
-									slog.WarnContext(ctx, "Found a query in a non-target call", slog.Any("", pos), slog.String("call", c.Name()), slog.Int("index", i), slog.Any("SQL", q))
-									break
+								if q, ok := st.ParseString(str); ok && st.Filter(q, pos) {
+									queries = append(queries, q)
 								}
 							}
+							if len(queries) > 0 {
+								slog.WarnContext(ctx, "Found a query in a non-target call", slog.Any("", pos), slog.String("call", c.Name()), slog.Int("index", i), slog.Any("SQL", queries[0]))
+								st.addNonTargetCall(c, i, queries, pos)
+							}
 						}
 					}
 				}
@@ -97,8 +149,27 @@ func AnalyzeFunc(ctx context.Context, fn *ssa.Function, opt *Option) QueryResult
 
 			// 3. ssa.Value to filtered sql.Query
 			pos := ssautil.NewPos(fn, targetArg.Pos(), callCommon.Pos(), instr.Pos(), fn.Pos())
-			qr := valueToValidQuery(ctx, targetArg, opt, pos)
+			if _, ok := st.wrappers[fn]; ok && isForwardedParam(targetArg, fn) {
+				// The query is resolved at each call site of fn, and reported here only if no call site is resolved. See state.result.
+				if reason := unknownQueryIfNotSkipped(targetArg, st, pos); reason != "" {
+					slog.DebugContext(ctx, "Parameter passed to a target function: but warning is suppressed", slog.Any("", pos), slog.String("reason", string(reason)))
+					continue
+				}
+				qr := NewQueryResult(pos)
+				qr.Append(&sql.Query{Kind: sql.Unknown})
+				qr.tx = callTx(callCommon)
+				qr.forwarded = callCommon
+				foundQueryResults = append(foundQueryResults, qr)
+				continue
+			}
+			qr := valueToValidQuery(ctx, targetArg, st, pos)
 			if qr != nil && len(qr.Queries()) > 0 {
+				st.resolveWrapperCall(callCommon)
+				qr.tx = callTx(callCommon)
+				if isPrepareStmt(callCommon, instr) {
+					// The query is reported where the statement is executed if it is traced from there. See 1'.
+					qr.prepare = callCommon
+				}
 				foundQueryResults = append(foundQueryResults, qr)
 			}
 		}
@@ -107,42 +178,52 @@ func AnalyzeFunc(ctx context.Context, fn *ssa.Function, opt *Option) QueryResult
 	return foundQueryResults
 }
 
-func valueToValidQuery(ctx context.Context, v ssa.Value, opt *Option, pos *ssautil.Posx) *QueryResult {
+func valueToValidQuery(ctx context.Context, v ssa.Value, st *state, pos *ssautil.Posx) *QueryResult {
 
 	// 3-1. ssa.Value to string constants.
 	// Returns a slice considering the case where the argument value is a Phi node.
-	strs, ok := ssautil.ValueToStrings(v)
+	// The parts built dynamically by fmt.Sprintf or string concatenation are left as holes of the templates.
+	strs, ok := valueToTemplates(v)
+	if !ok || (hasHole(strs) && st.IsCommented(pos.Package(), pos.Pos...)) {
+		strs, ok = ssautil.ValueToStrings(v)
+	}
 	if !ok {
-		if reason := unknownQueryIfNotSkipped(v, opt, pos); reason != "" {
+		if reason := unknownQueryIfNotSkipped(v, st, pos); reason != "" {
 			slog.DebugContext(ctx, "Failed to convert ssa.Value to string constants: but warning is suppressed", slog.Any("", pos), slog.String("reason", string(reason)), slog.Any("value", v))
 			return nil
 		}
 		slog.WarnContext(ctx, "Failed to convert ssa.Value to string constants", slog.Any("", pos), slog.Any("value", v))
+		st.addParseFailure("Failed to convert ssa.Value to string constants", "", pos)
 		return &QueryResult{QueryGroup: sql.NewQueryGroupFrom(&sql.Query{Kind: sql.Unknown}), Posx: pos}
 	}
+	return stringsToValidQuery(ctx, strs, v, st, pos)
+}
 
+func stringsToValidQuery(ctx context.Context, strs []string, v ssa.Value, st *state, pos *ssautil.Posx) *QueryResult {
 	qr := NewQueryResult(pos)
 	slices.Sort(strs)
 	strs = slices.Compact(strs)
 	hasUnknown := false
 	for _, str := range strs {
 		// 3-2. Convert string constants to sql.Query
-		q, ok := sql.ParseString(str)
+		q, ok := st.ParseTemplate(str)
 		if !ok {
-			if reason := unknownQueryIfNotSkipped(v, opt, pos); reason != "" {
+			if reason := unknownQueryIfNotSkipped(v, st, pos); reason != "" {
 				slog.InfoContext(ctx, "Failed to parse string as SQL: but warning is suppressed", slog.Any("", pos), slog.String("reason", string(reason)), slog.Any("string", str))
 			} else {
 				slog.WarnContext(ctx, "Failed to parse string as SQL", slog.Any("", pos), slog.Any("string", str))
+				st.addParseFailure("Failed to parse string as SQL", str, pos)
 				hasUnknown = true
 			}
 			continue
 		}
 
 		// 3-3. Filter query
-		if !opt.Filter(q, pos) {
+		if !st.Filter(q, pos) {
 			slog.InfoContext(ctx, "Filtered query out", slog.Any("", pos), slog.Any("SQL", q))
 			continue
 		}
+		reportSchemaErrors(ctx, q, pos)
 		qr.Append(q)
 	}
 	if hasUnknown && len(qr.Queries()) == 0 {
@@ -151,6 +232,13 @@ func valueToValidQuery(ctx context.Context, v ssa.Value, opt *Option, pos *ssaut
 	return qr
 }
 
+// reportSchemaErrors logs the tables and columns which are not defined in the schema.
+func reportSchemaErrors(ctx context.Context, q *sql.Query, pos *ssautil.Posx) {
+	for _, e := range q.SchemaErrors {
+		slog.ErrorContext(ctx, "Query does not match the schema", slog.Any("", pos), slog.String("error", e), slog.Any("SQL", q))
+	}
+}
+
 type TargetCall struct {
 	NamePattern string // function name pattern
 	ArgIndex    int
@@ -159,6 +247,8 @@ type TargetCall struct {
 // Use knife to cut the target function.
 // knife -template knife.template database/sql | sort | uniq
 // knife -template knife.template github.com/jmoiron/sqlx | sort | uniq
+// knife -template knife.template github.com/jackc/pgx/v5 | sort | uniq
+// The queries of GORM's chainable methods and pgx's CopyFrom are synthesized by SynthesizeQuery.
 var targetCalls = []TargetCall{
 	{NamePattern: "(*database/sql.*).Exec", ArgIndex: 0},
 	{NamePattern: "(*database/sql.*).ExecContext", ArgIndex: 1},
@@ -224,11 +314,34 @@ var targetCalls = []TargetCall{
 	{NamePattern: "github.com/jmoiron/sqlx.Rebind", ArgIndex: 1},
 	{NamePattern: "github.com/jmoiron/sqlx.Select", ArgIndex: 2},
 	{NamePattern: "github.com/jmoiron/sqlx.SelectContext", ArgIndex: 3},
+	{NamePattern: "(*gorm.io/gorm.DB).Exec", ArgIndex: 0},
+	{NamePattern: "(*gorm.io/gorm.DB).Raw", ArgIndex: 0},
+	{NamePattern: "(*github.com/jackc/pgx/v5.Batch).Queue", ArgIndex: 0},
+	{NamePattern: "(*github.com/jackc/pgx/v5.Conn).Exec", ArgIndex: 1},
+	{NamePattern: "(*github.com/jackc/pgx/v5.Conn).Prepare", ArgIndex: 2},
+	{NamePattern: "(*github.com/jackc/pgx/v5.Conn).Query", ArgIndex: 1},
+	{NamePattern: "(*github.com/jackc/pgx/v5.Conn).QueryRow", ArgIndex: 1},
+	{NamePattern: "(*github.com/jackc/pgx/v5/pgxpool.*).Exec", ArgIndex: 1},
+	{NamePattern: "(*github.com/jackc/pgx/v5/pgxpool.*).Query", ArgIndex: 1},
+	{NamePattern: "(*github.com/jackc/pgx/v5/pgxpool.*).QueryRow", ArgIndex: 1},
+	{NamePattern: "github.com/jackc/pgx/v5.*.Exec", ArgIndex: 1},     // pgx.Tx
+	{NamePattern: "github.com/jackc/pgx/v5.*.Prepare", ArgIndex: 2},  // pgx.Tx
+	{NamePattern: "github.com/jackc/pgx/v5.*.Query", ArgIndex: 1},    // pgx.Tx
+	{NamePattern: "github.com/jackc/pgx/v5.*.QueryRow", ArgIndex: 1}, // pgx.Tx
 }
 
 func CheckIfTargetFunction(_ context.Context, call *ssa.CallCommon, opt *Option) (ssa.Value, bool) {
+	return matchTargetCalls(call, slices.Concat(targetCalls, opt.AdditionalFuncSlice()))
+}
+
+// checkIfTargetFunction is like CheckIfTargetFunction, but also matches the wrapper functions found so far.
+func (st *state) checkIfTargetFunction(call *ssa.CallCommon) (ssa.Value, bool) {
+	return matchTargetCalls(call, slices.Concat(targetCalls, st.AdditionalFuncSlice(), st.wrapperFuncs))
+}
+
+func matchTargetCalls(call *ssa.CallCommon, targets []TargetCall) (ssa.Value, bool) {
 	c := ssautil.GetCallInfo(call)
-	for _, t := range slices.Concat(targetCalls, opt.AdditionalFuncSlice()) {
+	for _, t := range targets {
 		if c.Match(t.NamePattern) {
 			return c.Arg(t.ArgIndex), true
 		}
@@ -236,12 +349,21 @@ func CheckIfTargetFunction(_ context.Context, call *ssa.CallCommon, opt *Option)
 	return nil, false
 }
 
+// SynthesizeQuery synthesizes SQL strings from the call which does not take a query string as an argument.
+// e.g. finisher methods of GORM, CopyFrom of pgx
+func SynthesizeQuery(ctx context.Context, call *ssa.CallCommon) ([]string, bool) {
+	if strs, ok := SynthesizeGormQuery(ctx, call); ok {
+		return strs, true
+	}
+	return SynthesizePgxCopyFrom(call)
+}
+
 type skipReason string
 
-func unknownQueryIfNotSkipped(v ssa.Value, opt *Option, pos *ssautil.Posx) skipReason {
-	if opt.IsCommented(pos.Package(), pos.Pos...) {
+func unknownQueryIfNotSkipped(v ssa.Value, st *state, pos *ssautil.Posx) skipReason {
+	if st.IsCommented(pos.Package(), pos.Pos...) {
 		return "No need to warn if v is commented by scone:sql or scone:ignore"
-	} else if !opt.Filter(&sql.Query{Kind: sql.Unknown}, pos) {
+	} else if !st.Filter(&sql.Query{Kind: sql.Unknown}, pos) {
 		return "No need to warn if v is filtered out"
 	}
 	if call, ok := ssautil.ValueToCallCommon(v); ok {
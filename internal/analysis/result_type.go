@@ -8,6 +8,7 @@ import (
 	"github.com/haijima/analysisutil/ssautil"
 	"github.com/haijima/scone/internal/sql"
 	"golang.org/x/exp/maps"
+	"golang.org/x/tools/go/ssa"
 )
 
 type QueryResults []*QueryResult
@@ -34,6 +35,10 @@ type QueryResult struct {
 	*sql.QueryGroup
 	Posx        *ssautil.Posx
 	FromComment bool
+	PreparedAt  []*ssautil.Posx // positions of the Prepare calls if the query is executed by a prepared statement
+	tx          string          // key of the transaction which the query is executed in
+	prepare     *ssa.CallCommon // the Prepare call if the query is reported where the statement is prepared
+	forwarded   *ssa.CallCommon // the call which the parameter of the wrapper function is passed to as the query
 }
 
 func NewQueryResult(pos *ssautil.Posx) *QueryResult {
@@ -0,0 +1,91 @@
+package analysis
+
+import (
+	"slices"
+
+	"golang.org/x/exp/maps"
+)
+
+// Slice returns the subgraph of the focused nodes and their callers and callees.
+// depth limits the number of function calls from the focused nodes. Zero or negative means no limit.
+func Slice(cg *CallGraph, focus []*Node, depth int) *CallGraph {
+	keep := make(map[string]bool)
+	visit := func(node *Node, d int) bool {
+		if depth > 0 && d > depth {
+			return true
+		}
+		keep[node.ID] = true
+		return false
+	}
+	for _, node := range focus {
+		Walk(cg, node, Callers, visit)
+		Walk(cg, node, Callees, visit)
+	}
+	return subgraph(cg, keep)
+}
+
+// CollapsePassThrough returns the graph in which the functions passing through a call are removed and their callers call their callees directly.
+// A function passes through a call if it is called by only one function, calls only one function and executes no queries. e.g. a thin service layer
+// The nodes in keep are never removed.
+func CollapsePassThrough(cg *CallGraph, keep []*Node) *CallGraph {
+	all := make(map[string]bool, len(cg.Nodes))
+	for id := range cg.Nodes {
+		all[id] = true
+	}
+	result := subgraph(cg, all)
+	kept := make(map[string]bool, len(keep))
+	for _, node := range keep {
+		kept[node.ID] = true
+	}
+
+	for collapsed := true; collapsed; {
+		collapsed = false
+		ids := maps.Keys(result.Nodes)
+		slices.Sort(ids)
+		for _, id := range ids {
+			node := result.Nodes[id]
+			if kept[id] || !passThrough(node) {
+				continue
+			}
+			in, out := node.In[0], node.Out[0]
+			caller, callee := result.Nodes[in.Caller], result.Nodes[out.Callee]
+			if caller == node || callee == node || caller == callee {
+				continue // recursion
+			}
+			caller.Out = slices.DeleteFunc(caller.Out, func(e *Edge) bool { return e == in })
+			callee.In = slices.DeleteFunc(callee.In, func(e *Edge) bool { return e == out })
+			delete(result.Nodes, id)
+			result.add(caller, callee, &Edge{})
+			collapsed = true
+		}
+	}
+	return result
+}
+
+func passThrough(node *Node) bool {
+	return node.IsFunc() && len(node.In) == 1 && len(node.Out) == 1 && node.Out[0].IsFuncCall()
+}
+
+// subgraph returns the copy of the graph which consists of the nodes in keep and the edges between them.
+func subgraph(cg *CallGraph, keep map[string]bool) *CallGraph {
+	ids := maps.Keys(keep)
+	slices.Sort(ids)
+	result := &CallGraph{Nodes: make(map[string]*Node, len(keep))}
+	for _, id := range ids {
+		if n, ok := cg.Nodes[id]; ok {
+			result.AddNode(&Node{ID: n.ID, Name: n.Name, Func: n.Func})
+		}
+	}
+	for _, id := range ids {
+		n, ok := cg.Nodes[id]
+		if !ok {
+			continue
+		}
+		for _, e := range n.Out {
+			if keep[e.Callee] {
+				result.add(result.Nodes[e.Caller], result.Nodes[e.Callee], &Edge{SqlValue: e.SqlValue})
+			}
+		}
+	}
+	return result
+}
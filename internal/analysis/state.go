@@ -0,0 +1,81 @@
+package analysis
+
+import (
+	"context"
+	"go/token"
+	"go/types"
+	"slices"
+
+	"github.com/haijima/analysisutil/ssautil"
+	"golang.org/x/tools/go/ssa"
+)
+
+// state is the findings collected while analyzing the packages. They are returned as Result.
+type state struct {
+	*Option
+	commentedNodes   []*NodeWithPackage
+	ignoreComments   []*ignoreComment
+	parseFailures    []*ParseFailure
+	wrapperFuncs     []TargetCall
+	wrappers         map[*ssa.Function]TargetCall
+	stmtStores       map[string][]ssa.Value
+	callSites        map[*ssa.Function][]*ssa.CallCommon
+	tracedPrepares   map[*ssa.CallCommon]bool // Prepare calls traced from the calls executing the statements
+	resolvedWrappers map[TargetCall]bool      // wrapper functions of which a call site passes a query
+	nonTargetCalls   []*NonTargetCall
+	txBegins         []*txBegin
+	txCalls          map[string][]*txCall
+	txEnds           map[string][]*TxEnd
+}
+
+func newState(opt *Option) *state {
+	return &state{
+		Option:           opt,
+		wrappers:         make(map[*ssa.Function]TargetCall),
+		stmtStores:       make(map[string][]ssa.Value),
+		callSites:        make(map[*ssa.Function][]*ssa.CallCommon),
+		tracedPrepares:   make(map[*ssa.CallCommon]bool),
+		resolvedWrappers: make(map[TargetCall]bool),
+		txCalls:          make(map[string][]*txCall),
+		txEnds:           make(map[string][]*TxEnd),
+	}
+}
+
+// result returns the findings with the queries.
+// Queries passed to Prepare are reported at the Prepare calls only if no execution of the statements is traced back to them.
+// Queries passed to wrapper functions are reported in the wrapper functions only if no call site of them is resolved.
+func (st *state) result(ctx context.Context, qrs QueryResults) *Result {
+	st.resolveForwardedQueries(ctx, qrs)
+	qrs = slices.DeleteFunc(qrs, func(qr *QueryResult) bool {
+		return (qr.prepare != nil && st.tracedPrepares[qr.prepare]) || (qr.forwarded != nil && st.resolvedWrappers[st.wrappers[qr.Posx.Func]])
+	})
+	return &Result{
+		QueryResults:   qrs,
+		Transactions:   transactions(qrs, st),
+		ParseFailures:  st.parseFailures,
+		NonTargetCalls: st.nonTargetCalls,
+		Wrappers:       st.wrappers,
+		wrapperFuncs:   st.wrapperFuncs,
+		ignoreComments: st.ignoreComments,
+	}
+}
+
+func (st *state) IsCommented(pkg *types.Package, pos ...token.Pos) bool {
+	if pkg == nil || pkg.Path() == "" {
+		return false
+	}
+	for _, p := range pos {
+		if p.IsValid() {
+			for _, n := range st.commentedNodes {
+				if n.Package != nil && n.Node != nil && n.Pos() <= p && p < n.End() {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func (st *state) addParseFailure(message, sql string, pos *ssautil.Posx) {
+	st.parseFailures = append(st.parseFailures, &ParseFailure{Message: message, SQL: sql, Posx: pos})
+}
@@ -0,0 +1,238 @@
+package analysis
+
+import (
+	"context"
+	"go/token"
+	"go/types"
+	"slices"
+	"strings"
+
+	"github.com/haijima/analysisutil/ssautil"
+	"golang.org/x/tools/go/ssa"
+)
+
+var stmtTypes = []string{
+	"*database/sql.Stmt",
+	"*github.com/jmoiron/sqlx.Stmt",
+	"*github.com/jmoiron/sqlx.NamedStmt",
+}
+
+var stmtMethods = []string{
+	"Exec", "ExecContext", "MustExec", "MustExecContext",
+	"Query", "QueryContext", "Queryx", "QueryxContext",
+	"QueryRow", "QueryRowContext", "QueryRowx", "QueryRowxContext",
+	"Get", "GetContext", "Select", "SelectContext",
+}
+
+// IndexStmtStores indexes the prepared statements stored in package globals, struct fields and maps.
+// The key is the name of the location. e.g. "example.com/app.stmt", "example.com/app.Repo.stmt", "example.com/app.stmts[]"
+// Values stored in other packages can be found by the same key because the key does not depend on ssa.Program.
+func IndexStmtStores(funcs []*ssa.Function) map[string][]ssa.Value {
+	stores := make(map[string][]ssa.Value)
+	var walk func(fn *ssa.Function)
+	walk = func(fn *ssa.Function) {
+		for _, anon := range fn.AnonFuncs {
+			walk(anon)
+		}
+		for _, block := range fn.Blocks {
+			for _, instr := range block.Instrs {
+				switch t := instr.(type) {
+				case *ssa.Store:
+					if key, ok := locationKey(t.Addr); ok && isStmtType(t.Val.Type()) {
+						stores[key] = append(stores[key], t.Val)
+					}
+				case *ssa.MapUpdate:
+					if load, ok := t.Map.(*ssa.UnOp); ok && load.Op == token.MUL && isStmtType(t.Value.Type()) {
+						if key, ok := locationKey(load.X); ok {
+							stores[key+"[]"] = append(stores[key+"[]"], t.Value)
+						}
+					}
+				}
+			}
+		}
+	}
+	for _, fn := range funcs {
+		walk(fn)
+	}
+	return stores
+}
+
+// stmtCallRecv returns the prepared statement if the call executes it.
+func stmtCallRecv(call *ssa.CallCommon) (ssa.Value, bool) {
+	c := ssautil.GetCallInfo(call)
+	m, ok := c.(*ssautil.StaticMethodCall)
+	if !ok {
+		return nil, false
+	}
+	for _, t := range stmtTypes {
+		for _, method := range stmtMethods {
+			if c.Match("(" + t + ")." + method) {
+				return m.Recv(), true
+			}
+		}
+	}
+	return nil, false
+}
+
+// IndexCallSites indexes the static calls of the functions by the callee.
+func IndexCallSites(funcs []*ssa.Function) map[*ssa.Function][]*ssa.CallCommon {
+	sites := make(map[*ssa.Function][]*ssa.CallCommon)
+	var walk func(fn *ssa.Function)
+	walk = func(fn *ssa.Function) {
+		for _, anon := range fn.AnonFuncs {
+			walk(anon)
+		}
+		for _, block := range fn.Blocks {
+			for _, instr := range block.Instrs {
+				if call, ok := ssautil.InstrToCallCommon(instr); ok {
+					if callee := call.StaticCallee(); callee != nil {
+						sites[callee] = append(sites[callee], call)
+					}
+				}
+			}
+		}
+	}
+	for _, fn := range funcs {
+		walk(fn)
+	}
+	return sites
+}
+
+// preparedQuery is the query argument of a Prepare call and the position of the call.
+type preparedQuery struct {
+	arg  ssa.Value
+	pos  *ssautil.Posx
+	call *ssa.CallCommon
+}
+
+// tracePreparedQuery returns the query arguments of Prepare calls which create the statement.
+func tracePreparedQuery(ctx context.Context, stmt ssa.Value, st *state, depth int) []preparedQuery {
+	if depth > 10 {
+		return nil
+	}
+	depth++
+	switch t := stmt.(type) {
+	case *ssa.Extract:
+		if call, ok := t.Tuple.(*ssa.Call); ok {
+			return tracePreparedQuery(ctx, call, st, depth)
+		}
+	case *ssa.Call:
+		c := ssautil.GetCallInfo(t.Common())
+		if matchAny(c, txStmtFuncs) {
+			// tx.Stmt(stmt) returns a transaction-specific prepared statement
+			return tracePreparedQuery(ctx, unwrapInterface(c.Arg(c.ArgsLen()-1)), st, depth)
+		}
+		if arg, ok := st.checkIfTargetFunction(t.Common()); ok && strings.HasPrefix(calleeName(c), "Prepare") {
+			return []preparedQuery{{arg: arg, pos: ssautil.NewPos(t.Parent(), arg.Pos(), t.Pos(), t.Parent().Pos()), call: t.Common()}}
+		}
+	case *ssa.UnOp:
+		if t.Op == token.MUL {
+			if key, ok := locationKey(t.X); ok {
+				return tracePreparedQueries(ctx, st.stmtStores[key], st, depth)
+			}
+			return tracePreparedQuery(ctx, t.X, st, depth) // the variable captured by a closure
+		}
+	case *ssa.Alloc:
+		// the variable captured by reference
+		stmts := make([]ssa.Value, 0)
+		for _, ref := range *t.Referrers() {
+			if store, ok := ref.(*ssa.Store); ok && store.Addr == t {
+				stmts = append(stmts, store.Val)
+			}
+		}
+		return tracePreparedQueries(ctx, stmts, st, depth)
+	case *ssa.FreeVar:
+		// the statement captured by a closure
+		fn := t.Parent()
+		idx := slices.Index(fn.FreeVars, t)
+		if fn.Parent() == nil {
+			return nil
+		}
+		for _, block := range fn.Parent().Blocks {
+			for _, instr := range block.Instrs {
+				if mc, ok := instr.(*ssa.MakeClosure); ok && mc.Fn == fn && idx < len(mc.Bindings) {
+					return tracePreparedQuery(ctx, mc.Bindings[idx], st, depth)
+				}
+			}
+		}
+	case *ssa.Parameter:
+		// the statement passed to a function
+		idx := slices.Index(t.Parent().Params, t)
+		stmts := make([]ssa.Value, 0)
+		for _, call := range st.callSites[t.Parent()] {
+			if idx < len(call.Args) {
+				stmts = append(stmts, call.Args[idx])
+			}
+		}
+		return tracePreparedQueries(ctx, stmts, st, depth)
+	case *ssa.Lookup:
+		if load, ok := t.X.(*ssa.UnOp); ok && load.Op == token.MUL {
+			if key, ok := locationKey(load.X); ok {
+				return tracePreparedQueries(ctx, st.stmtStores[key+"[]"], st, depth)
+			}
+		}
+	case *ssa.Phi:
+		return tracePreparedQueries(ctx, t.Edges, st, depth)
+	}
+	return nil
+}
+
+func tracePreparedQueries(ctx context.Context, stmts []ssa.Value, st *state, depth int) []preparedQuery {
+	res := make([]preparedQuery, 0, len(stmts))
+	for _, s := range stmts {
+		res = append(res, tracePreparedQuery(ctx, s, st, depth)...)
+	}
+	return res
+}
+
+// isPrepareStmt returns true if the call is a Prepare call which creates a prepared statement.
+// The query is reported where the statement is executed instead, unless the statement is not traced from there. See stmtCallRecv.
+func isPrepareStmt(call *ssa.CallCommon, instr ssa.Instruction) bool {
+	v, ok := instr.(ssa.Value)
+	if !ok || !strings.HasPrefix(calleeName(ssautil.GetCallInfo(call)), "Prepare") {
+		return false
+	}
+	t := v.Type()
+	if tuple, ok := t.(*types.Tuple); ok && tuple.Len() > 0 {
+		t = tuple.At(0).Type()
+	}
+	return isStmtType(t)
+}
+
+func calleeName(c ssautil.CallInfo) string {
+	switch t := c.(type) {
+	case ssautil.Method:
+		return t.Method().Name()
+	case *ssautil.StaticFunctionCall:
+		return t.Func().Name()
+	}
+	return ""
+}
+
+// locationKey returns the name of the location which the address points to.
+func locationKey(addr ssa.Value) (string, bool) {
+	switch t := addr.(type) {
+	case *ssa.Global:
+		return t.String(), true
+	case *ssa.FieldAddr:
+		ptr, ok := t.X.Type().Underlying().(*types.Pointer)
+		if !ok {
+			return "", false
+		}
+		st, ok := ptr.Elem().Underlying().(*types.Struct)
+		if !ok {
+			return "", false
+		}
+		return ptr.Elem().String() + "." + st.Field(t.Field).Name(), true
+	}
+	return "", false
+}
+
+func isStmtType(t types.Type) bool {
+	for _, s := range stmtTypes {
+		if t.String() == s {
+			return true
+		}
+	}
+	return false
+}
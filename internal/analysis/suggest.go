@@ -0,0 +1,72 @@
+package analysis
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/haijima/analysisutil/ssautil"
+	"github.com/haijima/scone/internal/sql"
+)
+
+// NonTargetCall is a call of a function which is not a target function but takes a query as an argument.
+type NonTargetCall struct {
+	TargetCall
+	Queries []*sql.Query
+	Posx    *ssautil.Posx
+}
+
+func (st *state) addNonTargetCall(c ssautil.CallInfo, argIdx int, queries []*sql.Query, pos *ssautil.Posx) {
+	if !st.CollectNonTargetCalls {
+		return
+	}
+	switch c.(type) {
+	case *ssautil.DynamicFunctionCall, *ssautil.BuiltinStaticFunctionCall, *ssautil.BuiltinDynamicMethodCall:
+		return // can not be specified by --analyze-funcs
+	}
+	st.nonTargetCalls = append(st.nonTargetCalls, &NonTargetCall{TargetCall: TargetCall{NamePattern: c.Name(), ArgIndex: argIdx}, Queries: queries, Posx: pos})
+}
+
+// FuncSuggestion is a function suggested to be analyzed additionally.
+type FuncSuggestion struct {
+	TargetCall
+	Queries mapset.Set[string] // hashes of distinct queries
+	Calls   []*NonTargetCall
+}
+
+// String returns the value for --analyze-funcs option.
+func (s *FuncSuggestion) String() string {
+	return s.NamePattern + "@" + strconv.Itoa(s.ArgIndex)
+}
+
+// SuggestFuncs aggregates the calls by the callee and the argument index,
+// and ranks them by the number of distinct queries passed to them.
+func SuggestFuncs(calls []*NonTargetCall) []*FuncSuggestion {
+	m := make(map[TargetCall]*FuncSuggestion)
+	for _, c := range calls {
+		s, ok := m[c.TargetCall]
+		if !ok {
+			s = &FuncSuggestion{TargetCall: c.TargetCall, Queries: mapset.NewThreadUnsafeSet[string]()}
+			m[c.TargetCall] = s
+		}
+		for _, q := range c.Queries {
+			s.Queries.Add(q.Hash())
+		}
+		s.Calls = append(s.Calls, c)
+	}
+
+	res := make([]*FuncSuggestion, 0, len(m))
+	for _, s := range m {
+		res = append(res, s)
+	}
+	slices.SortFunc(res, func(a, b *FuncSuggestion) int {
+		return cmp.Or(
+			cmp.Compare(b.Queries.Cardinality(), a.Queries.Cardinality()),
+			cmp.Compare(len(b.Calls), len(a.Calls)),
+			cmp.Compare(a.NamePattern, b.NamePattern),
+			cmp.Compare(a.ArgIndex, b.ArgIndex),
+		)
+	})
+	return res
+}
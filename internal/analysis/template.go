@@ -0,0 +1,167 @@
+package analysis
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"regexp"
+	"strings"
+
+	"github.com/haijima/analysisutil/ssautil"
+	"github.com/haijima/scone/internal/sql"
+	"golang.org/x/tools/go/ssa"
+)
+
+const maxTemplates = 64
+
+// valueToTemplates returns the possible query templates of the value.
+// The parts which can not be determined statically are replaced with sql.Hole or sql.ValueHole.
+// e.g.
+//
+//	fmt.Sprintf("SELECT * FROM %s WHERE id = ?", table) // -> "SELECT * FROM \x00 WHERE id = ?"
+//	"SELECT * FROM users WHERE id IN (" + placeholders + ")" // -> "SELECT * FROM users WHERE id IN (\x00)"
+//
+// It returns false if some of the templates have no static part.
+func valueToTemplates(v ssa.Value) ([]string, bool) {
+	tmpls := toTemplates(v, 0)
+	for _, t := range tmpls {
+		if strings.Trim(t, sql.Hole+sql.ValueHole) == "" {
+			return nil, false
+		}
+	}
+	return tmpls, len(tmpls) > 0
+}
+
+func hasHole(tmpls []string) bool {
+	for _, t := range tmpls {
+		if strings.ContainsAny(t, sql.Hole+sql.ValueHole) {
+			return true
+		}
+	}
+	return false
+}
+
+func toTemplates(v ssa.Value, depth int) []string {
+	if depth > 10 {
+		return []string{hole(v)}
+	}
+	depth++
+	switch t := v.(type) {
+	case *ssa.Phi:
+		res := make([]string, 0, len(t.Edges))
+		for _, edge := range t.Edges {
+			res = append(res, toTemplates(edge, depth)...)
+		}
+		return limit(res)
+	case *ssa.BinOp:
+		if t.Op == token.ADD && isString(t.Type()) {
+			return product(toTemplates(t.X, depth), toTemplates(t.Y, depth))
+		}
+	case *ssa.Call:
+		if ssautil.GetCallInfo(t.Common()).Match("fmt.Sprintf") {
+			if tmpls, ok := sprintfToTemplates(t, depth); ok {
+				return tmpls
+			}
+		}
+	}
+	if strs, ok := ssautil.ValueToStrings(v); ok {
+		return strs
+	}
+	return []string{hole(v)}
+}
+
+var fmtVerbRegexp = regexp.MustCompile(`%[-+# 0]*(?:\d+|\*)?(?:\.(?:\d+|\*)?)?(\[\d+])?[a-zA-Z%]`)
+
+// sprintfToTemplates returns the templates of fmt.Sprintf call whose format is constant.
+func sprintfToTemplates(call *ssa.Call, depth int) ([]string, bool) {
+	formats, ok := ssautil.ValueToStrings(call.Call.Args[0])
+	if !ok || len(formats) != 1 {
+		return nil, false
+	}
+	args := sliceElems(call.Call.Args[1])
+	format := formats[0]
+
+	res := []string{""}
+	argIdx := 0
+	last := 0
+	for _, loc := range fmtVerbRegexp.FindAllStringSubmatchIndex(format, -1) {
+		res = product(res, []string{format[last:loc[0]]})
+		last = loc[1]
+		spec := format[loc[0]:loc[1]]
+		if spec == "%%" {
+			res = product(res, []string{"%"})
+			continue
+		}
+		if loc[2] >= 0 || strings.Contains(spec, "*") || argIdx >= len(args) {
+			res = product(res, []string{sql.Hole}) // explicit argument indexes and star are not supported
+			argIdx++
+			continue
+		}
+		res = product(res, formatArg(spec, unwrapInterface(args[argIdx]), depth))
+		argIdx++
+	}
+	return product(res, []string{format[last:]}), true
+}
+
+func formatArg(spec string, arg ssa.Value, depth int) []string {
+	verb := spec[len(spec)-1]
+	if isInteger(arg.Type()) {
+		if ints, ok := ssautil.ValueToInts(arg); ok {
+			res := make([]string, 0, len(ints))
+			for _, i := range ints {
+				res = append(res, fmt.Sprintf(spec, i))
+			}
+			return res
+		}
+		return []string{sql.ValueHole}
+	}
+	if isString(arg.Type()) && (verb == 's' || verb == 'v') {
+		return toTemplates(arg, depth)
+	}
+	if isString(arg.Type()) && verb == 'q' {
+		if strs, ok := ssautil.ValueToStrings(arg); ok {
+			res := make([]string, 0, len(strs))
+			for _, s := range strs {
+				res = append(res, fmt.Sprintf(spec, s))
+			}
+			return res
+		}
+	}
+	return []string{hole(arg)}
+}
+
+// hole returns the placeholder for the value which can not be determined statically
+func hole(v ssa.Value) string {
+	if t, ok := v.Type().Underlying().(*types.Basic); ok && t.Info()&(types.IsNumeric|types.IsBoolean) != 0 {
+		return sql.ValueHole
+	}
+	return sql.Hole
+}
+
+func isString(t types.Type) bool {
+	b, ok := t.Underlying().(*types.Basic)
+	return ok && b.Info()&types.IsString != 0
+}
+
+func isInteger(t types.Type) bool {
+	b, ok := t.Underlying().(*types.Basic)
+	return ok && b.Info()&types.IsInteger != 0
+}
+
+// product returns the concatenations of all combinations of xs and ys.
+func product(xs, ys []string) []string {
+	res := make([]string, 0, len(xs)*len(ys))
+	for _, x := range xs {
+		for _, y := range ys {
+			res = append(res, x+y)
+		}
+	}
+	return limit(res)
+}
+
+func limit(strs []string) []string {
+	if len(strs) > maxTemplates {
+		return strs[:maxTemplates]
+	}
+	return strs
+}
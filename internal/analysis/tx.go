@@ -0,0 +1,344 @@
+package analysis
+
+import (
+	"go/token"
+	"go/types"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/haijima/analysisutil/ssautil"
+	"golang.org/x/tools/go/ssa"
+)
+
+var beginFuncs = []string{
+	"(*database/sql.DB).Begin",
+	"(*database/sql.DB).BeginTx",
+	"(*database/sql.Conn).BeginTx",
+	"(*github.com/jmoiron/sqlx.DB).Beginx",
+	"(*github.com/jmoiron/sqlx.DB).BeginTxx",
+	"(*github.com/jmoiron/sqlx.DB).MustBegin",
+	"(*github.com/jmoiron/sqlx.DB).MustBeginTx",
+	"(*github.com/jmoiron/sqlx.Conn).BeginTxx",
+	"(*github.com/jackc/pgx/v5.Conn).Begin",
+	"(*github.com/jackc/pgx/v5.Conn).BeginTx",
+	"(*github.com/jackc/pgx/v5/pgxpool.*).Begin",
+	"(*github.com/jackc/pgx/v5/pgxpool.*).BeginTx",
+}
+
+var commitFuncs = []string{
+	"(*database/sql.Tx).Commit",
+	"github.com/jackc/pgx/v5.Tx.Commit",
+}
+
+var rollbackFuncs = []string{
+	"(*database/sql.Tx).Rollback",
+	"github.com/jackc/pgx/v5.Tx.Rollback",
+}
+
+// txStmtFuncs returns a transaction-specific prepared statement from the statement of the last argument.
+var txStmtFuncs = []string{
+	"(*database/sql.Tx).Stmt",
+	"(*database/sql.Tx).StmtContext",
+	"(*github.com/jmoiron/sqlx.Tx).Stmtx",
+	"(*github.com/jmoiron/sqlx.Tx).StmtxContext",
+	"(*github.com/jmoiron/sqlx.Tx).NamedStmt",
+	"(*github.com/jmoiron/sqlx.Tx).NamedStmtContext",
+}
+
+var txTypes = []string{
+	"*database/sql.Tx",
+	"*github.com/jmoiron/sqlx.Tx",
+	"github.com/jackc/pgx/v5.Tx",
+}
+
+// Transaction is a database transaction from Begin to Commit or Rollback.
+type Transaction struct {
+	Posx    *ssautil.Posx // position of the Begin call
+	Queries QueryResults  // queries executed in the transaction in order
+	Ends    []*TxEnd      // Commit and Rollback calls in order
+}
+
+// TxEnd is a Commit or Rollback call of a transaction.
+type TxEnd struct {
+	Commit   bool
+	Deferred bool
+	Posx     *ssautil.Posx
+}
+
+func (e *TxEnd) String() string {
+	if e.Commit {
+		return "COMMIT"
+	}
+	return "ROLLBACK"
+}
+
+// Tables returns the tables touched in the transaction in order of appearance.
+func (t *Transaction) Tables() []string {
+	res := make([]string, 0)
+	for _, qr := range t.Queries {
+		for _, q := range qr.Queries() {
+			for _, table := range q.Tables {
+				if !slices.Contains(res, table) {
+					res = append(res, table)
+				}
+			}
+		}
+	}
+	return res
+}
+
+// LockedTables returns the tables locked by SELECT ... FOR UPDATE or write queries in the transaction.
+func (t *Transaction) LockedTables() []string {
+	res := make([]string, 0)
+	for _, qr := range t.Queries {
+		for _, q := range qr.Queries() {
+			if q.TakesLock() && q.MainTable != "" && !slices.Contains(res, q.MainTable) {
+				res = append(res, q.MainTable)
+			}
+		}
+	}
+	return res
+}
+
+type txCall struct {
+	callee string // key of the parameter which the transaction is passed to
+	posx   *ssautil.Posx
+}
+
+type txBegin struct {
+	key  string
+	posx *ssautil.Posx
+}
+
+// trackTx records the calls which begin, end or pass along transactions.
+func (st *state) trackTx(fn *ssa.Function, instr ssa.Instruction, call *ssa.CallCommon) {
+	c := ssautil.GetCallInfo(call)
+	pos := ssautil.NewPos(fn, call.Pos(), instr.Pos(), fn.Pos())
+	if matchAny(c, beginFuncs) {
+		st.txBegins = append(st.txBegins, &txBegin{key: beginKey(fn, call), posx: pos})
+		return
+	}
+	if commit, rollback := matchAny(c, commitFuncs), matchAny(c, rollbackFuncs); commit || rollback {
+		if key := callTx(call); key != "" {
+			_, deferred := instr.(*ssa.Defer)
+			st.txEnds[key] = append(st.txEnds[key], &TxEnd{Commit: commit, Deferred: deferred, Posx: pos})
+		}
+		return
+	}
+	callee := call.StaticCallee()
+	if callee == nil || len(callee.Blocks) == 0 {
+		return // the transaction is not passed to the source code
+	}
+	for i, arg := range call.Args {
+		if key := txOf(arg, 0); key != "" && i < len(callee.Params) {
+			st.txCalls[key] = append(st.txCalls[key], &txCall{callee: paramKey(callee, i), posx: pos})
+		}
+	}
+}
+
+// transactions returns the transactions begun in the analyzed code.
+// Queries executed in functions which take the transaction as a parameter are attributed to the transaction at each call site.
+func transactions(qrs QueryResults, st *state) []*Transaction {
+	qrsByTx := make(map[string]QueryResults)
+	for _, qr := range qrs {
+		if qr.tx != "" {
+			qrsByTx[qr.tx] = append(qrsByTx[qr.tx], qr)
+		}
+	}
+
+	type entry struct {
+		path []*ssautil.Posx
+		qr   *QueryResult
+		end  *TxEnd
+	}
+	comparePath := func(a, b []*ssautil.Posx) int {
+		return slices.CompareFunc(a, b, func(x, y *ssautil.Posx) int { return x.Compare(y) })
+	}
+
+	res := make([]*Transaction, 0, len(st.txBegins))
+	for _, b := range st.txBegins {
+		entries := make([]*entry, 0)
+		visiting := make(map[string]bool)
+		var collect func(key string, path []*ssautil.Posx)
+		collect = func(key string, path []*ssautil.Posx) {
+			if visiting[key] {
+				return // recursive call
+			}
+			visiting[key] = true
+			defer delete(visiting, key)
+			for _, qr := range qrsByTx[key] {
+				entries = append(entries, &entry{path: append(slices.Clone(path), qr.Posx), qr: qr})
+			}
+			for _, e := range st.txEnds[key] {
+				entries = append(entries, &entry{path: append(slices.Clone(path), e.Posx), end: e})
+			}
+			for _, c := range st.txCalls[key] {
+				collect(c.callee, append(slices.Clone(path), c.posx))
+			}
+		}
+		collect(b.key, nil)
+		slices.SortStableFunc(entries, func(a, b *entry) int { return comparePath(a.path, b.path) })
+
+		tx := &Transaction{Posx: b.posx, Queries: make(QueryResults, 0)}
+		deferred := make([]*TxEnd, 0)
+		for _, e := range entries {
+			if e.qr != nil {
+				tx.Queries = append(tx.Queries, e.qr)
+			} else if e.end.Deferred {
+				deferred = append(deferred, e.end)
+			} else {
+				tx.Ends = append(tx.Ends, e.end)
+			}
+		}
+		slices.Reverse(deferred) // deferred calls are executed at last in LIFO order
+		tx.Ends = slices.Concat(tx.Ends, deferred)
+		res = append(res, tx)
+	}
+	slices.SortFunc(res, func(a, b *Transaction) int { return a.Posx.Compare(b.Posx) })
+	return res
+}
+
+// callTx returns the key of the transaction which the call is executed in.
+func callTx(call *ssa.CallCommon) string {
+	return callTxAt(call, 0)
+}
+
+func callTxAt(call *ssa.CallCommon, depth int) string {
+	args := call.Args
+	if call.IsInvoke() {
+		args = slices.Insert(slices.Clone(args), 0, call.Value)
+	}
+	for _, arg := range args {
+		if key := txOf(arg, depth); key != "" {
+			return key
+		}
+	}
+	return ""
+}
+
+// txOf returns the key of the transaction which the value is or belongs to.
+// The key is either the Begin call or the parameter of the function which the transaction is passed to.
+func txOf(v ssa.Value, depth int) string {
+	if depth > 10 {
+		return ""
+	}
+	depth++
+	switch t := v.(type) {
+	case *ssa.Extract:
+		return txOf(t.Tuple, depth)
+	case *ssa.Call:
+		c := ssautil.GetCallInfo(t.Common())
+		if matchAny(c, beginFuncs) {
+			return beginKey(t.Parent(), t.Common())
+		}
+		if matchAny(c, txStmtFuncs) {
+			return callTxAt(t.Common(), depth)
+		}
+	case *ssa.Parameter:
+		if isTxType(t.Parent().Prog, t.Type()) {
+			return paramKey(t.Parent(), slices.Index(t.Parent().Params, t))
+		}
+	case *ssa.FreeVar:
+		// the transaction captured by a closure
+		fn := t.Parent()
+		idx := slices.Index(fn.FreeVars, t)
+		if fn.Parent() == nil {
+			return ""
+		}
+		for _, block := range fn.Parent().Blocks {
+			for _, instr := range block.Instrs {
+				if mc, ok := instr.(*ssa.MakeClosure); ok && mc.Fn == fn && idx < len(mc.Bindings) {
+					return txOf(mc.Bindings[idx], depth)
+				}
+			}
+		}
+	case *ssa.Alloc:
+		// the variable captured by reference
+		for _, ref := range *t.Referrers() {
+			if store, ok := ref.(*ssa.Store); ok && store.Addr == t {
+				if key := txOf(store.Val, depth); key != "" {
+					return key
+				}
+			}
+		}
+	case *ssa.UnOp:
+		if t.Op == token.MUL {
+			return txOf(t.X, depth)
+		}
+	case *ssa.FieldAddr:
+		// e.g. *sql.Tx embedded in *sqlx.Tx
+		if st, ok := t.X.Type().Underlying().(*types.Pointer); ok {
+			if s, ok := st.Elem().Underlying().(*types.Struct); ok && s.Field(t.Field).Embedded() {
+				return txOf(t.X, depth)
+			}
+		}
+	case *ssa.MakeInterface:
+		return txOf(t.X, depth)
+	case *ssa.ChangeType:
+		return txOf(t.X, depth)
+	case *ssa.Phi:
+		for _, edge := range t.Edges {
+			if key := txOf(edge, depth); key != "" {
+				return key
+			}
+		}
+	}
+	return ""
+}
+
+func beginKey(fn *ssa.Function, call *ssa.CallCommon) string {
+	return fn.String() + "#" + strconv.Itoa(int(call.Pos()))
+}
+
+func paramKey(fn *ssa.Function, idx int) string {
+	if fn.Origin() != nil {
+		fn = fn.Origin() // generic function
+	}
+	return fn.String() + "@" + strconv.Itoa(idx)
+}
+
+// isTxType returns true if the type is a transaction or an interface which a transaction satisfies.
+// e.g. *sql.Tx, sqlx.Ext, or user-defined interfaces like `interface{ ExecContext(...) }`
+func isTxType(prog *ssa.Program, t types.Type) bool {
+	if slices.Contains(txTypes, t.String()) {
+		return true
+	}
+	iface, ok := t.Underlying().(*types.Interface)
+	if !ok || iface.Empty() {
+		return false
+	}
+	for _, txType := range txTypes {
+		if tx := lookupType(prog, txType); tx != nil && types.Implements(tx, iface) {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupType returns the type of the name like "*database/sql.Tx" if the package is loaded in the program.
+func lookupType(prog *ssa.Program, name string) types.Type {
+	path, ptr := strings.CutPrefix(name, "*")
+	i := strings.LastIndex(path, ".")
+	pkg := prog.ImportedPackage(path[:i])
+	if pkg == nil {
+		return nil
+	}
+	obj, ok := pkg.Pkg.Scope().Lookup(path[i+1:]).(*types.TypeName)
+	if !ok {
+		return nil
+	}
+	if ptr {
+		return types.NewPointer(obj.Type())
+	}
+	return obj.Type()
+}
+
+func matchAny(c ssautil.CallInfo, patterns []string) bool {
+	for _, p := range patterns {
+		if c.Match(p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package analysis
+
+import (
+	"go/constant"
+	"slices"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// unwrapInterface returns the underlying value if v is converted to an interface.
+func unwrapInterface(v ssa.Value) ssa.Value {
+	for {
+		switch t := v.(type) {
+		case *ssa.MakeInterface:
+			v = t.X
+		case *ssa.ChangeInterface:
+			v = t.X
+		default:
+			return v
+		}
+	}
+}
+
+// sliceElems returns the elements of the slice literal or variadic arguments.
+// e.g. []string{"a", "b"}, f(a, b) for func f(args ...any)
+func sliceElems(v ssa.Value) []ssa.Value {
+	if ct, ok := v.(*ssa.ChangeType); ok {
+		v = ct.X
+	}
+	s, ok := v.(*ssa.Slice)
+	if !ok {
+		return nil
+	}
+	alloc, ok := s.X.(*ssa.Alloc)
+	if !ok || alloc.Referrers() == nil {
+		return nil
+	}
+	type elem struct {
+		idx int64
+		val ssa.Value
+	}
+	elems := make([]elem, 0)
+	for _, ref := range *alloc.Referrers() {
+		ia, ok := ref.(*ssa.IndexAddr)
+		if !ok || ia.Referrers() == nil {
+			continue
+		}
+		c, ok := ia.Index.(*ssa.Const)
+		if !ok || c.Value == nil || c.Value.Kind() != constant.Int {
+			continue
+		}
+		idx, _ := constant.Int64Val(c.Value)
+		for _, r := range *ia.Referrers() {
+			if store, ok := r.(*ssa.Store); ok && store.Addr == ia {
+				elems = append(elems, elem{idx: idx, val: store.Val})
+			}
+		}
+	}
+	slices.SortFunc(elems, func(a, b elem) int { return int(a.idx - b.idx) })
+	res := make([]ssa.Value, 0, len(elems))
+	for _, e := range elems {
+		res = append(res, e.val)
+	}
+	return res
+}
@@ -0,0 +1,149 @@
+package analysis
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+
+	"github.com/haijima/analysisutil/ssautil"
+	"golang.org/x/tools/go/ssa"
+)
+
+// findWrapperFuncs finds functions which pass one of their parameters to a target function as a query.
+// e.g.
+//
+//	func (r *Repo) get(ctx context.Context, dst any, q string, args ...any) error {
+//	    return r.db.GetContext(ctx, dst, q, args...) // <--- q is the parameter of get
+//	}
+//
+// The found functions are registered to the state and treated as target functions, so that queries are resolved at each call site of them.
+// Wrappers of wrappers are also found by repeating the search until no new wrapper is found.
+func findWrapperFuncs(ctx context.Context, funcs []*ssa.Function, st *state) []TargetCall {
+	all := make([]*ssa.Function, 0, len(funcs))
+	var collect func(fn *ssa.Function)
+	collect = func(fn *ssa.Function) {
+		all = append(all, fn)
+		for _, anon := range fn.AnonFuncs {
+			collect(anon)
+		}
+	}
+	for _, fn := range funcs {
+		collect(fn)
+	}
+
+	found := make(map[*ssa.Function]bool)
+	for changed := true; changed; {
+		changed = false
+		for _, fn := range all {
+			if found[fn] {
+				continue
+			}
+			if t, ok := findWrapperFunc(ctx, fn, st); ok {
+				slog.DebugContext(ctx, "Found a wrapper function", slog.String("func", t.NamePattern), slog.Int("index", t.ArgIndex))
+				st.wrapperFuncs = append(st.wrapperFuncs, t)
+				st.wrappers[fn] = t
+				found[fn] = true
+				changed = true
+			}
+		}
+	}
+	return st.wrapperFuncs
+}
+
+func findWrapperFunc(ctx context.Context, fn *ssa.Function, st *state) (TargetCall, bool) {
+	for _, block := range fn.Blocks {
+		for _, instr := range block.Instrs {
+			callCommon, ok := ssautil.InstrToCallCommon(instr)
+			if !ok {
+				continue
+			}
+			targetArg, ok := st.checkIfTargetFunction(callCommon)
+			if !ok {
+				continue
+			}
+			if !isForwardedParam(targetArg, fn) {
+				continue
+			}
+			p, _ := forwardedParam(targetArg)
+			return wrapperTargetCall(fn, slices.Index(fn.Params, p))
+		}
+	}
+	return TargetCall{}, false
+}
+
+// wrapperTargetCall returns TargetCall which matches the calls of fn.
+// The name pattern and the argument index are the same as the ones of ssautil.CallInfo.
+func wrapperTargetCall(fn *ssa.Function, paramIdx int) (TargetCall, bool) {
+	if paramIdx < 0 {
+		return TargetCall{}, false
+	}
+	if fn.Origin() != nil {
+		fn = fn.Origin() // generic function
+	}
+	if recv := fn.Signature.Recv(); recv != nil {
+		if paramIdx == 0 {
+			return TargetCall{}, false // receiver is not a query
+		}
+		return TargetCall{NamePattern: "(" + recv.Type().String() + ")." + fn.Name(), ArgIndex: paramIdx - 1}, true
+	}
+	if fn.Pkg == nil {
+		return TargetCall{}, false
+	}
+	return TargetCall{NamePattern: fn.Pkg.Pkg.Path() + "." + fn.Name(), ArgIndex: paramIdx}, true
+}
+
+// isForwardedParam returns true if v is a parameter of fn or a type conversion of it.
+func isForwardedParam(v ssa.Value, fn *ssa.Function) bool {
+	p, ok := forwardedParam(v)
+	return ok && p.Parent() == fn
+}
+
+// resolveWrapperCall records the wrapper functions which the call matches, since a query is found at the call.
+func (st *state) resolveWrapperCall(call *ssa.CallCommon) {
+	c := ssautil.GetCallInfo(call)
+	for _, t := range st.wrapperFuncs {
+		if c.Match(t.NamePattern) {
+			st.resolvedWrappers[t] = true
+		}
+	}
+}
+
+// resolveForwardedQueries resolves the wrapper functions called by the resolved wrappers of them, and warns the queries passed through the wrapper functions of which no call site is resolved.
+// e.g. the wrapper function is called only through an interface.
+func (st *state) resolveForwardedQueries(ctx context.Context, qrs QueryResults) {
+	forwarded := slices.DeleteFunc(slices.Clone(qrs), func(qr *QueryResult) bool { return qr.forwarded == nil })
+	for changed := true; changed; {
+		changed = false
+		for _, qr := range forwarded {
+			if !st.resolvedWrappers[st.wrappers[qr.Posx.Func]] {
+				continue
+			}
+			c := ssautil.GetCallInfo(qr.forwarded)
+			for _, t := range st.wrapperFuncs {
+				if c.Match(t.NamePattern) && !st.resolvedWrappers[t] {
+					st.resolvedWrappers[t] = true
+					changed = true
+				}
+			}
+		}
+	}
+	for _, qr := range forwarded {
+		if !st.resolvedWrappers[st.wrappers[qr.Posx.Func]] {
+			slog.WarnContext(ctx, "Failed to resolve the query passed to the wrapper function at any call site", slog.Any("", qr.Posx))
+			st.addParseFailure("Failed to resolve the query passed to the wrapper function at any call site", "", qr.Posx)
+		}
+	}
+}
+
+// forwardedParam returns the parameter if v is a parameter itself or a type conversion of it.
+func forwardedParam(v ssa.Value) (*ssa.Parameter, bool) {
+	switch t := v.(type) {
+	case *ssa.Parameter:
+		return t, true
+	case *ssa.ChangeType:
+		return forwardedParam(t.X)
+	case *ssa.Convert:
+		return forwardedParam(t.X)
+	}
+	return nil, false
+}
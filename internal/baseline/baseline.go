@@ -0,0 +1,258 @@
+package baseline
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+)
+
+// Version is the version of the baseline file.
+const Version = 2
+
+// Snapshot is the analysis result which is compared by stable keys. It does not contain positions so that moving code does not change it.
+type Snapshot struct {
+	Version int      `json:"version"`
+	Queries []*Query `json:"queries"`
+	Crud    []*Crud  `json:"crud"`
+	Loops   []*Loop  `json:"loops"`
+}
+
+type Query struct {
+	PackagePath string   `json:"packagePath"`
+	Function    string   `json:"function"` // the name in the package. e.g. "(*Repo).Get", "main$1"
+	Hash        string   `json:"hash"`
+	Kind        string   `json:"kind"`
+	Tables      []string `json:"tables"`
+	Raw         string   `json:"raw"`
+	Index       int      `json:"index,omitempty"` // the order of the unknown queries of the same hash in the function
+}
+
+func (q *Query) Key() string {
+	if q.Index > 0 {
+		return q.PackagePath + "." + q.Function + " " + q.Hash + "#" + strconv.Itoa(q.Index)
+	}
+	return q.PackagePath + "." + q.Function + " " + q.Hash
+}
+
+// IsWrite returns true if the query modifies the table.
+func (q *Query) IsWrite() bool {
+	return slices.Contains([]string{"INSERT", "UPDATE", "DELETE", "REPLACE"}, q.Kind)
+}
+
+// Crud is a cell of the CRUD matrix of `scone crud`.
+type Crud struct {
+	Method     string `json:"method"`
+	Path       string `json:"path"`
+	Function   string `json:"function"`
+	Table      string `json:"table"`
+	Operations string `json:"operations"` // e.g. "CR"
+}
+
+func (c *Crud) Key() string {
+	return c.Method + " " + c.Path + " " + c.Table
+}
+
+// Loop is the calls of a query function or a function which executes queries in for loops of a function.
+type Loop struct {
+	PackagePath string   `json:"packagePath"`
+	Function    string   `json:"function"`
+	Callee      string   `json:"callee"`
+	Count       int      `json:"count"`
+	Hashes      []string `json:"hashes"`
+}
+
+func (l *Loop) Key() string {
+	return l.PackagePath + "." + l.Function + " -> " + l.Callee
+}
+
+func (l *Loop) String() string {
+	return fmt.Sprintf("%s (%d calls)", l.Callee, l.Count)
+}
+
+// New returns an empty snapshot of the current version.
+func New() *Snapshot {
+	return &Snapshot{Version: Version, Queries: make([]*Query, 0), Crud: make([]*Crud, 0), Loops: make([]*Loop, 0)}
+}
+
+// AddQuery adds the query if the same query is not added to the function yet.
+// Unknown queries are all added since they can not be told apart by the hash. They are numbered in order of addition instead.
+func (s *Snapshot) AddQuery(q *Query) {
+	if q.Kind == "UNKNOWN" {
+		for slices.ContainsFunc(s.Queries, func(o *Query) bool { return o.Key() == q.Key() }) {
+			q.Index++
+		}
+	}
+	if !slices.ContainsFunc(s.Queries, func(o *Query) bool { return o.Key() == q.Key() }) {
+		s.Queries = append(s.Queries, q)
+	}
+}
+
+// AddLoop adds the call in a loop. Calls of the same callee in the same function are counted up.
+func (s *Snapshot) AddLoop(l *Loop) {
+	if i := slices.IndexFunc(s.Loops, func(o *Loop) bool { return o.Key() == l.Key() }); i >= 0 {
+		s.Loops[i].Count += l.Count
+		for _, h := range l.Hashes {
+			if !slices.Contains(s.Loops[i].Hashes, h) {
+				s.Loops[i].Hashes = append(s.Loops[i].Hashes, h)
+			}
+		}
+		return
+	}
+	s.Loops = append(s.Loops, l)
+}
+
+// Sort sorts the elements by their keys so that the snapshot is written in a stable order.
+func (s *Snapshot) Sort() {
+	slices.SortFunc(s.Queries, func(a, b *Query) int { return strings.Compare(a.Key(), b.Key()) })
+	slices.SortFunc(s.Crud, func(a, b *Crud) int { return strings.Compare(a.Key(), b.Key()) })
+	slices.SortFunc(s.Loops, func(a, b *Loop) int { return strings.Compare(a.Key(), b.Key()) })
+	for _, l := range s.Loops {
+		slices.Sort(l.Hashes)
+	}
+}
+
+func (s *Snapshot) Write(w io.Writer) error {
+	s.Sort()
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+// Read reads the baseline file written by Write.
+func Read(r io.Reader) (*Snapshot, error) {
+	s := New()
+	if err := json.NewDecoder(r).Decode(s); err != nil {
+		return nil, errors.Wrap(err, "failed to read baseline")
+	}
+	if s.Version != Version {
+		return nil, errors.Newf("unsupported baseline version: %d", s.Version)
+	}
+	return s, nil
+}
+
+type ChangeType string
+
+const (
+	Added   ChangeType = "added"
+	Removed ChangeType = "removed"
+	Changed ChangeType = "changed"
+)
+
+// Change is a difference between the baseline and the current snapshot.
+type Change struct {
+	Type     ChangeType
+	Category string // "query", "crud" or "loop"
+	Key      string
+	Before   string
+	After    string
+
+	query *Query
+	crud  *Crud
+	loop  *Loop
+}
+
+// Diff returns the changes from base to current.
+// A removed query and an added query in the same function with the same kind and tables are reported as a changed query.
+func Diff(base, current *Snapshot) []*Change {
+	base.Sort()
+	current.Sort()
+	changes := make([]*Change, 0)
+
+	// queries
+	added, removed := make([]*Query, 0), make([]*Query, 0)
+	for _, q := range current.Queries {
+		if !slices.ContainsFunc(base.Queries, func(o *Query) bool { return o.Key() == q.Key() }) {
+			added = append(added, q)
+		}
+	}
+	for _, q := range base.Queries {
+		if !slices.ContainsFunc(current.Queries, func(o *Query) bool { return o.Key() == q.Key() }) {
+			removed = append(removed, q)
+		}
+	}
+	for _, q := range added {
+		i := slices.IndexFunc(removed, func(o *Query) bool {
+			return o.PackagePath == q.PackagePath && o.Function == q.Function && o.Kind == q.Kind && slices.Equal(o.Tables, q.Tables)
+		})
+		if i >= 0 {
+			changes = append(changes, &Change{Type: Changed, Category: "query", Key: q.PackagePath + "." + q.Function, Before: removed[i].Raw, After: q.Raw, query: q})
+			removed = slices.Delete(removed, i, i+1)
+			continue
+		}
+		changes = append(changes, &Change{Type: Added, Category: "query", Key: q.Key(), After: q.Raw, query: q})
+	}
+	for _, q := range removed {
+		changes = append(changes, &Change{Type: Removed, Category: "query", Key: q.Key(), Before: q.Raw, query: q})
+	}
+
+	// CRUD cells
+	for _, c := range current.Crud {
+		i := slices.IndexFunc(base.Crud, func(o *Crud) bool { return o.Key() == c.Key() })
+		if i < 0 {
+			changes = append(changes, &Change{Type: Added, Category: "crud", Key: c.Key(), After: c.Operations, crud: c})
+		} else if base.Crud[i].Operations != c.Operations {
+			changes = append(changes, &Change{Type: Changed, Category: "crud", Key: c.Key(), Before: base.Crud[i].Operations, After: c.Operations, crud: c})
+		}
+	}
+	for _, c := range base.Crud {
+		if !slices.ContainsFunc(current.Crud, func(o *Crud) bool { return o.Key() == c.Key() }) {
+			changes = append(changes, &Change{Type: Removed, Category: "crud", Key: c.Key(), Before: c.Operations, crud: c})
+		}
+	}
+
+	// loops
+	for _, l := range current.Loops {
+		i := slices.IndexFunc(base.Loops, func(o *Loop) bool { return o.Key() == l.Key() })
+		if i < 0 {
+			changes = append(changes, &Change{Type: Added, Category: "loop", Key: l.Key(), After: l.String(), loop: l})
+		} else if b := base.Loops[i]; b.Count != l.Count || !slices.Equal(b.Hashes, l.Hashes) {
+			changes = append(changes, &Change{Type: Changed, Category: "loop", Key: l.Key(), Before: b.String(), After: l.String(), loop: l})
+		}
+	}
+	for _, l := range base.Loops {
+		if !slices.ContainsFunc(current.Loops, func(o *Loop) bool { return o.Key() == l.Key() }) {
+			changes = append(changes, &Change{Type: Removed, Category: "loop", Key: l.Key(), Before: l.String(), loop: l})
+		}
+	}
+
+	slices.SortStableFunc(changes, func(a, b *Change) int {
+		return cmp.Or(strings.Compare(a.Category, b.Category), strings.Compare(a.Key, b.Key))
+	})
+	return changes
+}
+
+// Policies of changes which make `scone diff` fail
+const (
+	NewLoop    = "new-loop"    // a function starts to call a query function or a function which executes queries in loops
+	NewUnknown = "new-unknown" // a query which could not be analyzed is added
+	NewWrite   = "new-write"   // a write query is added, or an endpoint starts to write a table
+	AnyChange  = "any"
+)
+
+var Policies = []string{NewLoop, NewUnknown, NewWrite, AnyChange}
+
+// Violates returns true if the change is not allowed by the policy.
+func (c *Change) Violates(policy string) bool {
+	switch policy {
+	case AnyChange:
+		return true
+	case NewLoop:
+		return c.loop != nil && c.Type == Added
+	case NewUnknown:
+		return c.query != nil && c.Type == Added && c.query.Kind == "UNKNOWN"
+	case NewWrite:
+		if c.query != nil {
+			return c.Type == Added && c.query.IsWrite()
+		}
+		return c.crud != nil && c.Type != Removed && strings.ContainsFunc(c.After, func(r rune) bool {
+			return strings.ContainsRune("CUD", r) && !strings.ContainsRune(c.Before, r)
+		})
+	}
+	return false
+}
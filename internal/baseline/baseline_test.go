@@ -0,0 +1,90 @@
+package baseline
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	base := New()
+	base.AddQuery(&Query{PackagePath: "main", Function: "getUser", Hash: "00000001", Kind: "SELECT", Tables: []string{"users"}, Raw: "SELECT * FROM users WHERE id = ?"})
+	base.AddQuery(&Query{PackagePath: "main", Function: "getUser", Hash: "00000002", Kind: "SELECT", Tables: []string{"icons"}, Raw: "SELECT * FROM icons WHERE user_id = ?"})
+	base.Crud = append(base.Crud, &Crud{Method: "GET", Path: "/users/:id", Function: "getUser", Table: "users", Operations: "R"})
+	base.AddLoop(&Loop{PackagePath: "main", Function: "getUsers", Callee: "main.getUser", Count: 1})
+
+	current := New()
+	current.AddQuery(&Query{PackagePath: "main", Function: "getUser", Hash: "00000003", Kind: "SELECT", Tables: []string{"users"}, Raw: "SELECT id, name FROM users WHERE id = ?"})
+	current.AddQuery(&Query{PackagePath: "main", Function: "getUser", Hash: "00000004", Kind: "UPDATE", Tables: []string{"users"}, Raw: "UPDATE users SET visited_at = NOW() WHERE id = ?"})
+	current.AddQuery(&Query{PackagePath: "main", Function: "search", Hash: "00000005", Kind: "UNKNOWN", Raw: "SELECT * FROM users WHERE ?"})
+	current.Crud = append(current.Crud, &Crud{Method: "GET", Path: "/users/:id", Function: "getUser", Table: "users", Operations: "RU"})
+	current.AddLoop(&Loop{PackagePath: "main", Function: "getUsers", Callee: "main.getUser", Count: 1})
+	current.AddLoop(&Loop{PackagePath: "main", Function: "getUsers", Callee: "main.getUser", Count: 1})
+	current.AddLoop(&Loop{PackagePath: "main", Function: "getPosts", Callee: "main.getUser", Count: 1})
+
+	changes := Diff(base, current)
+	require.Len(t, changes, 7)
+
+	type want struct {
+		typ      ChangeType
+		category string
+		key      string
+		before   string
+		after    string
+		policies []string
+	}
+	wants := []want{
+		{Changed, "crud", "GET /users/:id users", "R", "RU", []string{NewWrite}},
+		{Added, "loop", "main.getPosts -> main.getUser", "", "main.getUser (1 calls)", []string{NewLoop}},
+		{Changed, "loop", "main.getUsers -> main.getUser", "main.getUser (1 calls)", "main.getUser (2 calls)", []string{}},
+		{Changed, "query", "main.getUser", "SELECT * FROM users WHERE id = ?", "SELECT id, name FROM users WHERE id = ?", []string{}},
+		{Removed, "query", "main.getUser 00000002", "SELECT * FROM icons WHERE user_id = ?", "", []string{}},
+		{Added, "query", "main.getUser 00000004", "", "UPDATE users SET visited_at = NOW() WHERE id = ?", []string{NewWrite}},
+		{Added, "query", "main.search 00000005", "", "SELECT * FROM users WHERE ?", []string{NewUnknown}},
+	}
+	for i, w := range wants {
+		c := changes[i]
+		assert.Equal(t, w.typ, c.Type, w.key)
+		assert.Equal(t, w.category, c.Category, w.key)
+		assert.Equal(t, w.key, c.Key)
+		assert.Equal(t, w.before, c.Before, w.key)
+		assert.Equal(t, w.after, c.After, w.key)
+		for _, p := range []string{NewLoop, NewUnknown, NewWrite} {
+			assert.Equal(t, slices.Contains(w.policies, p), c.Violates(p), "%s %s", w.key, p)
+		}
+		assert.True(t, c.Violates(AnyChange))
+	}
+}
+
+func TestSnapshot_AddQuery(t *testing.T) {
+	s := New()
+	s.AddQuery(&Query{PackagePath: "main", Function: "getUser", Hash: "00000001", Kind: "SELECT", Tables: []string{"users"}, Raw: "SELECT * FROM users WHERE id = ?"})
+	s.AddQuery(&Query{PackagePath: "main", Function: "getUser", Hash: "00000001", Kind: "SELECT", Tables: []string{"users"}, Raw: "SELECT * FROM users WHERE id = ?"})
+	s.AddQuery(&Query{PackagePath: "main", Function: "getUser", Hash: "da39a3ee", Kind: "UNKNOWN"})
+	s.AddQuery(&Query{PackagePath: "main", Function: "getUser", Hash: "da39a3ee", Kind: "UNKNOWN"})
+	s.AddQuery(&Query{PackagePath: "main", Function: "getPosts", Hash: "da39a3ee", Kind: "UNKNOWN"})
+
+	keys := make([]string, 0, len(s.Queries))
+	for _, q := range s.Queries {
+		keys = append(keys, q.Key())
+	}
+	assert.Equal(t, []string{"main.getUser 00000001", "main.getUser da39a3ee", "main.getUser da39a3ee#1", "main.getPosts da39a3ee"}, keys)
+}
+
+func TestReadWrite(t *testing.T) {
+	s := New()
+	s.AddQuery(&Query{PackagePath: "main", Function: "getUser", Hash: "00000001", Kind: "SELECT", Tables: []string{"users"}, Raw: "SELECT * FROM users WHERE id = ?"})
+	s.AddLoop(&Loop{PackagePath: "main", Function: "getUsers", Callee: "main.getUser", Count: 1, Hashes: []string{"00000001"}})
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, s.Write(buf))
+	got, err := Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, s, got)
+
+	_, err = Read(bytes.NewBufferString(`{"version": 1}`))
+	assert.EqualError(t, err, "unsupported baseline version: 1")
+}
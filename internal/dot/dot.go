@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"slices"
 	"strings"
 	"text/template"
 )
@@ -55,6 +56,7 @@ func (p Attrs) List() []string {
 	for k, v := range p {
 		l = append(l, fmt.Sprintf("%s=%q", k, v))
 	}
+	slices.Sort(l)
 	return l
 }
 
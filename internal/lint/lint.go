@@ -0,0 +1,135 @@
+package lint
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/haijima/analysisutil/ssautil"
+	"github.com/haijima/scone/internal/analysis"
+	"github.com/haijima/scone/internal/sql"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+)
+
+type Severity int
+
+const (
+	Info Severity = iota
+	Warning
+	Error
+)
+
+func ParseSeverity(s string) (Severity, error) {
+	switch strings.ToLower(s) {
+	case "info":
+		return Info, nil
+	case "warning", "warn":
+		return Warning, nil
+	case "error":
+		return Error, nil
+	}
+	return Info, errors.Newf("unknown severity: %s", s)
+}
+
+func (s Severity) String() string {
+	switch s {
+	case Warning:
+		return "warning"
+	case Error:
+		return "error"
+	default:
+		return "info"
+	}
+}
+
+// Rule is a named check over the queries and the call graphs.
+type Rule struct {
+	Name     string
+	Doc      string
+	Severity Severity // default severity
+	Run      func(pass *Pass)
+}
+
+// RuleConfig is the configuration of a rule in the config file.
+type RuleConfig struct {
+	Severity string `mapstructure:"severity"`
+	Enabled  *bool  `mapstructure:"enabled"`
+}
+
+// Query is a query with its syntax tree.
+type Query struct {
+	*sql.Query
+	Stmt ast.StmtNode
+	Posx *ssautil.Posx
+}
+
+// Pass provides the analyzed queries to a rule and collects its diagnostics.
+type Pass struct {
+	Queries     []*Query
+	CallGraph   *analysis.CallGraph
+	rule        *Rule
+	severity    Severity
+	diagnostics []*Diagnostic
+}
+
+// Report reports a diagnostic at the position. q is nil if the diagnostic is not about a specific query.
+func (p *Pass) Report(posx *ssautil.Posx, q *sql.Query, format string, args ...any) {
+	p.diagnostics = append(p.diagnostics, &Diagnostic{Rule: p.rule, Severity: p.severity, Message: fmt.Sprintf(format, args...), Query: q, Posx: posx})
+}
+
+// Diagnostic is a problem found by a rule.
+type Diagnostic struct {
+	Rule     *Rule
+	Severity Severity
+	Message  string
+	Query    *sql.Query
+	Posx     *ssautil.Posx
+}
+
+// Run runs the enabled rules and returns the diagnostics in order of position.
+// Diagnostics in the nodes commented by `scone:ignore <rule>` are suppressed.
+func Run(rules []*Rule, result *analysis.Result, opt *analysis.Option, config map[string]RuleConfig) ([]*Diagnostic, error) {
+	parser := &sql.Parser{Dialect: opt.Dialect, Schema: opt.Schema}
+	queries := make([]*Query, 0)
+	for _, qr := range result.QueryResults {
+		for _, q := range qr.Queries() {
+			stmt, ok := parser.ParseStmt(q.Raw)
+			if !ok {
+				continue
+			}
+			queries = append(queries, &Query{Query: q, Stmt: stmt, Posx: qr.Posx})
+		}
+	}
+
+	for name := range config {
+		if !slices.ContainsFunc(rules, func(r *Rule) bool { return r.Name == name }) {
+			return nil, errors.Newf("unknown rule: %s", name)
+		}
+	}
+
+	res := make([]*Diagnostic, 0)
+	for _, rule := range rules {
+		pass := &Pass{Queries: queries, CallGraph: result.CallGraph, rule: rule, severity: rule.Severity}
+		if c, ok := config[rule.Name]; ok {
+			if c.Enabled != nil && !*c.Enabled {
+				continue
+			}
+			if c.Severity != "" {
+				severity, err := ParseSeverity(c.Severity)
+				if err != nil {
+					return nil, errors.Wrapf(err, "invalid config of rule %s", rule.Name)
+				}
+				pass.severity = severity
+			}
+		}
+		rule.Run(pass)
+		for _, d := range pass.diagnostics {
+			if !result.IsIgnored(rule.Name, d.Posx) {
+				res = append(res, d)
+			}
+		}
+	}
+	slices.SortStableFunc(res, func(a, b *Diagnostic) int { return a.Posx.Compare(b.Posx) })
+	return res, nil
+}
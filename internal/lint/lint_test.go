@@ -0,0 +1,93 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/haijima/analysisutil/ssautil"
+	"github.com/haijima/scone/internal/analysis"
+	"github.com/haijima/scone/internal/sql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/go/ssa"
+)
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		rule *Rule
+		sql  string
+		want bool
+	}{
+		{MissingWhere, "UPDATE users SET name = ?", true},
+		{MissingWhere, "UPDATE users SET name = ? WHERE id = ?", false},
+		{MissingWhere, "DELETE FROM users", true},
+		{MissingWhere, "DELETE FROM users WHERE id = ?", false},
+		{MissingWhere, "SELECT * FROM users", false},
+		{SelectStar, "SELECT * FROM users", true},
+		{SelectStar, "SELECT u.* FROM users u JOIN posts p ON u.id = p.user_id", true},
+		{SelectStar, "SELECT id FROM users WHERE id IN (SELECT * FROM admins)", true},
+		{SelectStar, "SELECT id FROM users WHERE EXISTS (SELECT * FROM posts WHERE posts.user_id = users.id)", false},
+		{SelectStar, "SELECT COUNT(*) FROM users", false},
+		{LeadingWildcardLike, "SELECT id FROM users WHERE name LIKE '%foo'", true},
+		{LeadingWildcardLike, "SELECT id FROM users WHERE name LIKE '_oo'", true},
+		{LeadingWildcardLike, "SELECT id FROM users WHERE name LIKE CONCAT('%', ?, '%')", true},
+		{LeadingWildcardLike, "SELECT id FROM users WHERE name LIKE 'foo%'", false},
+		{LeadingWildcardLike, "SELECT id FROM users WHERE name LIKE ?", false},
+		{LeadingWildcardLike, "SELECT id FROM users WHERE name NOT LIKE '%foo'", false},
+		{OrderByRand, "SELECT id FROM users ORDER BY RAND() LIMIT 1", true},
+		{OrderByRand, "SELECT id FROM users ORDER BY id", false},
+		{OffsetPagination, "SELECT id FROM users ORDER BY id LIMIT ? OFFSET ?", true},
+		{OffsetPagination, "SELECT id FROM users ORDER BY id LIMIT 20, 10", true},
+		{OffsetPagination, "SELECT id FROM users ORDER BY id LIMIT 10 OFFSET 0", false},
+		{OffsetPagination, "SELECT id FROM users ORDER BY id LIMIT 10", false},
+	}
+	p := sql.NewParser(sql.MySQL)
+	for _, tt := range tests {
+		t.Run(tt.rule.Name+"/"+tt.sql, func(t *testing.T) {
+			q, ok := p.ParseString(tt.sql)
+			require.True(t, ok)
+			stmt, ok := p.ParseStmt(tt.sql)
+			require.True(t, ok)
+			pass := &Pass{Queries: []*Query{{Query: q, Stmt: stmt}}, rule: tt.rule, severity: tt.rule.Severity}
+			tt.rule.Run(pass)
+			assert.Equal(t, tt.want, len(pass.diagnostics) > 0)
+		})
+	}
+}
+
+func TestRun_config(t *testing.T) {
+	qr := analysis.NewQueryResult(ssautil.NewPos(&ssa.Function{}))
+	q, ok := sql.NewParser(sql.MySQL).ParseString("DELETE FROM users")
+	require.True(t, ok)
+	qr.Append(q)
+	result := &analysis.Result{QueryResults: analysis.QueryResults{qr}}
+	opt := analysis.NewOption("", nil)
+	disabled := false
+
+	tests := []struct {
+		name         string
+		config       map[string]RuleConfig
+		wantSeverity []Severity
+		wantErr      string
+	}{
+		{"default", nil, []Severity{Error}, ""},
+		{"severity", map[string]RuleConfig{"missing-where": {Severity: "warning"}}, []Severity{Warning}, ""},
+		{"disabled", map[string]RuleConfig{"missing-where": {Enabled: &disabled}}, []Severity{}, ""},
+		{"unknown rule", map[string]RuleConfig{"no-such-rule": {}}, nil, "unknown rule: no-such-rule"},
+		{"unknown severity", map[string]RuleConfig{"missing-where": {Severity: "fatal"}}, nil, "invalid config of rule missing-where: unknown severity: fatal"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Run(DefaultRules, result, opt, tt.config)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			severities := make([]Severity, 0)
+			for _, d := range got {
+				severities = append(severities, d.Severity)
+			}
+			assert.Equal(t, tt.wantSeverity, severities)
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package lint
+
+import (
+	"go/ast"
+	"go/token"
+
+	"github.com/haijima/scone/internal/analysis"
+	"golang.org/x/tools/go/ssa"
+)
+
+// LoopedCall is a call in for loops which executes queries directly or through the callee.
+type LoopedCall struct {
+	Func   *ssa.Function
+	Callee *ssa.Function
+	Call   *ssa.Call
+	Depth  int // the number of the for loops which enclose the call
+}
+
+// FindLoopedCalls returns the calls in for loops whose callee executes queries in the call graph.
+// The calls which execute queries by themselves are also returned if isQuery reports true. isQuery may be nil.
+func FindLoopedCalls(fns []*ssa.Function, cg *analysis.CallGraph, isQuery func(call *ssa.CallCommon) bool) []*LoopedCall {
+	res := make([]*LoopedCall, 0)
+	for _, fn := range fns {
+		for _, block := range fn.Blocks {
+			for _, instr := range block.Instrs {
+				call, ok := instr.(*ssa.Call)
+				if !ok {
+					continue
+				}
+				callee := call.Call.StaticCallee()
+				if callee == nil || callee.Pkg == nil {
+					continue
+				}
+				depth := LoopDepth(fn, call.Pos())
+				if depth == 0 {
+					continue
+				}
+				if _, ok := cg.Lookup(callee); ok || (isQuery != nil && isQuery(&call.Call)) {
+					res = append(res, &LoopedCall{Func: fn, Callee: callee, Call: call, Depth: depth})
+				}
+			}
+		}
+	}
+	return res
+}
+
+// InLoop returns true if the position is in the body of a for statement in the function.
+func InLoop(fn *ssa.Function, pos token.Pos) bool {
+	return LoopDepth(fn, pos) > 0
+}
+
+// LoopDepth returns the number of the for statements whose body contains the position in the function.
+// The for statements which enclose the closure are also counted.
+func LoopDepth(fn *ssa.Function, pos token.Pos) int {
+	if fn == nil || !pos.IsValid() {
+		return 0
+	}
+	for fn.Parent() != nil {
+		fn = fn.Parent()
+	}
+	if fn.Syntax() == nil {
+		return 0
+	}
+	depth := 0
+	ast.Inspect(fn.Syntax(), func(n ast.Node) bool {
+		if n == nil || pos < n.Pos() || n.End() <= pos {
+			return false
+		}
+		switch t := n.(type) {
+		case *ast.ForStmt:
+			if t.Body.Pos() <= pos {
+				depth++
+			}
+		case *ast.RangeStmt:
+			if t.Body.Pos() <= pos {
+				depth++
+			}
+		}
+		return true
+	})
+	return depth
+}
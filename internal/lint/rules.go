@@ -0,0 +1,217 @@
+package lint
+
+import (
+	"go/token"
+	"slices"
+	"strings"
+
+	"github.com/haijima/analysisutil/ssautil"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"golang.org/x/tools/go/ssa"
+)
+
+// DefaultRules is the rules which `scone lint` runs.
+var DefaultRules = []*Rule{
+	MissingWhere,
+	SelectStar,
+	LeadingWildcardLike,
+	OrderByRand,
+	OffsetPagination,
+	QueryInLoop,
+	SchemaMismatch,
+}
+
+var MissingWhere = &Rule{
+	Name:     "missing-where",
+	Doc:      "UPDATE or DELETE without WHERE clause modifies all the rows of the table",
+	Severity: Error,
+	Run: func(pass *Pass) {
+		for _, q := range pass.Queries {
+			switch s := q.Stmt.(type) {
+			case *ast.UpdateStmt:
+				if s.Where == nil {
+					pass.Report(q.Posx, q.Query, "UPDATE without WHERE clause modifies all the rows of %s", q.MainTable)
+				}
+			case *ast.DeleteStmt:
+				if s.Where == nil {
+					pass.Report(q.Posx, q.Query, "DELETE without WHERE clause deletes all the rows of %s", q.MainTable)
+				}
+			}
+		}
+	},
+}
+
+var SelectStar = &Rule{
+	Name:     "select-star",
+	Doc:      "SELECT * reads unnecessary columns and breaks when columns are added. Wildcards in EXISTS sub-queries are allowed",
+	Severity: Warning,
+	Run: func(pass *Pass) {
+		for _, q := range pass.Queries {
+			found := false
+			inspect(q.Stmt, func(n ast.Node) bool {
+				switch t := n.(type) {
+				case *ast.ExistsSubqueryExpr:
+					return false
+				case *ast.SelectField:
+					found = found || t.WildCard != nil
+				}
+				return !found
+			})
+			if found {
+				pass.Report(q.Posx, q.Query, "SELECT * should be replaced with the column names")
+			}
+		}
+	},
+}
+
+var LeadingWildcardLike = &Rule{
+	Name:     "leading-wildcard-like",
+	Doc:      "LIKE pattern starting with a wildcard can not use indexes",
+	Severity: Warning,
+	Run: func(pass *Pass) {
+		for _, q := range pass.Queries {
+			found := false
+			inspect(q.Stmt, func(n ast.Node) bool {
+				if like, ok := n.(*ast.PatternLikeOrIlikeExpr); ok && !like.Not && isLeadingWildcard(like.Pattern) {
+					found = true
+				}
+				return !found
+			})
+			if found {
+				pass.Report(q.Posx, q.Query, "LIKE pattern starting with a wildcard causes a full scan")
+			}
+		}
+	},
+}
+
+// isLeadingWildcard returns true if the pattern is a string literal which starts with "%" or "_", or CONCAT of such a literal and others.
+func isLeadingWildcard(pattern ast.ExprNode) bool {
+	switch p := pattern.(type) {
+	case ast.ValueExpr:
+		s, ok := p.GetValue().(string)
+		return ok && (strings.HasPrefix(s, "%") || strings.HasPrefix(s, "_"))
+	case *ast.FuncCallExpr:
+		return p.FnName.L == "concat" && len(p.Args) > 0 && isLeadingWildcard(p.Args[0])
+	}
+	return false
+}
+
+var OrderByRand = &Rule{
+	Name:     "order-by-rand",
+	Doc:      "ORDER BY RAND() sorts all the rows of the result",
+	Severity: Warning,
+	Run: func(pass *Pass) {
+		for _, q := range pass.Queries {
+			found := false
+			inspect(q.Stmt, func(n ast.Node) bool {
+				if item, ok := n.(*ast.ByItem); ok {
+					if fn, ok := item.Expr.(*ast.FuncCallExpr); ok && fn.FnName.L == "rand" {
+						found = true
+					}
+				}
+				return !found
+			})
+			if found {
+				pass.Report(q.Posx, q.Query, "ORDER BY RAND() sorts all the rows of the result")
+			}
+		}
+	},
+}
+
+var OffsetPagination = &Rule{
+	Name:     "offset-pagination",
+	Doc:      "LIMIT with OFFSET reads and discards all the skipped rows. Keyset pagination (WHERE id < ?) is faster for deep pages",
+	Severity: Info,
+	Run: func(pass *Pass) {
+		for _, q := range pass.Queries {
+			found := false
+			inspect(q.Stmt, func(n ast.Node) bool {
+				if limit, ok := n.(*ast.Limit); ok && limit.Offset != nil && !isZero(limit.Offset) {
+					found = true
+				}
+				return !found
+			})
+			if found {
+				pass.Report(q.Posx, q.Query, "OFFSET pagination reads all the skipped rows")
+			}
+		}
+	},
+}
+
+func isZero(expr ast.ExprNode) bool {
+	v, ok := expr.(ast.ValueExpr)
+	if !ok {
+		return false
+	}
+	switch n := v.GetValue().(type) {
+	case int64:
+		return n == 0
+	case uint64:
+		return n == 0
+	}
+	return false
+}
+
+var QueryInLoop = &Rule{
+	Name:     "query-in-loop",
+	Doc:      "Queries executed in for loops, directly or through function calls, cause N+1 problems",
+	Severity: Warning,
+	Run: func(pass *Pass) {
+		reported := make(map[token.Pos]bool)
+		for _, q := range pass.Queries {
+			i := slices.IndexFunc(q.Posx.Pos, token.Pos.IsValid)
+			if i < 0 || reported[q.Posx.Pos[i]] || !InLoop(q.Posx.Func, q.Posx.Pos[i]) {
+				continue
+			}
+			reported[q.Posx.Pos[i]] = true
+			pass.Report(q.Posx, q.Query, "%s query is executed in a loop", q.Kind)
+		}
+
+		// calls of the functions which execute queries
+		if pass.CallGraph == nil {
+			return
+		}
+		fns := make([]*ssa.Function, 0, len(pass.CallGraph.Nodes))
+		for _, node := range pass.CallGraph.Nodes {
+			if node.IsFunc() {
+				fns = append(fns, node.Func)
+			}
+		}
+		for _, c := range FindLoopedCalls(fns, pass.CallGraph, nil) {
+			if !reported[c.Call.Pos()] {
+				reported[c.Call.Pos()] = true
+				pass.Report(ssautil.NewPos(c.Func, c.Call.Pos()), nil, "%s which executes queries is called in a loop", c.Callee.Name())
+			}
+		}
+	},
+}
+
+var SchemaMismatch = &Rule{
+	Name:     "schema-mismatch",
+	Doc:      "Queries refer to the tables or columns which are not defined in the schema file given by --schema",
+	Severity: Error,
+	Run: func(pass *Pass) {
+		for _, q := range pass.Queries {
+			if len(q.SchemaErrors) > 0 {
+				pass.Report(q.Posx, q.Query, "%s", strings.Join(q.SchemaErrors, ", "))
+			}
+		}
+	},
+}
+
+// inspect traverses the syntax tree in depth-first order. Children of the node are skipped if fn returns false.
+func inspect(node ast.Node, fn func(ast.Node) bool) {
+	node.Accept(&inspector{fn: fn})
+}
+
+type inspector struct {
+	fn func(ast.Node) bool
+}
+
+func (v *inspector) Enter(in ast.Node) (ast.Node, bool) {
+	return in, !v.fn(in)
+}
+
+func (v *inspector) Leave(in ast.Node) (ast.Node, bool) {
+	return in, true
+}
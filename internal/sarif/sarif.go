@@ -0,0 +1,139 @@
+package sarif
+
+import (
+	"encoding/json"
+	"io"
+	"slices"
+)
+
+const (
+	Version = "2.1.0"
+	Schema  = "https://json.schemastore.org/sarif-2.1.0.json"
+
+	// FingerprintKey is the key of partialFingerprints which identifies the same finding across runs.
+	FingerprintKey = "scone/v2"
+
+	// SrcRoot is the uriBaseId of the artifacts. The code scanning tools resolve it to the root of the repository.
+	SrcRoot = "%SRCROOT%"
+)
+
+// Levels of results
+const (
+	LevelError   = "error"
+	LevelWarning = "warning"
+	LevelNote    = "note"
+)
+
+// Log is the root of SARIF 2.1.0 document, which is read by code scanning tools such as GitHub code scanning.
+type Log struct {
+	Version string `json:"version"`
+	Schema  string `json:"$schema"`
+	Runs    []*Run `json:"runs"`
+}
+
+type Run struct {
+	Tool    Tool      `json:"tool"`
+	Results []*Result `json:"results"`
+}
+
+type Tool struct {
+	Driver Driver `json:"driver"`
+}
+
+type Driver struct {
+	Name           string                 `json:"name"`
+	Version        string                 `json:"version,omitempty"`
+	InformationURI string                 `json:"informationUri,omitempty"`
+	Rules          []*ReportingDescriptor `json:"rules"`
+}
+
+type ReportingDescriptor struct {
+	ID                   string         `json:"id"`
+	ShortDescription     *Message       `json:"shortDescription,omitempty"`
+	DefaultConfiguration *Configuration `json:"defaultConfiguration,omitempty"`
+}
+
+type Configuration struct {
+	Level string `json:"level"`
+}
+
+type Message struct {
+	Text string `json:"text"`
+}
+
+type Result struct {
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           int               `json:"ruleIndex"`
+	Level               string            `json:"level"`
+	Message             Message           `json:"message"`
+	Locations           []*Location       `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints,omitempty"`
+}
+
+type Location struct {
+	PhysicalLocation PhysicalLocation `json:"physicalLocation"`
+}
+
+type PhysicalLocation struct {
+	ArtifactLocation ArtifactLocation `json:"artifactLocation"`
+	Region           *Region          `json:"region,omitempty"`
+}
+
+type ArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type Region struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// NewLog returns a log which has a run of the tool.
+func NewLog(name, version, informationURI string) *Log {
+	return &Log{
+		Version: Version,
+		Schema:  Schema,
+		Runs:    []*Run{{Tool: Tool{Driver: Driver{Name: name, Version: version, InformationURI: informationURI, Rules: make([]*ReportingDescriptor, 0)}}, Results: make([]*Result, 0)}},
+	}
+}
+
+// AddRule adds the rule if it is not added yet.
+func (l *Log) AddRule(id, description, level string) {
+	driver := &l.Runs[0].Tool.Driver
+	if slices.ContainsFunc(driver.Rules, func(r *ReportingDescriptor) bool { return r.ID == id }) {
+		return
+	}
+	driver.Rules = append(driver.Rules, &ReportingDescriptor{ID: id, ShortDescription: &Message{Text: description}, DefaultConfiguration: &Configuration{Level: level}})
+}
+
+// AddResult adds the result of the rule. uri is the path of the file relative to SrcRoot, or empty if the location is unknown.
+// fingerprint should not depend on the line number so that the result is tracked when the code is moved.
+func (l *Log) AddResult(ruleID, level, message, uri string, line, column int, fingerprint string) {
+	run := l.Runs[0]
+	r := &Result{
+		RuleID:    ruleID,
+		RuleIndex: slices.IndexFunc(run.Tool.Driver.Rules, func(r *ReportingDescriptor) bool { return r.ID == ruleID }),
+		Level:     level,
+		Message:   Message{Text: message},
+		Locations: make([]*Location, 0, 1),
+	}
+	if uri != "" {
+		loc := &Location{PhysicalLocation: PhysicalLocation{ArtifactLocation: ArtifactLocation{URI: uri, URIBaseID: SrcRoot}}}
+		if line > 0 {
+			loc.PhysicalLocation.Region = &Region{StartLine: line, StartColumn: column}
+		}
+		r.Locations = append(r.Locations, loc)
+	}
+	if fingerprint != "" {
+		r.PartialFingerprints = map[string]string{FingerprintKey: fingerprint}
+	}
+	run.Results = append(run.Results, r)
+}
+
+// Write writes the log in JSON format.
+func (l *Log) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(l)
+}
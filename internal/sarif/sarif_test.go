@@ -0,0 +1,37 @@
+package sarif
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLog_Write(t *testing.T) {
+	l := NewLog("scone", "", "https://github.com/haijima/scone")
+	l.AddRule("missing-where", "UPDATE or DELETE without WHERE clause", LevelError)
+	l.AddRule("select-star", "SELECT *", LevelWarning)
+	l.AddRule("missing-where", "duplicated", LevelNote)
+	l.AddResult("select-star", LevelWarning, "SELECT * should be replaced", "main.go", 10, 5, "0123456789abcdef")
+	l.AddResult("missing-where", LevelError, "DELETE without WHERE clause", "", 0, 0, "")
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, l.Write(buf))
+	assert.JSONEq(t, `{
+  "version": "2.1.0",
+  "$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+  "runs": [{
+    "tool": {"driver": {"name": "scone", "informationUri": "https://github.com/haijima/scone", "rules": [
+      {"id": "missing-where", "shortDescription": {"text": "UPDATE or DELETE without WHERE clause"}, "defaultConfiguration": {"level": "error"}},
+      {"id": "select-star", "shortDescription": {"text": "SELECT *"}, "defaultConfiguration": {"level": "warning"}}
+    ]}},
+    "results": [
+      {"ruleId": "select-star", "ruleIndex": 1, "level": "warning", "message": {"text": "SELECT * should be replaced"},
+       "locations": [{"physicalLocation": {"artifactLocation": {"uri": "main.go", "uriBaseId": "%SRCROOT%"}, "region": {"startLine": 10, "startColumn": 5}}}],
+       "partialFingerprints": {"scone/v2": "0123456789abcdef"}},
+      {"ruleId": "missing-where", "ruleIndex": 0, "level": "error", "message": {"text": "DELETE without WHERE clause"}, "locations": []}
+    ]
+  }]
+}`, buf.String())
+}
@@ -0,0 +1,250 @@
+package sql
+
+import (
+	"slices"
+
+	"github.com/pingcap/tidb/pkg/parser/ast"
+)
+
+// columnResolver resolves column names to "table.column" by the tables in FROM clause.
+type columnResolver struct {
+	tableAliases map[string]string   // key: alias, value: original table name
+	fieldAliases map[string][]string // key: alias of select field, value: resolved columns of the field
+	single       string              // the table name if only one table is referred
+	tables       []string            // the table names in order of appearance, or nil if derived tables are referred
+	schema       *Schema
+}
+
+func newColumnResolver(tableRefs *ast.TableRefsClause, schema *Schema) *columnResolver {
+	r := &columnResolver{tableAliases: make(map[string]string), fieldAliases: make(map[string][]string), schema: schema}
+	if tableRefs == nil || tableRefs.TableRefs == nil {
+		return r
+	}
+	jf := &JoinFlatter{}
+	tableRefs.Accept(jf)
+	for _, t := range jf.tableNames {
+		name := t.Source.(*ast.TableName).Name.L
+		asName := t.AsName.L
+		if asName == "" {
+			asName = name
+		}
+		r.tableAliases[asName] = name
+		r.tables = appendUnique(r.tables, name)
+	}
+	if len(jf.selectStmts) > 0 || len(jf.setOprStmts) > 0 {
+		r.tables = nil
+	}
+	if len(jf.tableNames) == 1 && len(jf.selectStmts) == 0 && len(jf.setOprStmts) == 0 {
+		r.single = jf.tableNames[0].Source.(*ast.TableName).Name.L
+	}
+	return r
+}
+
+// resolve returns the column name qualified by the original table name if possible.
+func (r *columnResolver) resolve(col *ast.ColumnName) string {
+	if col.Table.L == "" {
+		if r.single != "" {
+			return r.single + "." + col.Name.L
+		}
+		if alias, ok := r.schema.ownerAlias(col.Name.L, r.tableAliases); ok {
+			return r.tableAliases[alias] + "." + col.Name.L
+		}
+		return col.Name.L // ambiguous column
+	}
+	if name, ok := r.tableAliases[col.Table.L]; ok {
+		return name + "." + col.Name.L
+	}
+	return col.Table.L + "." + col.Name.L // e.g. alias of derived table
+}
+
+// columns returns the resolved columns which appear in the nodes except for sub-queries.
+func (r *columnResolver) columns(nodes ...ast.Node) []string {
+	v := &columnNameX{}
+	for _, n := range nodes {
+		if n != nil {
+			n.Accept(v)
+		}
+	}
+	res := make([]string, 0, len(v.colNames))
+	for _, col := range v.colNames {
+		res = appendUnique(res, r.resolve(col))
+	}
+	return res
+}
+
+// byItems returns the resolved columns of ORDER BY or GROUP BY clause. The aliases of select fields are resolved to their columns.
+func (r *columnResolver) byItems(items []*ast.ByItem) []string {
+	res := make([]string, 0, len(items))
+	for _, item := range items {
+		if c, ok := item.Expr.(*ast.ColumnNameExpr); ok && c.Name.Table.L == "" {
+			if cols, ok := r.fieldAliases[c.Name.Name.L]; ok {
+				res = appendUnique(res, cols...)
+				continue
+			}
+		}
+		res = appendUnique(res, r.columns(item.Expr)...)
+	}
+	return res
+}
+
+// selectFields returns the resolved columns of select fields. Wildcards are returned as "table.*" or "*".
+func (r *columnResolver) selectFields(fields *ast.FieldList) []string {
+	res := make([]string, 0)
+	if fields == nil {
+		return res
+	}
+	for _, f := range fields.Fields {
+		if f.WildCard != nil {
+			if f.WildCard.Table.L == "" && r.tables != nil {
+				for _, t := range r.tables {
+					res = appendUnique(res, r.wildcard(t)...)
+				}
+			} else if f.WildCard.Table.L == "" {
+				res = appendUnique(res, "*")
+			} else if name, ok := r.tableAliases[f.WildCard.Table.L]; ok {
+				res = appendUnique(res, r.wildcard(name)...)
+			} else {
+				res = appendUnique(res, f.WildCard.Table.L+".*")
+			}
+			continue
+		}
+		cols := r.columns(f.Expr)
+		if f.AsName.L != "" {
+			r.fieldAliases[f.AsName.L] = cols
+		}
+		res = appendUnique(res, cols...)
+	}
+	return res
+}
+
+// wildcard returns all the columns of the table if the schema is given, otherwise "table.*".
+func (r *columnResolver) wildcard(table string) []string {
+	cols, ok := r.schema.Columns(table)
+	if !ok {
+		return []string{table + ".*"}
+	}
+	res := make([]string, 0, len(cols))
+	for _, c := range cols {
+		res = append(res, table+"."+c)
+	}
+	return res
+}
+
+// joinColumns returns the resolved columns compared by equality in ON clauses of joins.
+func (r *columnResolver) joinColumns(tableRefs *ast.TableRefsClause) []string {
+	if tableRefs == nil || tableRefs.TableRefs == nil {
+		return nil
+	}
+	var res []string
+	var walk func(node ast.ResultSetNode)
+	walk = func(node ast.ResultSetNode) {
+		join, ok := node.(*ast.Join)
+		if !ok {
+			return
+		}
+		walk(join.Left)
+		walk(join.Right)
+		if join.On != nil {
+			v := &colX{}
+			join.On.Expr.Accept(v)
+			for _, col := range v.colNames {
+				res = appendUnique(res, r.resolve(col))
+			}
+		}
+	}
+	walk(tableRefs.TableRefs)
+	return res
+}
+
+func (r *columnResolver) assignments(list []*ast.Assignment) []string {
+	res := make([]string, 0, len(list))
+	for _, a := range list {
+		res = appendUnique(res, r.resolve(a.Column))
+	}
+	return res
+}
+
+type columnNameX struct {
+	colNames []*ast.ColumnName
+}
+
+func (v *columnNameX) Enter(in ast.Node) (ast.Node, bool) {
+	switch t := in.(type) {
+	case *ast.SubqueryExpr:
+		return in, true
+	case *ast.ColumnNameExpr:
+		v.colNames = append(v.colNames, t.Name)
+	}
+	return in, false
+}
+
+func (v *columnNameX) Leave(in ast.Node) (ast.Node, bool) {
+	return in, true
+}
+
+func parseSelectColumns(q *Query, s *ast.SelectStmt, schema *Schema) {
+	r := newColumnResolver(s.From, schema)
+	q.SelectColumns = appendUnique(q.SelectColumns, r.selectFields(s.Fields)...)
+	q.JoinColumns = appendUnique(q.JoinColumns, r.joinColumns(s.From)...)
+	if s.GroupBy != nil {
+		q.GroupByColumns = appendUnique(q.GroupByColumns, r.byItems(s.GroupBy.Items)...)
+	}
+	if s.OrderBy != nil {
+		q.OrderByColumns = appendUnique(q.OrderByColumns, r.byItems(s.OrderBy.Items)...)
+	}
+}
+
+func parseSetOprColumns(q *Query, s *ast.SetOprSelectList, schema *Schema) {
+	for _, sel := range s.Selects {
+		switch t := sel.(type) {
+		case *ast.SelectStmt:
+			parseSelectColumns(q, t, schema)
+		case *ast.SetOprSelectList:
+			parseSetOprColumns(q, t, schema)
+		}
+	}
+}
+
+func parseInsertColumns(q *Query, s *ast.InsertStmt, schema *Schema) {
+	r := newColumnResolver(s.Table, schema)
+	if len(s.Columns) > 0 {
+		for _, col := range s.Columns {
+			q.WriteColumns = appendUnique(q.WriteColumns, r.resolve(col))
+		}
+	} else if r.single != "" {
+		q.WriteColumns = appendUnique(q.WriteColumns, r.wildcard(r.single)...)
+	}
+	q.WriteColumns = appendUnique(q.WriteColumns, r.assignments(s.OnDuplicate)...)
+	if sel, ok := s.Select.(*ast.SelectStmt); ok {
+		parseSelectColumns(q, sel, schema)
+	}
+}
+
+func parseUpdateColumns(q *Query, s *ast.UpdateStmt, schema *Schema) {
+	r := newColumnResolver(s.TableRefs, schema)
+	q.WriteColumns = appendUnique(q.WriteColumns, r.assignments(s.List)...)
+	q.JoinColumns = appendUnique(q.JoinColumns, r.joinColumns(s.TableRefs)...)
+	if s.Order != nil {
+		q.OrderByColumns = appendUnique(q.OrderByColumns, r.byItems(s.Order.Items)...)
+	}
+}
+
+func parseDeleteColumns(q *Query, s *ast.DeleteStmt, schema *Schema) {
+	r := newColumnResolver(s.TableRefs, schema)
+	if r.single != "" {
+		q.WriteColumns = appendUnique(q.WriteColumns, r.wildcard(r.single)...) // all columns of the deleted rows
+	}
+	q.JoinColumns = appendUnique(q.JoinColumns, r.joinColumns(s.TableRefs)...)
+	if s.Order != nil {
+		q.OrderByColumns = appendUnique(q.OrderByColumns, r.byItems(s.Order.Items)...)
+	}
+}
+
+func appendUnique(s []string, elems ...string) []string {
+	for _, e := range elems {
+		if !slices.Contains(s, e) {
+			s = append(s, e)
+		}
+	}
+	return s
+}
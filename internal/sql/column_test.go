@@ -0,0 +1,66 @@
+package sql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parse_columns(t *testing.T) {
+	tests := []struct {
+		name    string
+		sql     string
+		selects []string
+		writes  []string
+		orderBy []string
+		groupBy []string
+	}{
+		{"wildcard", "SELECT * FROM t1 WHERE id = ?", []string{"t1.*"}, nil, nil, nil},
+		{"columns", "SELECT id, name FROM t1", []string{"t1.id", "t1.name"}, nil, nil, nil},
+		{"table alias", "SELECT a.id, b.* FROM t1 AS a JOIN t2 b ON a.id = b.t1_id", []string{"t1.id", "t2.*"}, nil, nil, nil},
+		{"ambiguous column", "SELECT name FROM t1 JOIN t2 ON t1.id = t2.t1_id", []string{"name"}, nil, nil, nil},
+		{"function", "SELECT COUNT(id) AS cnt, MAX(score) FROM t1", []string{"t1.id", "t1.score"}, nil, nil, nil},
+		{"order by", "SELECT * FROM t1 ORDER BY created_at DESC, id", []string{"t1.*"}, nil, []string{"t1.created_at", "t1.id"}, nil},
+		{"group by with alias", "SELECT user_id AS u, COUNT(*) AS cnt FROM t1 GROUP BY u ORDER BY cnt", []string{"t1.user_id"}, nil, nil, []string{"t1.user_id"}},
+		{"sub-query", "SELECT id FROM t1 WHERE id IN (SELECT t1_id FROM t2)", []string{"t1.id"}, nil, nil, nil},
+		{"union", "SELECT id FROM t1 UNION SELECT id FROM t2", []string{"t1.id", "t2.id"}, nil, nil, nil},
+		{"insert", "INSERT INTO t1 (id, name) VALUES (?, ?)", nil, []string{"t1.id", "t1.name"}, nil, nil},
+		{"insert without columns", "INSERT INTO t1 VALUES (?, ?)", nil, []string{"t1.*"}, nil, nil},
+		{"insert on duplicate key update", "INSERT INTO t1 (id, name) VALUES (?, ?) ON DUPLICATE KEY UPDATE updated_at = NOW()", nil, []string{"t1.id", "t1.name", "t1.updated_at"}, nil, nil},
+		{"insert select", "INSERT INTO t1 (id) SELECT id FROM t2", []string{"t2.id"}, []string{"t1.id"}, nil, nil},
+		{"update", "UPDATE t1 SET name = ?, score = score + 1 WHERE id = ?", nil, []string{"t1.name", "t1.score"}, nil, nil},
+		{"update join", "UPDATE t1 JOIN t2 AS b ON t1.id = b.t1_id SET b.name = ?", nil, []string{"t2.name"}, nil, nil},
+		{"delete", "DELETE FROM t1 WHERE id = ? ORDER BY id LIMIT 1", nil, []string{"t1.*"}, []string{"t1.id"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parse(tt.sql, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.selects, got.SelectColumns, "SelectColumns")
+			assert.Equal(t, tt.writes, got.WriteColumns, "WriteColumns")
+			assert.Equal(t, tt.orderBy, got.OrderByColumns, "OrderByColumns")
+			assert.Equal(t, tt.groupBy, got.GroupByColumns, "GroupByColumns")
+		})
+	}
+}
+
+func Test_parse_joinColumns(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want []string
+	}{
+		{"no join", "SELECT * FROM t1 WHERE id = ?", nil},
+		{"join", "SELECT * FROM t1 AS a JOIN t2 b ON a.id = b.t1_id", []string{"t1.id", "t2.t1_id"}},
+		{"multiple conditions", "SELECT * FROM t1 JOIN t2 ON t1.id = t2.t1_id AND t2.deleted = 0 AND t2.score > 10", []string{"t1.id", "t2.t1_id", "t2.deleted"}},
+		{"nested join", "SELECT * FROM t1 JOIN t2 ON t1.id = t2.t1_id LEFT JOIN t3 ON t2.id = t3.t2_id", []string{"t1.id", "t2.t1_id", "t2.id", "t3.t2_id"}},
+		{"update join", "UPDATE t1 JOIN t2 AS b ON t1.id = b.t1_id SET b.name = ?", []string{"t1.id", "t2.t1_id"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parse(tt.sql, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got.JoinColumns)
+		})
+	}
+}
@@ -0,0 +1,379 @@
+package sql
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/pingcap/tidb/pkg/parser"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+)
+
+type Dialect int
+
+const (
+	MySQL Dialect = iota
+	Postgres
+	SQLite
+)
+
+var Dialects = []Dialect{MySQL, Postgres, SQLite}
+
+func ParseDialect(s string) (Dialect, error) {
+	switch strings.ToLower(s) {
+	case "", "mysql", "mariadb", "tidb":
+		return MySQL, nil
+	case "postgres", "postgresql", "pg":
+		return Postgres, nil
+	case "sqlite", "sqlite3":
+		return SQLite, nil
+	}
+	return MySQL, errors.Newf("unknown dialect: %s", s)
+}
+
+func (d Dialect) String() string {
+	switch d {
+	case Postgres:
+		return "postgres"
+	case SQLite:
+		return "sqlite"
+	default:
+		return "mysql"
+	}
+}
+
+// Parser parses SQL strings of the dialect into Query.
+// The parser is the one of MySQL (TiDB). Queries of the other dialects are passed through a best-effort compatibility shim,
+// which rewrites the known constructs (postgresShimConstructs and sqliteShimConstructs) into MySQL syntax before parsing,
+// so that the same Query fields (Kind, Tables, MainTable, FilterColumnMap) are filled. It is not a parser of the dialects.
+// The rewriting is done outside the string literals and the comments. If a query uses syntax which the shim does not support
+// (e.g. RETURNING in a subquery, UPDATE ... FROM), it fails to parse and is reported as UNKNOWN rather than with wrong tables.
+// If Schema is given, column ownership is resolved by the table definitions and unknown tables and columns are reported in Query.SchemaErrors.
+type Parser struct {
+	Dialect Dialect
+	Schema  *Schema
+}
+
+func NewParser(dialect Dialect) *Parser {
+	return &Parser{Dialect: dialect}
+}
+
+func (p *Parser) ParseString(str string) (*Query, bool) {
+	raw, rewritten, ok := p.rewrite(str)
+	if !ok {
+		return nil, false
+	}
+	q, err := parse(rewritten, p.Schema)
+	if err != nil {
+		return nil, false
+	}
+	if p.Dialect != MySQL {
+		q.Raw = raw // show the query as written
+	}
+	return q, true
+}
+
+// ParseStmt parses the SQL string into the syntax tree of the first statement.
+// Queries of the dialects other than MySQL are rewritten in the same way as ParseString.
+func (p *Parser) ParseStmt(str string) (ast.StmtNode, bool) {
+	_, rewritten, ok := p.rewrite(str)
+	if !ok {
+		return nil, false
+	}
+	stmtNodes, _, err := parser.New().ParseSQL(rewritten)
+	if err != nil || len(stmtNodes) == 0 {
+		return nil, false
+	}
+	return stmtNodes[0], true
+}
+
+// rewrite returns the normalized query as written and the query rewritten into MySQL compatible syntax by the shim of the dialect.
+// It returns false if the query can not be rewritten.
+func (p *Parser) rewrite(str string) (string, string, bool) {
+	if p.Dialect == MySQL {
+		str = Normalize(str)
+		return str, str, true
+	}
+	code, literals, ok := maskLiterals(str, p.Dialect)
+	if !ok {
+		return "", "", false
+	}
+	code = Normalize(code) // parameters are replaced only outside the literals
+	if p.Dialect == Postgres {
+		code = pgParameterRegexp.ReplaceAllString(code, "?") // e.g. $1
+	}
+	raw := unmaskLiterals(code, literals, func(l *literal) string { return l.raw })
+	code = strings.ReplaceAll(code, `"`, "`") // quoted identifiers
+	switch p.Dialect {
+	case Postgres:
+		code, ok = postgresShim(code, literals)
+	case SQLite:
+		code, ok = sqliteShim(code)
+	}
+	if !ok {
+		return "", "", false
+	}
+	return raw, unmaskLiterals(code, literals, (*literal).mysql), true
+}
+
+var (
+	pgParameterRegexp     = regexp.MustCompile(`\$\d+`)
+	pgIntervalRegexp      = regexp.MustCompile(`(?i)\bINTERVAL\s+'(\d+)'`)
+	pgIntervalValueRegexp = regexp.MustCompile(`(?i)^\s*(\d+)\s*([a-z]+?)s?\s*$`)
+	pgCastRegexp          = regexp.MustCompile("(?i)::\\s*(?:`[^`]+`|[a-z_][\\w.]*)" + `(?:\s+(?:varying|precision|with\s+time\s+zone|without\s+time\s+zone))?(?:\s*\([^)]*\))?(?:\s*\[\])*`)
+	pgILikeRegexp         = regexp.MustCompile(`(?i)\bILIKE\b`)
+	pgAnyRegexp           = regexp.MustCompile(`(?i)(=\s*ANY|<>\s*ALL)\s*\(\s*\?\s*\)`)
+	pgDistinctOnRegexp    = regexp.MustCompile(`(?i)\bDISTINCT\s+ON\s*\([^)]*\)`)
+	sqliteInsertOrRegexp  = regexp.MustCompile(`(?i)^INSERT\s+OR\s+(REPLACE|IGNORE|ABORT|FAIL|ROLLBACK)\b`)
+	sqliteParameterRegexp = regexp.MustCompile(`(?i)(?:[?$]\d+|[@$][a-z_]\w*)`)
+	sqliteGlobRegexp      = regexp.MustCompile(`(?i)\bGLOB\b`)
+	returningRegexp       = regexp.MustCompile(`(?i)\bRETURNING\b`)
+	onConflictRegexp      = regexp.MustCompile(`(?i)\s+ON\s+CONFLICT\b(?:\s*\([^)]*\))?(?:\s+ON\s+CONSTRAINT\s+\S+)?(?:\s+WHERE\s+.*?)?\s+DO\s+(NOTHING|UPDATE\s+SET\s+(.*))$`)
+	onConflictWordRegexp  = regexp.MustCompile(`(?i)\bON\s+CONFLICT\b`)
+	onConflictWhereRegexp = regexp.MustCompile(`(?i)\s+WHERE\s+.*$`)
+	excludedColumnRegexp  = regexp.MustCompile("(?i)\\bEXCLUDED\\.([`\\w]+)")
+	leadingInsertRegexp   = regexp.MustCompile(`(?i)^INSERT\s+INTO\b`)
+	literalRegexp         = regexp.MustCompile(`'(\d+)'`)
+)
+
+// postgresShimConstructs are the PostgreSQL specific constructs which postgresShim (and maskLiterals) rewrites into MySQL syntax.
+// Each of them has a test case of the same name. The other PostgreSQL specific syntax is not supported.
+var postgresShimConstructs = []string{
+	"positional parameter",   // $1 -> ?
+	"quoted identifier",      // "user" -> `user`
+	"escape string",          // E'it\'s'
+	"dollar quoted",          // $$it's$$, $tag$it's$tag$
+	"cast",                   // id::bigint, removed
+	"ilike",                  // ILIKE -> LIKE
+	"any",                    // = ANY($1) -> IN (?), <> ALL($1) -> NOT IN (?)
+	"distinct on",            // DISTINCT ON (a) -> DISTINCT
+	"interval",               // INTERVAL '1 day' -> INTERVAL 1 DAY
+	"returning",              // removed if it is not in a subquery
+	"on conflict do update",  // -> ON DUPLICATE KEY UPDATE
+	"on conflict do nothing", // -> INSERT IGNORE
+}
+
+// postgresShim rewrites the PostgreSQL specific syntax listed in postgresShimConstructs into MySQL compatible one.
+// It is a best-effort compatibility shim of regular expressions, not a parser of PostgreSQL.
+// code is the query whose literals are masked by maskLiterals.
+func postgresShim(code string, literals []*literal) (string, bool) {
+	code = pgIntervalRegexp.ReplaceAllStringFunc(code, func(s string) string {
+		idx, _ := strconv.Atoi(pgIntervalRegexp.FindStringSubmatch(s)[1])
+		m := pgIntervalValueRegexp.FindStringSubmatch(literals[idx].value)
+		if m == nil {
+			return s
+		}
+		return fmt.Sprintf("INTERVAL %s %s", m[1], strings.ToUpper(m[2])) // e.g. INTERVAL '1 day' -> INTERVAL 1 DAY
+	})
+	code = pgCastRegexp.ReplaceAllString(code, "")
+	if strings.Contains(code, "::") {
+		return "", false // casts to the types which are not supported
+	}
+	code = pgILikeRegexp.ReplaceAllString(code, "LIKE")
+	code = pgAnyRegexp.ReplaceAllStringFunc(code, func(s string) string {
+		if strings.HasPrefix(s, "<>") {
+			return "NOT IN (?)"
+		}
+		return "IN (?)"
+	})
+	code = pgDistinctOnRegexp.ReplaceAllString(code, "DISTINCT")
+	return rewriteUpsert(code)
+}
+
+// sqliteShimConstructs are the SQLite specific constructs which sqliteShim rewrites into MySQL syntax.
+// Each of them has a test case of the same name. The other SQLite specific syntax is not supported.
+var sqliteShimConstructs = []string{
+	"numbered parameter", // ?1, $1 -> ?
+	"named parameter",    // @id, $name -> ?
+	"insert or replace",  // -> REPLACE
+	"insert or ignore",   // -> INSERT IGNORE
+	"glob",               // GLOB -> LIKE
+	"returning",          // removed if it is not in a subquery
+	"on conflict",        // -> ON DUPLICATE KEY UPDATE
+}
+
+// sqliteShim rewrites the SQLite specific syntax listed in sqliteShimConstructs into MySQL compatible one.
+// It is a best-effort compatibility shim of regular expressions like postgresShim.
+// code is the query whose literals are masked by maskLiterals.
+func sqliteShim(code string) (string, bool) {
+	code = sqliteParameterRegexp.ReplaceAllString(code, "?")
+	code = sqliteGlobRegexp.ReplaceAllString(code, "LIKE")
+	code = sqliteInsertOrRegexp.ReplaceAllStringFunc(code, func(s string) string {
+		switch strings.ToUpper(sqliteInsertOrRegexp.FindStringSubmatch(s)[1]) {
+		case "REPLACE":
+			return "REPLACE"
+		case "IGNORE":
+			return "INSERT IGNORE"
+		default:
+			return "INSERT"
+		}
+	})
+	return rewriteUpsert(code)
+}
+
+// rewriteUpsert rewrites "ON CONFLICT ... DO UPDATE SET" into "ON DUPLICATE KEY UPDATE" and removes "RETURNING" clause.
+// It returns false if RETURNING is used in a subquery (e.g. data-modifying WITH) or ON CONFLICT clause is not recognized.
+func rewriteUpsert(code string) (string, bool) {
+	code, ok := removeReturning(code)
+	if !ok {
+		return "", false
+	}
+	m := onConflictRegexp.FindStringSubmatchIndex(code)
+	if m == nil {
+		return code, !onConflictWordRegexp.MatchString(code)
+	}
+	action := code[m[2]:m[3]]
+	head := code[:m[0]]
+	if strings.EqualFold(action, "NOTHING") {
+		return leadingInsertRegexp.ReplaceAllString(head, "INSERT IGNORE INTO"), true
+	}
+	set := code[m[4]:m[5]]
+	set = onConflictWhereRegexp.ReplaceAllString(set, "")
+	set = excludedColumnRegexp.ReplaceAllString(set, "VALUES($1)")
+	return head + " ON DUPLICATE KEY UPDATE " + set, true
+}
+
+// removeReturning removes RETURNING clause of the statement. It returns false if RETURNING is in parentheses.
+func removeReturning(code string) (string, bool) {
+	locs := returningRegexp.FindAllStringIndex(code, -1)
+	if len(locs) == 0 {
+		return code, true
+	}
+	for _, loc := range locs {
+		if depth := strings.Count(code[:loc[0]], "(") - strings.Count(code[:loc[0]], ")"); depth != 0 {
+			return "", false
+		}
+	}
+	return strings.TrimSpace(code[:locs[0][0]]), true
+}
+
+// literal is a string literal or a block comment in the query.
+type literal struct {
+	raw     string // as written. e.g. E'it\'s', $$it's$$
+	value   string // the value of the literal. e.g. it's
+	comment bool
+}
+
+// mysql returns the literal in MySQL syntax.
+func (l *literal) mysql() string {
+	if l.comment {
+		return " "
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `''`).Replace(l.value) + "'"
+}
+
+// maskLiterals replaces the string literals and the block comments in the query with numbered placeholders such as '0', and removes the line comments,
+// so that the query can be rewritten without touching the contents of the literals.
+// Quoted identifiers ("name" and `name`) are kept as they are.
+// PostgreSQL's escape strings (E'...') and dollar-quoted strings ($tag$...$tag$) are recognized only in the Postgres dialect.
+// It returns false if a literal, an identifier or a comment is not terminated.
+func maskLiterals(str string, dialect Dialect) (string, []*literal, bool) {
+	var b strings.Builder
+	literals := make([]*literal, 0)
+	mask := func(l *literal) {
+		fmt.Fprintf(&b, "'%d'", len(literals))
+		literals = append(literals, l)
+	}
+	for i := 0; i < len(str); {
+		c := str[i]
+		switch {
+		case c == '\'' || (dialect == Postgres && (c == 'E' || c == 'e') && i+1 < len(str) && str[i+1] == '\'' && !identChar(str, i-1)):
+			start := i
+			escape := c != '\''
+			if escape {
+				i++
+			}
+			var value strings.Builder
+			closed := false
+			for i++; i < len(str); i++ {
+				if escape && str[i] == '\\' && i+1 < len(str) {
+					value.WriteByte(str[i+1])
+					i++
+					continue
+				}
+				if str[i] == '\'' {
+					if i+1 < len(str) && str[i+1] == '\'' {
+						value.WriteByte('\'')
+						i++
+						continue
+					}
+					closed = true
+					i++
+					break
+				}
+				value.WriteByte(str[i])
+			}
+			if !closed {
+				return "", nil, false
+			}
+			mask(&literal{raw: str[start:i], value: value.String()})
+		case dialect == Postgres && c == '$' && !identChar(str, i-1):
+			tag := dollarQuoteTag(str[i:])
+			if tag == "" {
+				b.WriteByte(c) // a positional parameter
+				i++
+				continue
+			}
+			end := strings.Index(str[i+len(tag):], tag)
+			if end < 0 {
+				return "", nil, false
+			}
+			mask(&literal{raw: str[i : i+len(tag)+end+len(tag)], value: str[i+len(tag) : i+len(tag)+end]})
+			i += len(tag) + end + len(tag)
+		case c == '"' || c == '`':
+			end := strings.IndexByte(str[i+1:], c)
+			if end < 0 {
+				return "", nil, false
+			}
+			b.WriteString(str[i : i+end+2])
+			i += end + 2
+		case strings.HasPrefix(str[i:], "--"):
+			end := strings.IndexByte(str[i:], '\n')
+			if end < 0 {
+				end = len(str) - i
+			}
+			b.WriteByte(' ')
+			i += end
+		case strings.HasPrefix(str[i:], "/*"):
+			end := strings.Index(str[i+2:], "*/")
+			if end < 0 {
+				return "", nil, false
+			}
+			mask(&literal{raw: str[i : i+end+4], comment: true})
+			i += end + 4
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String(), literals, true
+}
+
+// unmaskLiterals replaces the placeholders of the literals made by maskLiterals with fn.
+func unmaskLiterals(code string, literals []*literal, fn func(l *literal) string) string {
+	return literalRegexp.ReplaceAllStringFunc(code, func(s string) string {
+		idx, err := strconv.Atoi(s[1 : len(s)-1])
+		if err != nil || idx >= len(literals) {
+			return s
+		}
+		return fn(literals[idx])
+	})
+}
+
+var dollarQuoteRegexp = regexp.MustCompile(`^\$(?:[A-Za-z_][A-Za-z0-9_]*)?\$`)
+
+// dollarQuoteTag returns the opening tag of the dollar-quoted string at the head of s. e.g. "$$", "$body$"
+func dollarQuoteTag(s string) string {
+	return dollarQuoteRegexp.FindString(s)
+}
+
+// identChar returns true if the byte at i is a part of an identifier.
+func identChar(str string, i int) bool {
+	if i < 0 {
+		return false
+	}
+	c := str[i]
+	return c == '_' || c == '$' || ('0' <= c && c <= '9') || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
@@ -0,0 +1,144 @@
+package sql
+
+import (
+	"testing"
+
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDialect(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Dialect
+		wantErr bool
+	}{
+		{"", MySQL, false},
+		{"mysql", MySQL, false},
+		{"postgres", Postgres, false},
+		{"PostgreSQL", Postgres, false},
+		{"sqlite", SQLite, false},
+		{"oracle", MySQL, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDialect(tt.name)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParser_ParseString_postgres(t *testing.T) {
+	tests := []struct {
+		name   string
+		sql    string
+		kind   QueryKind
+		tables []string
+		filter map[string]mapset.Set[string]
+	}{
+		{"positional parameter", "SELECT * FROM users WHERE id = $1", Select, []string{"users"}, map[string]mapset.Set[string]{"users": mapset.NewSet("id")}},
+		{"returning", "INSERT INTO users (name) VALUES ($1) RETURNING id", Insert, []string{"users"}, map[string]mapset.Set[string]{}},
+		{"update returning", "UPDATE users SET name = $1 WHERE id = $2 RETURNING id, name", Update, []string{"users"}, map[string]mapset.Set[string]{"users": mapset.NewSet("id")}},
+		{"ilike", "SELECT * FROM users WHERE name ILIKE $1 AND id = $2", Select, []string{"users"}, map[string]mapset.Set[string]{"users": mapset.NewSet("id")}},
+		{"cast", "SELECT id::text, created_at::timestamp with time zone FROM users WHERE id = $1::bigint", Select, []string{"users"}, map[string]mapset.Set[string]{"users": mapset.NewSet("id")}},
+		{"cast in literal", "SELECT * FROM users WHERE name = 'a::b' AND id = $1", Select, []string{"users"}, map[string]mapset.Set[string]{"users": mapset.NewSet("id", "name")}},
+		{"on conflict do update", "INSERT INTO users (id, name) VALUES ($1, $2) ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name", Insert, []string{"users"}, map[string]mapset.Set[string]{}},
+		{"on conflict do nothing", "INSERT INTO users (id, name) VALUES ($1, $2) ON CONFLICT DO NOTHING", Insert, []string{"users"}, map[string]mapset.Set[string]{}},
+		{"quoted identifier", `SELECT * FROM "user" WHERE "user"."id" = $1`, Select, []string{"user"}, map[string]mapset.Set[string]{"user": mapset.NewSet("id")}},
+		{"any", "SELECT * FROM users WHERE id = ANY($1)", Select, []string{"users"}, map[string]mapset.Set[string]{"users": mapset.NewSet[string]()}},
+		{"distinct on", "SELECT DISTINCT ON (user_id) * FROM posts WHERE user_id = $1 ORDER BY user_id, created_at DESC", Select, []string{"posts"}, map[string]mapset.Set[string]{"posts": mapset.NewSet("user_id")}},
+		{"interval", "DELETE FROM sessions WHERE created_at < NOW() - INTERVAL '1 day'", Delete, []string{"sessions"}, map[string]mapset.Set[string]{"sessions": mapset.NewSet[string]()}},
+		{"escape string", `SELECT * FROM users WHERE name = E'it\'s' AND id = $1`, Select, []string{"users"}, map[string]mapset.Set[string]{"users": mapset.NewSet("id", "name")}},
+		{"dollar quoted", "SELECT * FROM users WHERE bio = $$it's a::b RETURNING$$ AND id = $1", Select, []string{"users"}, map[string]mapset.Set[string]{"users": mapset.NewSet("bio", "id")}},
+		{"tagged dollar quoted", "UPDATE users SET bio = $body$(it's)$body$ WHERE id = $1 RETURNING id", Update, []string{"users"}, map[string]mapset.Set[string]{"users": mapset.NewSet("id")}},
+		{"comment", "SELECT * FROM users /* don't */ WHERE id = $1 -- it's\n", Select, []string{"users"}, map[string]mapset.Set[string]{"users": mapset.NewSet("id")}},
+	}
+	p := NewParser(Postgres)
+	names := make([]string, 0, len(tests))
+	for _, tt := range tests {
+		names = append(names, tt.name)
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := p.ParseString(tt.sql)
+			require.True(t, ok)
+			assert.Equal(t, tt.kind, got.Kind)
+			assert.Equal(t, tt.tables, got.Tables)
+			assert.Equal(t, tt.tables[0], got.MainTable)
+			assert.Equal(t, tt.filter, got.FilterColumnMap)
+			assert.Equal(t, pgParameterRegexp.ReplaceAllString(Normalize(tt.sql), "?"), got.Raw)
+		})
+	}
+	assert.Subset(t, names, postgresShimConstructs, "every supported construct should be tested")
+}
+
+func TestParser_ParseString_postgresRaw(t *testing.T) {
+	got, ok := NewParser(Postgres).ParseString("SELECT * FROM items WHERE label = 'costs $1'  AND id = $1::bigint")
+	require.True(t, ok)
+	assert.Equal(t, "SELECT * FROM items WHERE label = 'costs $1' AND id = ?::bigint", got.Raw)
+}
+
+func TestParser_ParseString_postgresUnsupported(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+	}{
+		{"returning in subquery", "WITH deleted AS (DELETE FROM sessions WHERE user_id = $1 RETURNING id) INSERT INTO audit (session_id) SELECT id FROM deleted"},
+		{"unknown on conflict", "INSERT INTO users (id) VALUES ($1) ON CONFLICT ON CONSTRAINT"},
+		{"unterminated literal", "SELECT * FROM users WHERE name = 'it"},
+		{"unterminated dollar quoted", "SELECT * FROM users WHERE name = $$it"},
+		{"update from", "UPDATE users SET name = n.name FROM new_names n WHERE users.id = n.id"},
+		{"lateral", "SELECT * FROM users u, LATERAL (SELECT * FROM posts p WHERE p.user_id = u.id LIMIT 1) p"},
+		{"array", "SELECT * FROM users WHERE tags @> ARRAY[$1]"},
+		{"filter", "SELECT count(*) FILTER (WHERE done) FROM tasks"},
+		{"regular expression", "SELECT * FROM users WHERE name ~ $1"},
+		{"similar to", "SELECT * FROM users WHERE name SIMILAR TO $1"},
+	}
+	p := NewParser(Postgres)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ok := p.ParseString(tt.sql)
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestParser_ParseString_sqlite(t *testing.T) {
+	tests := []struct {
+		name   string
+		sql    string
+		kind   QueryKind
+		tables []string
+	}{
+		{"insert or replace", "INSERT OR REPLACE INTO users (id, name) VALUES (?1, ?2)", Replace, []string{"users"}},
+		{"insert or ignore", "INSERT OR IGNORE INTO users (id, name) VALUES (?, ?)", Insert, []string{"users"}},
+		{"numbered parameter", "UPDATE users SET name = $1 WHERE id = ?2", Update, []string{"users"}},
+		{"named parameter", "UPDATE users SET name = $name WHERE id = @id", Update, []string{"users"}},
+		{"on conflict", "INSERT INTO users (id, name) VALUES (?, ?) ON CONFLICT(id) DO UPDATE SET name = excluded.name", Insert, []string{"users"}},
+		{"glob", "SELECT * FROM users WHERE name GLOB ?", Select, []string{"users"}},
+		{"returning", "DELETE FROM users WHERE id = ? RETURNING *", Delete, []string{"users"}},
+	}
+	p := NewParser(SQLite)
+	names := make([]string, 0, len(tests))
+	for _, tt := range tests {
+		names = append(names, tt.name)
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := p.ParseString(tt.sql)
+			require.True(t, ok)
+			assert.Equal(t, tt.kind, got.Kind)
+			assert.Equal(t, tt.tables, got.Tables)
+		})
+	}
+	assert.Subset(t, names, sqliteShimConstructs, "every supported construct should be tested")
+}
+
+func TestParser_ParseString_mysql(t *testing.T) {
+	_, ok := NewParser(MySQL).ParseString("SELECT * FROM users WHERE id = ?::bigint")
+	assert.False(t, ok)
+	_, ok = NewParser(Postgres).ParseString("SELECT * FROM users WHERE id = ?::bigint")
+	assert.True(t, ok)
+}
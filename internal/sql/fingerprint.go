@@ -0,0 +1,77 @@
+package sql
+
+import (
+	"strings"
+
+	"github.com/pingcap/tidb/pkg/parser"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/parser/format"
+	"github.com/pingcap/tidb/pkg/parser/test_driver"
+)
+
+// fingerprinter replaces literals with `?`, and collapses IN lists and rows of VALUES into one as pt-query-digest does.
+type fingerprinter struct{}
+
+func (v *fingerprinter) Enter(in ast.Node) (ast.Node, bool) {
+	if agg, ok := in.(*ast.AggregateFuncExpr); ok && strings.EqualFold(agg.F, ast.AggFuncCount) && len(agg.Args) == 1 {
+		if _, ok := agg.Args[0].(*test_driver.ValueExpr); ok {
+			return in, true // COUNT(*) is parsed as COUNT(1)
+		}
+	}
+	return in, false
+}
+
+func (v *fingerprinter) Leave(in ast.Node) (ast.Node, bool) {
+	switch n := in.(type) {
+	case *test_driver.ValueExpr:
+		return &test_driver.ParamMarkerExpr{}, true
+	case *ast.PatternInExpr:
+		if n.Sel == nil && len(n.List) > 1 && allParams(n.List) {
+			n.List = n.List[:1]
+		}
+	case *ast.InsertStmt:
+		if len(n.Lists) > 1 && allRowsParams(n.Lists) {
+			n.Lists = n.Lists[:1]
+		}
+	}
+	return in, true
+}
+
+func allParams(exprs []ast.ExprNode) bool {
+	for _, e := range exprs {
+		if _, ok := e.(*test_driver.ParamMarkerExpr); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func allRowsParams(rows [][]ast.ExprNode) bool {
+	for _, row := range rows {
+		if len(row) != len(rows[0]) || !allParams(row) {
+			return false
+		}
+	}
+	return true
+}
+
+// fingerprint returns the query text restored from the syntax tree without literals, comments and differences of cases and spaces.
+// Note that the literals in stmt are replaced.
+func fingerprint(stmt ast.StmtNode) (string, bool) {
+	stmt.Accept(&fingerprinter{})
+	var sb strings.Builder
+	flags := format.RestoreKeyWordLowercase | format.RestoreNameLowercase | format.RestoreStringSingleQuotes | format.RestoreStringWithoutCharset
+	if err := stmt.Restore(format.NewRestoreCtx(flags, &sb)); err != nil {
+		return "", false
+	}
+	return sb.String(), true
+}
+
+// Fingerprint returns the query text without literals, so that the queries which differ only in literals, the lengths of IN lists, comments, cases and spaces have the same fingerprint.
+// Queries which could not be parsed are normalized by the lexer instead of the syntax tree.
+func (q *Query) Fingerprint() string {
+	if q.fingerprint != "" || q.Raw == "" {
+		return q.fingerprint
+	}
+	return parser.Normalize(q.Raw, "ON")
+}
@@ -0,0 +1,39 @@
+package sql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuery_Fingerprint(t *testing.T) {
+	tests := []struct {
+		name    string
+		queries []string
+		want    string
+	}{
+		{"literals", []string{"SELECT * FROM users WHERE id = 1", "SELECT * FROM users WHERE id = 2", "SELECT * FROM users WHERE id = ?"}, "select * from users where id=?"},
+		{"cases and spaces", []string{"select *\n  FROM Users where ID = 'a'", "SELECT * FROM users WHERE id = 1 /* comment */"}, "select * from users where id=?"},
+		{"in list", []string{"SELECT id FROM users WHERE id IN (1, 2, 3)", "SELECT id FROM users WHERE id IN (?)"}, "select id from users where id in (?)"},
+		{"in list with columns", []string{"SELECT id FROM users WHERE id IN (1, parent_id)"}, "select id from users where id in (?,parent_id)"},
+		{"values", []string{"INSERT INTO users (id, name) VALUES (1, 'a'), (2, 'b')", "INSERT INTO users (id, name) VALUES (?, ?)"}, "insert into users (id,name) values (?,?)"},
+		{"limit", []string{"SELECT * FROM users LIMIT 10 OFFSET 20"}, "select * from users limit ?,?"},
+		{"count", []string{"SELECT COUNT(*) FROM users", "SELECT COUNT(1) FROM users"}, "select count(1) from users"},
+		{"negative", []string{"UPDATE users SET score = score - 1 WHERE score > -1"}, "update users set score=score-? where score>-?"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, s := range tt.queries {
+				q, ok := ParseString(s)
+				require.True(t, ok, s)
+				assert.Equal(t, tt.want, q.Fingerprint(), s)
+			}
+		})
+	}
+}
+
+func TestQuery_Fingerprint_unparsed(t *testing.T) {
+	assert.Equal(t, "select * from `users` where `id` in ( ... )", (&Query{Raw: "SELECT * FROM users WHERE id IN (1, 2)"}).Fingerprint())
+	assert.Equal(t, "", (&Query{Kind: Unknown}).Fingerprint())
+}
@@ -2,6 +2,7 @@ package sql
 
 import (
 	"log/slog"
+	"strings"
 
 	"github.com/cockroachdb/errors"
 	mapset "github.com/deckarep/golang-set/v2"
@@ -85,7 +86,7 @@ func (v *JoinFlatter) Leave(in ast.Node) (ast.Node, bool) {
 	return in, true
 }
 
-func parse(sql string) (*Query, error) {
+func parse(sql string, schema *Schema) (*Query, error) {
 	p := parser.New()
 	stmtNodes, warns, err := p.ParseSQL(sql)
 	if err != nil {
@@ -119,46 +120,60 @@ func parse(sql string) (*Query, error) {
 		}
 	}
 	q.Tables = tableSet // q.Tables[0] == q.MainTable
+	q.SchemaErrors = schema.validate(stmt)
 	switch s := stmt.(type) {
 	case *ast.SelectStmt:
 		q.Kind = Select
-		q.FilterColumnMap = parseStmt(s.From, s.Where, q.Raw)
+		q.FilterColumnMap = parseStmt(s.From, s.Where, q.Raw, schema)
+		if s.LockInfo != nil && s.LockInfo.LockType != ast.SelectLockNone {
+			q.Lock = strings.ToUpper(s.LockInfo.LockType.String())
+		}
+		parseSelectColumns(q, s, schema)
 	case *ast.SetOprStmt:
 		q.Kind = Select
-		q.FilterColumnMap = parseSetOprStmt(s, q.Raw)
+		q.FilterColumnMap = parseSetOprStmt(s, q.Raw, schema)
+		if s.SelectList != nil {
+			parseSetOprColumns(q, s.SelectList, schema)
+		}
 	case *ast.InsertStmt:
 		if s.IsReplace {
 			q.Kind = Replace
 		} else {
 			q.Kind = Insert
 		}
-		q.FilterColumnMap = parseInsertStmt(s, q.Raw)
+		q.FilterColumnMap = parseInsertStmt(s, q.Raw, schema)
+		parseInsertColumns(q, s, schema)
 	case *ast.UpdateStmt:
 		q.Kind = Update
-		q.FilterColumnMap = parseStmt(s.TableRefs, s.Where, q.Raw)
+		q.FilterColumnMap = parseStmt(s.TableRefs, s.Where, q.Raw, schema)
+		parseUpdateColumns(q, s, schema)
 	case *ast.DeleteStmt:
 		q.Kind = Delete
-		q.FilterColumnMap = parseStmt(s.TableRefs, s.Where, q.Raw)
+		q.FilterColumnMap = parseStmt(s.TableRefs, s.Where, q.Raw, schema)
+		parseDeleteColumns(q, s, schema)
 	default:
 		q.Kind = Unknown
 		q.FilterColumnMap = make(map[string]mapset.Set[string])
 	}
+	if fp, ok := fingerprint(stmt); ok {
+		q.fingerprint = fp
+	}
 	return q, nil
 }
 
-func parseSetOprStmt(stmt *ast.SetOprStmt, wholeSQL string) map[string]mapset.Set[string] {
-	return parseSetOprSelectList(stmt.SelectList, wholeSQL)
+func parseSetOprStmt(stmt *ast.SetOprStmt, wholeSQL string, schema *Schema) map[string]mapset.Set[string] {
+	return parseSetOprSelectList(stmt.SelectList, wholeSQL, schema)
 }
 
-func parseSetOprSelectList(stmt *ast.SetOprSelectList, wholeSQL string) map[string]mapset.Set[string] {
+func parseSetOprSelectList(stmt *ast.SetOprSelectList, wholeSQL string, schema *Schema) map[string]mapset.Set[string] {
 	res := util.NewSetMap[string, string]()
 	for _, s := range stmt.Selects {
 		if stmt, ok := s.(*ast.SelectStmt); ok {
-			for k, v := range parseStmt(stmt.From, stmt.Where, wholeSQL) {
+			for k, v := range parseStmt(stmt.From, stmt.Where, wholeSQL, schema) {
 				res.Intersect(k, v)
 			}
 		} else if stmt, ok := s.(*ast.SetOprSelectList); ok {
-			for k, v := range parseSetOprSelectList(stmt, wholeSQL) {
+			for k, v := range parseSetOprSelectList(stmt, wholeSQL, schema) {
 				res.Intersect(k, v)
 			}
 		}
@@ -166,13 +181,13 @@ func parseSetOprSelectList(stmt *ast.SetOprSelectList, wholeSQL string) map[stri
 	return res
 }
 
-func parseInsertStmt(stmt *ast.InsertStmt, wholeSQL string) map[string]mapset.Set[string] {
+func parseInsertStmt(stmt *ast.InsertStmt, wholeSQL string, schema *Schema) map[string]mapset.Set[string] {
 	if stmt.Select != nil {
 		switch s := stmt.Select.(type) {
 		case *ast.SelectStmt:
-			return parseStmt(s.From, s.Where, wholeSQL)
+			return parseStmt(s.From, s.Where, wholeSQL, schema)
 		case *ast.SetOprStmt:
-			return parseSetOprStmt(s, wholeSQL)
+			return parseSetOprStmt(s, wholeSQL, schema)
 			//case *ast.SubqueryExpr:
 			//	return parseStmt(s.Query.(*ast.SelectStmt).From, s.Query.(*ast.SelectStmt).Where, wholeSQL)
 		}
@@ -180,7 +195,7 @@ func parseInsertStmt(stmt *ast.InsertStmt, wholeSQL string) map[string]mapset.Se
 	return make(map[string]mapset.Set[string])
 }
 
-func parseStmt(tableRefs *ast.TableRefsClause, condition ast.ExprNode, wholeSQL string) map[string]mapset.Set[string] {
+func parseStmt(tableRefs *ast.TableRefsClause, condition ast.ExprNode, wholeSQL string, schema *Schema) map[string]mapset.Set[string] {
 	if tableRefs == nil || tableRefs.TableRefs == nil {
 		return make(map[string]mapset.Set[string])
 	}
@@ -212,6 +227,8 @@ func parseStmt(tableRefs *ast.TableRefsClause, condition ast.ExprNode, wholeSQL
 			if tableAlias == "" {
 				if len(jf.tableNames) == 1 && len(jf.selectStmts) == 0 && len(jf.setOprStmts) == 0 {
 					tableAlias = jf.tableNames[0].Source.(*ast.TableName).Name.L
+				} else if a, ok := schema.ownerAlias(col.Name.L, tableAliases); ok {
+					tableAlias = a // resolved by the table definitions
 				} else {
 					ts := make([]string, 0, len(jf.tableNames))
 					for _, t := range jf.tableNames {
@@ -239,12 +256,12 @@ func parseStmt(tableRefs *ast.TableRefsClause, condition ast.ExprNode, wholeSQL
 
 	// Intersect column names with result of sub-queries
 	for _, s := range jf.selectStmts {
-		for k, v := range parseStmt(s.Source.(*ast.SelectStmt).From, s.Source.(*ast.SelectStmt).Where, wholeSQL) {
+		for k, v := range parseStmt(s.Source.(*ast.SelectStmt).From, s.Source.(*ast.SelectStmt).Where, wholeSQL, schema) {
 			res.Intersect(k, v)
 		}
 	}
 	for _, s := range jf.setOprStmts {
-		for k, v := range parseSetOprStmt(s.Source.(*ast.SetOprStmt), wholeSQL) {
+		for k, v := range parseSetOprStmt(s.Source.(*ast.SetOprStmt), wholeSQL, schema) {
 			res.Intersect(k, v)
 		}
 	}
@@ -21,7 +21,7 @@ WHERE
 	AND a2.baz = 'baz'
 `
 
-	got, err := parse(query)
+	got, err := parse(query, nil)
 
 	assert.NotNil(t, got)
 	assert.NoError(t, err)
@@ -44,7 +44,7 @@ func Test_parse_kinds(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := parse(tt.sql)
+			got, err := parse(tt.sql, nil)
 			assert.NotNil(t, got)
 			assert.NoError(t, err)
 			assert.Equal(t, tt.want, got.Kind)
@@ -52,6 +52,32 @@ func Test_parse_kinds(t *testing.T) {
 	}
 }
 
+func Test_parse_lock(t *testing.T) {
+	tests := []struct {
+		name      string
+		sql       string
+		want      string
+		takesLock bool
+	}{
+		{"select", "SELECT * FROM t1 WHERE id = ?", "", false},
+		{"for update", "SELECT * FROM t1 WHERE id = ? FOR UPDATE", "FOR UPDATE", true},
+		{"for share", "SELECT * FROM t1 WHERE id = ? FOR SHARE", "FOR SHARE", true},
+		{"lock in share mode", "SELECT * FROM t1 WHERE id = ? LOCK IN SHARE MODE", "FOR SHARE", true},
+		{"for update nowait", "SELECT * FROM t1 WHERE id = ? FOR UPDATE NOWAIT", "FOR UPDATE NOWAIT", true},
+		{"update", "UPDATE t1 SET name = ? WHERE id = ?", "", true},
+		{"insert", "INSERT INTO t1 (id, name) VALUES (?, ?)", "", true},
+		{"ddl", "CREATE TABLE t1 (id INT)", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parse(tt.sql, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got.Lock)
+			assert.Equal(t, tt.takesLock, got.TakesLock())
+		})
+	}
+}
+
 func Test_parse_error(t *testing.T) {
 	tests := []struct {
 		name string
@@ -62,7 +88,7 @@ func Test_parse_error(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, err := parse(tt.sql)
+			_, err := parse(tt.sql, nil)
 			assert.Error(t, err)
 		})
 	}
@@ -99,7 +125,7 @@ func Test_parseSelectStmt(t *testing.T) {
 			stmt := stmtNodes[0]
 			assert.IsType(t, stmt, &ast.SelectStmt{})
 
-			parsed := parseStmt(stmt.(*ast.SelectStmt).From, stmt.(*ast.SelectStmt).Where, "")
+			parsed := parseStmt(stmt.(*ast.SelectStmt).From, stmt.(*ast.SelectStmt).Where, "", nil)
 			assert.Equal(t, tt.want, parsed)
 		})
 	}
@@ -128,7 +154,7 @@ func Test_parseSetOprStmt(t *testing.T) {
 			stmt := stmtNodes[0]
 			assert.IsType(t, stmt, &ast.SetOprStmt{})
 
-			parsed := parseSetOprStmt(stmt.(*ast.SetOprStmt), "")
+			parsed := parseSetOprStmt(stmt.(*ast.SetOprStmt), "", nil)
 			assert.Equal(t, tt.want, parsed)
 		})
 	}
@@ -162,7 +188,7 @@ func Test_parseInsertStmt(t *testing.T) {
 			stmt := stmtNodes[0]
 			assert.IsType(t, stmt, &ast.InsertStmt{})
 
-			parsed := parseInsertStmt(stmt.(*ast.InsertStmt), "")
+			parsed := parseInsertStmt(stmt.(*ast.InsertStmt), "", nil)
 			assert.Equal(t, tt.want, parsed)
 		})
 	}
@@ -74,6 +74,16 @@ type Query struct {
 	MainTable       string
 	Tables          []string
 	FilterColumnMap map[string]mapset.Set[string]
+	Dynamic         bool
+	Lock            string   // row lock clause of SELECT. e.g. "FOR UPDATE", "FOR SHARE"
+	SelectColumns   []string // columns read by SELECT. e.g. "users.name", "users.*"
+	WriteColumns    []string // columns written by INSERT, UPDATE, REPLACE and DELETE
+	OrderByColumns  []string // columns in ORDER BY clause
+	GroupByColumns  []string // columns in GROUP BY clause
+	JoinColumns     []string // columns compared by equality in ON clauses of joins
+	SchemaErrors    []string // unknown tables and columns if the schema is given
+
+	fingerprint string
 }
 
 func (q *Query) Hash() string {
@@ -82,6 +92,11 @@ func (q *Query) Hash() string {
 	return fmt.Sprintf("%x", h.Sum(nil))[:8]
 }
 
+// TakesLock returns true if the query takes row locks, i.e. locking reads and writes.
+func (q *Query) TakesLock() bool {
+	return q.Lock != "" || (q.Kind != Select && q.Kind != Unknown)
+}
+
 func (q *Query) String() string {
 	ellipsis := q.Raw
 	if len(ellipsis) > 60 {
@@ -166,22 +181,17 @@ func (k QueryKind) ColorAttribute() color.Attribute {
 }
 
 func ParseString(str string) (*Query, bool) {
-	str = Normalize(str)
-	q, err := parse(str)
-	if err != nil {
-		return nil, false
-	}
-	return q, true
+	return NewParser(MySQL).ParseString(str)
 }
 
-var namedParameterRegexp = regexp.MustCompile(`(?i):[a-z_]+`)
+var namedParameterRegexp = regexp.MustCompile(`(?i)(^|[^:]):[a-z_]+`) // not to match type casts of PostgreSQL (e.g. id::text)
 var trailingCommentRegexp = regexp.MustCompile(`(?i)--.*\r?\n`)
 
 func Normalize(str string) string {
-	str = namedParameterRegexp.ReplaceAllString(str, "?")  // replace named parameters with parameter of prepared statement
-	str = trailingCommentRegexp.ReplaceAllString(str, " ") // remove comments and join lines
-	str = strings.ReplaceAll(str, "\t", " ")               // remove tabs
-	str = strings.Join(strings.Fields(str), " ")           // remove duplicate spaces
-	str = strings.TrimSpace(str)                           // remove leading and trailing spaces
+	str = namedParameterRegexp.ReplaceAllString(str, "${1}?") // replace named parameters with parameter of prepared statement
+	str = trailingCommentRegexp.ReplaceAllString(str, " ")    // remove comments and join lines
+	str = strings.ReplaceAll(str, "\t", " ")                  // remove tabs
+	str = strings.Join(strings.Fields(str), " ")              // remove duplicate spaces
+	str = strings.TrimSpace(str)                              // remove leading and trailing spaces
 	return str
 }
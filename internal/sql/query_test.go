@@ -144,7 +144,7 @@ func TestParseString(t *testing.T) {
 		wantOk    bool
 	}{
 		{"empty", "", nil, false},
-		{"SQL", "SELECT * FROM t1 where t1.id = ?", &Query{Kind: Select, Raw: "SELECT * FROM t1 where t1.id = ?", MainTable: "t1", Tables: []string{"t1"}, FilterColumnMap: map[string]mapset.Set[string]{"t1": mapset.NewSet("id")}}, true},
+		{"SQL", "SELECT * FROM t1 where t1.id = ?", &Query{Kind: Select, Raw: "SELECT * FROM t1 where t1.id = ?", MainTable: "t1", Tables: []string{"t1"}, FilterColumnMap: map[string]mapset.Set[string]{"t1": mapset.NewSet("id")}, SelectColumns: []string{"t1.*"}, fingerprint: "select * from t1 where t1.id=?"}, true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -167,6 +167,8 @@ func TestNormalize(t *testing.T) {
 		{"duplicate whitespace", "A   B", "A B"},
 		{"trailing comment", "A -- B\n C", "A C"},
 		{"named parameters", "A = :B", "A = ?"},
+		{"type cast", "A = B::text", "A = B::text"},
+		{"dollar in literal", "A = '$1'", "A = '$1'"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,259 @@
+package sql
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/pingcap/tidb/pkg/parser"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+)
+
+// Schema is the table definitions parsed from CREATE TABLE statements.
+// A nil Schema is valid and means that the table definitions are unknown.
+type Schema struct {
+	tables  map[string][]string // key: table name, value: column names in order of definition
+	indexes map[string][]*Index // key: table name
+}
+
+// Index is an index of a table.
+type Index struct {
+	Name    string
+	Table   string
+	Columns []string
+	Primary bool
+	Unique  bool
+}
+
+// String returns the DDL of the index.
+func (i *Index) String() string {
+	if i.Primary {
+		return fmt.Sprintf("ALTER TABLE %s ADD PRIMARY KEY (%s);", i.Table, strings.Join(i.Columns, ", "))
+	} else if i.Unique {
+		return fmt.Sprintf("CREATE UNIQUE INDEX %s ON %s (%s);", i.Name, i.Table, strings.Join(i.Columns, ", "))
+	}
+	return fmt.Sprintf("CREATE INDEX %s ON %s (%s);", i.Name, i.Table, strings.Join(i.Columns, ", "))
+}
+
+// ParseSchema parses DDL. CREATE TABLE, CREATE INDEX and ALTER TABLE ... ADD INDEX statements are read, and the others are ignored.
+func ParseSchema(ddl string) (*Schema, error) {
+	stmtNodes, _, err := parser.New().ParseSQL(ddl)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse schema")
+	}
+	s := &Schema{tables: make(map[string][]string), indexes: make(map[string][]*Index)}
+	for _, stmt := range stmtNodes {
+		switch t := stmt.(type) {
+		case *ast.CreateTableStmt:
+			table := t.Table.Name.L
+			if t.ReferTable != nil { // CREATE TABLE ... LIKE ...
+				s.tables[table] = s.tables[t.ReferTable.Name.L]
+				for _, idx := range s.indexes[t.ReferTable.Name.L] {
+					s.indexes[table] = append(s.indexes[table], &Index{Name: idx.Name, Table: table, Columns: idx.Columns, Primary: idx.Primary, Unique: idx.Unique})
+				}
+				continue
+			}
+			cols := make([]string, 0, len(t.Cols))
+			for _, col := range t.Cols {
+				cols = append(cols, col.Name.Name.L)
+				for _, opt := range col.Options {
+					switch opt.Tp {
+					case ast.ColumnOptionPrimaryKey:
+						s.addIndex(&Index{Name: "PRIMARY", Table: table, Columns: []string{col.Name.Name.L}, Primary: true, Unique: true})
+					case ast.ColumnOptionUniqKey:
+						s.addIndex(&Index{Name: col.Name.Name.L, Table: table, Columns: []string{col.Name.Name.L}, Unique: true})
+					}
+				}
+			}
+			s.tables[table] = cols
+			for _, c := range t.Constraints {
+				s.addConstraint(table, c)
+			}
+		case *ast.CreateIndexStmt:
+			if t.KeyType != ast.IndexKeyTypeFullText {
+				s.addIndex(&Index{Name: t.IndexName, Table: t.Table.Name.L, Columns: indexColumns(t.IndexPartSpecifications), Unique: t.KeyType == ast.IndexKeyTypeUnique})
+			}
+		case *ast.AlterTableStmt:
+			for _, spec := range t.Specs {
+				if spec.Tp == ast.AlterTableAddConstraint && spec.Constraint != nil {
+					s.addConstraint(t.Table.Name.L, spec.Constraint)
+				}
+			}
+		}
+	}
+	return s, nil
+}
+
+func (s *Schema) addConstraint(table string, c *ast.Constraint) {
+	switch c.Tp {
+	case ast.ConstraintPrimaryKey:
+		s.addIndex(&Index{Name: "PRIMARY", Table: table, Columns: indexColumns(c.Keys), Primary: true, Unique: true})
+	case ast.ConstraintKey, ast.ConstraintIndex:
+		s.addIndex(&Index{Name: c.Name, Table: table, Columns: indexColumns(c.Keys)})
+	case ast.ConstraintUniq, ast.ConstraintUniqKey, ast.ConstraintUniqIndex:
+		s.addIndex(&Index{Name: c.Name, Table: table, Columns: indexColumns(c.Keys), Unique: true})
+	}
+}
+
+func (s *Schema) addIndex(idx *Index) {
+	if len(idx.Columns) > 0 {
+		s.indexes[idx.Table] = append(s.indexes[idx.Table], idx)
+	}
+}
+
+// indexColumns returns the column names of the index. Functional key parts are ignored.
+func indexColumns(keys []*ast.IndexPartSpecification) []string {
+	res := make([]string, 0, len(keys))
+	for _, k := range keys {
+		if k.Column == nil {
+			break
+		}
+		res = append(res, k.Column.Name.L)
+	}
+	return res
+}
+
+// Indexes returns the indexes of the table in order of definition.
+func (s *Schema) Indexes(table string) []*Index {
+	if s == nil {
+		return nil
+	}
+	return s.indexes[strings.ToLower(table)]
+}
+
+// Tables returns the table names in the schema.
+func (s *Schema) Tables() []string {
+	if s == nil {
+		return nil
+	}
+	res := make([]string, 0, len(s.tables))
+	for t := range s.tables {
+		res = append(res, t)
+	}
+	slices.Sort(res)
+	return res
+}
+
+// Columns returns the column names of the table in order of definition.
+func (s *Schema) Columns(table string) ([]string, bool) {
+	if s == nil {
+		return nil, false
+	}
+	cols, ok := s.tables[strings.ToLower(table)]
+	return cols, ok
+}
+
+// ownerAlias returns the alias of the only table which has the column.
+func (s *Schema) ownerAlias(column string, tableAliases map[string]string) (string, bool) {
+	if s == nil {
+		return "", false
+	}
+	owners := make([]string, 0, 1)
+	for alias, table := range tableAliases {
+		if cols, ok := s.Columns(table); ok && slices.Contains(cols, column) {
+			owners = append(owners, alias)
+		}
+	}
+	if len(owners) != 1 {
+		return "", false
+	}
+	return owners[0], true
+}
+
+// validate returns the errors of tables and columns in the statement which are not defined in the schema.
+// Identifiers which contain "?" are skipped since they are holes of query templates.
+func (s *Schema) validate(stmt ast.StmtNode) []string {
+	if s == nil {
+		return nil
+	}
+	v := &schemaX{tableAliases: make(map[string]string)}
+	stmt.Accept(v)
+
+	res := make([]string, 0)
+	known := make([]string, 0, len(v.tables))
+	for _, t := range v.tables {
+		if strings.Contains(t, "?") || slices.Contains(v.ctes, t) {
+			continue
+		} else if _, ok := s.Columns(t); !ok {
+			res = appendUnique(res, fmt.Sprintf("unknown table: %s", t))
+		} else {
+			known = append(known, t)
+		}
+	}
+	if len(known) < len(v.tables) || v.derived || len(v.ctes) > 0 {
+		// columns of unknown tables or derived tables can not be validated
+		known = nil
+	}
+	for _, col := range v.columns {
+		name := col.Name.L
+		if strings.Contains(name, "?") || strings.Contains(col.Table.L, "?") || slices.Contains(v.fieldAliases, name) {
+			continue
+		}
+		if col.Table.L != "" {
+			table, ok := v.tableAliases[col.Table.L]
+			if !ok {
+				continue // alias of derived table
+			}
+			if cols, ok := s.Columns(table); ok && !slices.Contains(cols, name) {
+				res = appendUnique(res, fmt.Sprintf("unknown column: %s.%s", table, name))
+			}
+			continue
+		}
+		if known == nil {
+			continue
+		}
+		if !slices.ContainsFunc(known, func(t string) bool { cols, _ := s.Columns(t); return slices.Contains(cols, name) }) {
+			res = appendUnique(res, fmt.Sprintf("unknown column: %s", name))
+		}
+	}
+	if len(res) == 0 {
+		return nil
+	}
+	return res
+}
+
+type schemaX struct {
+	tables       []string
+	tableAliases map[string]string
+	fieldAliases []string
+	columns      []*ast.ColumnName
+	ctes         []string
+	derived      bool
+}
+
+func (v *schemaX) Enter(in ast.Node) (ast.Node, bool) {
+	switch t := in.(type) {
+	case *ast.TableSource:
+		switch src := t.Source.(type) {
+		case *ast.TableName:
+			name := src.Name.L
+			v.tables = appendUnique(v.tables, name)
+			v.tableAliases[name] = name
+			if t.AsName.L != "" {
+				v.tableAliases[t.AsName.L] = name
+			}
+		case *ast.SelectStmt, *ast.SetOprStmt:
+			v.derived = true
+		}
+	case *ast.TableName:
+		// tables which are not in FROM clause. e.g. INSERT INTO t1
+		v.tables = appendUnique(v.tables, t.Name.L)
+		if _, ok := v.tableAliases[t.Name.L]; !ok {
+			v.tableAliases[t.Name.L] = t.Name.L
+		}
+	case *ast.CommonTableExpression:
+		v.ctes = append(v.ctes, t.Name.L)
+	case *ast.SelectField:
+		if t.AsName.L != "" {
+			v.fieldAliases = append(v.fieldAliases, t.AsName.L)
+		}
+	case *ast.ColumnName:
+		v.columns = append(v.columns, t)
+	}
+	return in, false
+}
+
+func (v *schemaX) Leave(in ast.Node) (ast.Node, bool) {
+	return in, true
+}
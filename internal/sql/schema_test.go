@@ -0,0 +1,115 @@
+package sql
+
+import (
+	"testing"
+
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSchema = `
+CREATE TABLE users (
+  id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  name VARCHAR(255) NOT NULL,
+  created_at DATETIME NOT NULL
+);
+CREATE TABLE posts (
+  id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  user_id BIGINT NOT NULL,
+  title VARCHAR(255) NOT NULL,
+  INDEX idx_user_id (user_id)
+);
+CREATE TABLE archived_posts LIKE posts;
+CREATE INDEX idx_title ON posts (title);
+`
+
+func TestParseSchema(t *testing.T) {
+	s, err := ParseSchema(testSchema)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"archived_posts", "posts", "users"}, s.Tables())
+	cols, ok := s.Columns("users")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"id", "name", "created_at"}, cols)
+	cols, ok = s.Columns("archived_posts")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"id", "user_id", "title"}, cols)
+	_, ok = s.Columns("comments")
+	assert.False(t, ok)
+
+	_, err = ParseSchema("CREATE TABLE")
+	assert.Error(t, err)
+}
+
+func TestSchema_Indexes(t *testing.T) {
+	s, err := ParseSchema(testSchema + `
+CREATE TABLE follows (
+  follower_id BIGINT NOT NULL,
+  followee_id BIGINT NOT NULL,
+  token VARCHAR(255) NOT NULL UNIQUE,
+  bio TEXT NOT NULL,
+  PRIMARY KEY (follower_id, followee_id),
+  FULLTEXT INDEX idx_bio (bio)
+);
+ALTER TABLE follows ADD INDEX idx_followee_id (followee_id);
+CREATE UNIQUE INDEX uniq_name ON users (name);
+`)
+	require.NoError(t, err)
+
+	assert.Equal(t, []*Index{
+		{Name: "PRIMARY", Table: "posts", Columns: []string{"id"}, Primary: true, Unique: true},
+		{Name: "idx_user_id", Table: "posts", Columns: []string{"user_id"}},
+		{Name: "idx_title", Table: "posts", Columns: []string{"title"}},
+	}, s.Indexes("posts"))
+	assert.Equal(t, []*Index{
+		{Name: "PRIMARY", Table: "archived_posts", Columns: []string{"id"}, Primary: true, Unique: true},
+		{Name: "idx_user_id", Table: "archived_posts", Columns: []string{"user_id"}},
+	}, s.Indexes("archived_posts"))
+	assert.Equal(t, []*Index{
+		{Name: "token", Table: "follows", Columns: []string{"token"}, Unique: true},
+		{Name: "PRIMARY", Table: "follows", Columns: []string{"follower_id", "followee_id"}, Primary: true, Unique: true},
+		{Name: "idx_followee_id", Table: "follows", Columns: []string{"followee_id"}},
+	}, s.Indexes("follows"))
+	assert.Equal(t, "CREATE UNIQUE INDEX uniq_name ON users (name);", s.Indexes("users")[1].String())
+	assert.Empty(t, s.Indexes("comments"))
+	assert.Empty(t, (*Schema)(nil).Indexes("users"))
+}
+
+func TestParser_ParseString_schema(t *testing.T) {
+	s, err := ParseSchema(testSchema)
+	require.NoError(t, err)
+	p := &Parser{Dialect: MySQL, Schema: s}
+
+	tests := []struct {
+		name         string
+		sql          string
+		filter       map[string]mapset.Set[string]
+		selects      []string
+		schemaErrors []string
+	}{
+		{"expand wildcard", "SELECT * FROM users WHERE id = ?", map[string]mapset.Set[string]{"users": mapset.NewSet("id")}, []string{"users.id", "users.name", "users.created_at"}, nil},
+		{"expand wildcard of joined table", "SELECT p.*, u.name FROM posts p JOIN users u ON p.user_id = u.id", map[string]mapset.Set[string]{"posts": mapset.NewSet[string](), "users": mapset.NewSet[string]()}, []string{"posts.id", "posts.user_id", "posts.title", "users.name"}, nil},
+		{"resolve ambiguous column", "SELECT title, name FROM posts JOIN users ON posts.user_id = users.id WHERE user_id = ?", map[string]mapset.Set[string]{"posts": mapset.NewSet("user_id"), "users": mapset.NewSet[string]()}, []string{"posts.title", "users.name"}, nil},
+		{"column in both tables", "SELECT id FROM posts JOIN users ON posts.user_id = users.id WHERE id = ?", map[string]mapset.Set[string]{"posts": mapset.NewSet("id"), "users": mapset.NewSet("id")}, []string{"id"}, nil},
+		{"unknown table", "SELECT * FROM comments WHERE id = ?", map[string]mapset.Set[string]{"comments": mapset.NewSet("id")}, []string{"comments.*"}, []string{"unknown table: comments"}},
+		{"unknown column", "SELECT email FROM users WHERE users.age = ?", map[string]mapset.Set[string]{"users": mapset.NewSet("age")}, []string{"users.email"}, []string{"unknown column: email", "unknown column: users.age"}},
+		{"unknown column of insert", "INSERT INTO posts (user_id, body) VALUES (?, ?)", map[string]mapset.Set[string]{}, nil, []string{"unknown column: body"}},
+		{"alias of select field", "SELECT user_id, COUNT(*) AS cnt FROM posts GROUP BY user_id ORDER BY cnt DESC", map[string]mapset.Set[string]{"posts": mapset.NewSet[string]()}, []string{"posts.user_id"}, nil},
+		{"derived table", "SELECT t.id FROM (SELECT id FROM users) AS t WHERE t.id = ?", map[string]mapset.Set[string]{"users": mapset.NewSet[string]()}, []string{"t.id"}, nil},
+		{"template hole", "SELECT * FROM `?` WHERE id = ?", map[string]mapset.Set[string]{"?": mapset.NewSet("id")}, []string{"?.*"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := p.ParseString(tt.sql)
+			require.True(t, ok)
+			assert.Equal(t, tt.filter, got.FilterColumnMap, "FilterColumnMap")
+			assert.Equal(t, tt.selects, got.SelectColumns, "SelectColumns")
+			if len(tt.schemaErrors) == 0 {
+				assert.Empty(t, got.SchemaErrors, "SchemaErrors")
+			} else {
+				assert.Equal(t, tt.schemaErrors, got.SchemaErrors, "SchemaErrors")
+			}
+		})
+	}
+}
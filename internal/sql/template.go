@@ -0,0 +1,89 @@
+package sql
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Holes of a query template. They are the parts of the query which are determined at runtime.
+const (
+	Hole      = "\x00" // e.g. table name, column names, placeholders or conditions
+	ValueHole = "\x01" // e.g. integer
+)
+
+var (
+	listHoleRegexp     = regexp.MustCompile(`(?:\?\s*,?\s*)*[\x00\x01](?:\s*,?\s*\?)*`)
+	wordHoleRegexp     = regexp.MustCompile(`\w*[\x00\x01][\w\x00\x01]*`)
+	identifierKeywords = []string{"FROM", "JOIN", "INTO", "UPDATE", "TABLE"}
+)
+
+// ParseTemplate parses the query template which may have holes.
+// Holes are filled with a parameter "?" or an identifier "`?`" so that the query can be parsed.
+// The returned query is marked as dynamic if the template has holes.
+func (p *Parser) ParseTemplate(tmpl string) (*Query, bool) {
+	if !strings.ContainsAny(tmpl, Hole+ValueHole) {
+		return p.ParseString(tmpl)
+	}
+
+	// e.g. strings.Repeat("?, ", n-1) + "?" -> "?"
+	tmpl = listHoleRegexp.ReplaceAllStringFunc(tmpl, func(s string) string {
+		if strings.Contains(s, Hole) {
+			return Hole
+		}
+		return ValueHole
+	})
+	// e.g. "isu_condition_%d" -> "`isu_condition_?`"
+	tmpl = wordHoleRegexp.ReplaceAllStringFunc(tmpl, func(s string) string {
+		if len(strings.Trim(s, Hole+ValueHole)) == 0 {
+			return s
+		}
+		return "`" + strings.NewReplacer(Hole, "?", ValueHole, "?").Replace(s) + "`"
+	})
+
+	candidates := []string{
+		fillHoles(tmpl, contextualHole),
+		fillHoles(tmpl, func(string, string) string { return "?" }),
+		fillHoles(tmpl, func(string, string) string { return "`?`" }),
+	}
+	for i, c := range candidates {
+		if slices.Contains(candidates[:i], c) {
+			continue
+		}
+		if q, ok := p.ParseString(c); ok {
+			q.Dynamic = true
+			return q, true
+		}
+	}
+	return nil, false
+}
+
+// fillHoles replaces holes with the string returned by fn.
+// ValueHole is always replaced with "?".
+func fillHoles(tmpl string, fn func(before, after string) string) string {
+	var sb strings.Builder
+	for i := 0; i < len(tmpl); i++ {
+		switch tmpl[i : i+1] {
+		case Hole:
+			sb.WriteString(fn(tmpl[:i], tmpl[i+1:]))
+		case ValueHole:
+			sb.WriteString("?")
+		default:
+			sb.WriteByte(tmpl[i])
+		}
+	}
+	return sb.String()
+}
+
+// contextualHole returns an identifier if the hole seems to be a table name, otherwise a parameter.
+func contextualHole(before, after string) string {
+	before = strings.TrimRight(before, " \t\r\n")
+	if strings.HasSuffix(before, ".") || strings.HasPrefix(strings.TrimLeft(after, " \t\r\n"), ".") {
+		return "`?`"
+	}
+	fields := strings.Fields(before)
+	if len(fields) > 0 && slices.Contains(identifierKeywords, strings.ToUpper(fields[len(fields)-1])) {
+		return "`?`"
+	}
+	return "?"
+}
@@ -0,0 +1,43 @@
+package sql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParser_ParseTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		tmpl    string
+		kind    QueryKind
+		tables  []string
+		raw     string
+		dynamic bool
+	}{
+		{"no hole", "SELECT * FROM users WHERE id = ?", Select, []string{"users"}, "SELECT * FROM users WHERE id = ?", false},
+		{"table name", "SELECT * FROM " + Hole + " WHERE id = ?", Select, []string{"?"}, "SELECT * FROM `?` WHERE id = ?", true},
+		{"placeholders", "SELECT * FROM users WHERE id IN (" + Hole + ")", Select, []string{"users"}, "SELECT * FROM users WHERE id IN (?)", true},
+		{"repeated placeholders", "SELECT * FROM users WHERE id IN (" + Hole + "?)", Select, []string{"users"}, "SELECT * FROM users WHERE id IN (?)", true},
+		{"integer", "SELECT * FROM users LIMIT " + ValueHole, Select, []string{"users"}, "SELECT * FROM users LIMIT ?", true},
+		{"sharded table", "INSERT INTO isu_condition_" + ValueHole + " (id) VALUES (?)", Insert, []string{"isu_condition_?"}, "INSERT INTO `isu_condition_?` (id) VALUES (?)", true},
+		{"column list", "INSERT INTO users (" + Hole + ") VALUES (" + Hole + ")", Insert, []string{"users"}, "INSERT INTO users (`?`) VALUES (`?`)", true},
+		{"qualified column", "SELECT " + Hole + ".id FROM users JOIN " + Hole + " ON users.id = " + Hole, Select, []string{"users", "?"}, "SELECT `?`.id FROM users JOIN `?` ON users.id = ?", true},
+		{"condition", "UPDATE users SET name = ? WHERE " + Hole, Update, []string{"users"}, "UPDATE users SET name = ? WHERE ?", true},
+	}
+	p := NewParser(MySQL)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := p.ParseTemplate(tt.tmpl)
+			require.True(t, ok)
+			assert.Equal(t, tt.kind, got.Kind)
+			assert.Equal(t, tt.tables, got.Tables)
+			assert.Equal(t, tt.raw, got.Raw)
+			assert.Equal(t, tt.dynamic, got.Dynamic)
+		})
+	}
+
+	_, ok := p.ParseTemplate(Hole)
+	assert.False(t, ok)
+}
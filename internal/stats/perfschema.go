@@ -0,0 +1,79 @@
+package stats
+
+import (
+	"bufio"
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+)
+
+// ParsePerformanceSchema parses the dump of performance_schema.events_statements_summary_by_digest in TSV (e.g. `mysql -B`) or CSV with the header.
+// QUERY_SAMPLE_TEXT is used to compute the fingerprint if it exists, otherwise DIGEST_TEXT.
+func ParsePerformanceSchema(r io.Reader) (Stats, error) {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(1024)
+	if err != nil && err != io.EOF {
+		return nil, errors.Wrap(err, "failed to read performance_schema dump")
+	}
+	cr := csv.NewReader(br)
+	if firstLine, _, _ := strings.Cut(string(header), "\n"); strings.Contains(firstLine, "\t") {
+		cr.Comma = '\t'
+		cr.LazyQuotes = true
+	}
+	cr.FieldsPerRecord = -1
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read performance_schema dump")
+	}
+	if len(records) == 0 {
+		return make(Stats), nil
+	}
+
+	cols := make(map[string]int)
+	for i, name := range records[0] {
+		cols[strings.ToUpper(strings.TrimSpace(name))] = i
+	}
+	if _, ok := cols["DIGEST_TEXT"]; !ok {
+		return nil, errors.New("DIGEST_TEXT column is not found in performance_schema dump")
+	}
+	get := func(record []string, col string) string {
+		if i, ok := cols[col]; ok && i < len(record) && record[i] != "NULL" && record[i] != `\N` {
+			return record[i]
+		}
+		return ""
+	}
+	num := func(record []string, col string) int64 {
+		n, _ := strconv.ParseInt(get(record, col), 10, 64)
+		return n
+	}
+
+	ss := make(Stats)
+	for _, record := range records[1:] {
+		sample := get(record, "QUERY_SAMPLE_TEXT")
+		if sample == "" {
+			sample = get(record, "DIGEST_TEXT")
+		}
+		if sample == "" {
+			continue // statements without digest, e.g. the row for the overflow of the table
+		}
+		ss.Add(&Stat{
+			Fingerprint:  Fingerprint(sample),
+			Sample:       sample,
+			Count:        num(record, "COUNT_STAR"),
+			TotalTime:    picoseconds(num(record, "SUM_TIMER_WAIT")),
+			MaxTime:      picoseconds(num(record, "MAX_TIMER_WAIT")),
+			RowsSent:     num(record, "SUM_ROWS_SENT"),
+			RowsExamined: num(record, "SUM_ROWS_EXAMINED"),
+		})
+	}
+	return ss, nil
+}
+
+// picoseconds converts the timer of performance_schema into time.Duration.
+func picoseconds(ps int64) time.Duration {
+	return time.Duration(ps / 1000)
+}
@@ -0,0 +1,75 @@
+package stats
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/cockroachdb/errors"
+)
+
+// ptQueryDigest is the report of `pt-query-digest --output json`.
+type ptQueryDigest struct {
+	Classes []struct {
+		Fingerprint string `json:"fingerprint"`
+		QueryCount  int64  `json:"query_count"`
+		Example     *struct {
+			Query string `json:"query"`
+		} `json:"example"`
+		Metrics map[string]ptMetric `json:"metrics"`
+	} `json:"classes"`
+}
+
+type ptMetric struct {
+	Sum ptNumber `json:"sum"`
+	Max ptNumber `json:"max"`
+}
+
+// ptNumber is a number which pt-query-digest writes as a string or a number.
+type ptNumber float64
+
+func (n *ptNumber) UnmarshalJSON(b []byte) error {
+	var v any
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case float64:
+		*n = ptNumber(v)
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return err
+		}
+		*n = ptNumber(f)
+	}
+	return nil
+}
+
+// ParsePtQueryDigest parses the JSON report of pt-query-digest.
+// The fingerprints are computed from the example queries, since those of pt-query-digest differ from Fingerprint.
+func ParsePtQueryDigest(r io.Reader) (Stats, error) {
+	var report ptQueryDigest
+	if err := json.NewDecoder(r).Decode(&report); err != nil {
+		return nil, errors.Wrap(err, "failed to read pt-query-digest report")
+	}
+	ss := make(Stats)
+	for _, c := range report.Classes {
+		sample := c.Fingerprint
+		if c.Example != nil && c.Example.Query != "" {
+			sample = c.Example.Query
+		}
+		queryTime := c.Metrics["Query_time"]
+		ss.Add(&Stat{
+			Fingerprint:  Fingerprint(sample),
+			Sample:       sample,
+			Count:        c.QueryCount,
+			TotalTime:    time.Duration(float64(queryTime.Sum) * float64(time.Second)),
+			MaxTime:      time.Duration(float64(queryTime.Max) * float64(time.Second)),
+			RowsSent:     int64(c.Metrics["Rows_sent"].Sum),
+			RowsExamined: int64(c.Metrics["Rows_examined"].Sum),
+		})
+	}
+	return ss, nil
+}
@@ -0,0 +1,125 @@
+package stats
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+)
+
+// SlowLogEntry is a query in MySQL slow query log.
+type SlowLogEntry struct {
+	QueryTime    time.Duration
+	LockTime     time.Duration
+	RowsSent     int64
+	RowsExamined int64
+	SQL          string
+}
+
+// ParseSlowLog parses MySQL slow query log.
+// `SET timestamp=...;` and `use ...;` statements written before the queries, and administrator commands are skipped.
+func ParseSlowLog(r io.Reader) ([]*SlowLogEntry, error) {
+	entries := make([]*SlowLogEntry, 0)
+	cur := &SlowLogEntry{}
+	var lines []string
+	flush := func() {
+		if len(lines) > 0 {
+			cur.SQL = strings.TrimSpace(strings.Join(lines, "\n"))
+			entries = append(entries, cur)
+		}
+		cur = &SlowLogEntry{}
+		lines = nil
+	}
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case strings.HasPrefix(line, "# Query_time:"):
+			if len(lines) > 0 {
+				flush()
+			}
+			if err := parseSlowLogMetrics(cur, line); err != nil {
+				return nil, err
+			}
+		case strings.HasPrefix(line, "# Time:"), strings.HasPrefix(line, "# User@Host:"):
+			if len(lines) > 0 {
+				flush()
+			}
+		case strings.HasPrefix(line, "#"):
+			// other comments of MySQL and MariaDB (e.g. "# Thread_id:", "# administrator command: Quit;")
+		case len(lines) == 0 && isSlowLogPreamble(line):
+		case isSlowLogHeader(line):
+		default:
+			lines = append(lines, line)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read slow log")
+	}
+	flush()
+	return entries, nil
+}
+
+// parseSlowLogMetrics parses the line like `# Query_time: 0.001234  Lock_time: 0.000010 Rows_sent: 1  Rows_examined: 100`.
+func parseSlowLogMetrics(e *SlowLogEntry, line string) error {
+	fields := strings.Fields(strings.TrimPrefix(line, "#"))
+	for i := 0; i+1 < len(fields); i += 2 {
+		key, value := strings.TrimSuffix(fields[i], ":"), fields[i+1]
+		var err error
+		switch key {
+		case "Query_time":
+			e.QueryTime, err = parseSeconds(value)
+		case "Lock_time":
+			e.LockTime, err = parseSeconds(value)
+		case "Rows_sent":
+			e.RowsSent, err = strconv.ParseInt(value, 10, 64)
+		case "Rows_examined":
+			e.RowsExamined, err = strconv.ParseInt(value, 10, 64)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "invalid %s in slow log: %q", key, line)
+		}
+	}
+	return nil
+}
+
+func parseSeconds(s string) (time.Duration, error) {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(f * float64(time.Second)), nil
+}
+
+// isSlowLogPreamble returns true if the line is the statement which MySQL writes before the query.
+func isSlowLogPreamble(line string) bool {
+	upper := strings.ToUpper(strings.TrimSpace(line))
+	return strings.HasPrefix(upper, "SET TIMESTAMP=") || strings.HasPrefix(upper, "USE ")
+}
+
+// isSlowLogHeader returns true if the line is the header which MySQL writes when the server starts or the log is flushed.
+func isSlowLogHeader(line string) bool {
+	return strings.Contains(line, ", Version: ") || strings.HasPrefix(line, "Tcp port: ") || strings.HasPrefix(line, "Time                 Id Command")
+}
+
+// SlowLogStats aggregates the entries by their fingerprints.
+func SlowLogStats(entries []*SlowLogEntry) Stats {
+	ss := make(Stats)
+	for _, e := range entries {
+		ss.Add(&Stat{
+			Fingerprint:  Fingerprint(e.SQL),
+			Sample:       e.SQL,
+			Count:        1,
+			TotalTime:    e.QueryTime,
+			MaxTime:      e.QueryTime,
+			RowsSent:     e.RowsSent,
+			RowsExamined: e.RowsExamined,
+		})
+	}
+	return ss
+}
@@ -0,0 +1,65 @@
+package stats
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const slowLog = `/usr/sbin/mysqld, Version: 8.0.36 (MySQL Community Server - GPL). started with:
+Tcp port: 3306  Unix socket: /var/run/mysqld/mysqld.sock
+Time                 Id Command    Argument
+# Time: 2024-03-01T12:00:00.000001Z
+# User@Host: isucon[isucon] @ localhost []  Id:    10
+# Query_time: 0.001500  Lock_time: 0.000010 Rows_sent: 1  Rows_examined: 100
+use isucon;
+SET timestamp=1709294400;
+SELECT * FROM users WHERE id = 1;
+# Time: 2024-03-01T12:00:00.000002Z
+# User@Host: isucon[isucon] @ localhost []  Id:    10
+# Query_time: 0.000500  Lock_time: 0.000000 Rows_sent: 1  Rows_examined: 1
+SET timestamp=1709294400;
+select *
+  from users
+ where id = 2;
+# Time: 2024-03-01T12:00:00.000003Z
+# User@Host: isucon[isucon] @ localhost []  Id:    10
+# Query_time: 0.000001  Lock_time: 0.000000 Rows_sent: 0  Rows_examined: 0
+SET timestamp=1709294400;
+# administrator command: Quit;
+# Time: 2024-03-01T12:00:00.000004Z
+# User@Host: isucon[isucon] @ localhost []  Id:    11
+# Query_time: 2.000000  Lock_time: 0.000000 Rows_sent: 0  Rows_examined: 0
+SET timestamp=1709294400;
+INSERT INTO users (name) VALUES ('a'), ('b');
+`
+
+func TestParseSlowLog(t *testing.T) {
+	entries, err := ParseSlowLog(strings.NewReader(slowLog))
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, &SlowLogEntry{QueryTime: 1500 * time.Microsecond, LockTime: 10 * time.Microsecond, RowsSent: 1, RowsExamined: 100, SQL: "SELECT * FROM users WHERE id = 1;"}, entries[0])
+	assert.Equal(t, "select *\n  from users\n where id = 2;", entries[1].SQL)
+	assert.Equal(t, "INSERT INTO users (name) VALUES ('a'), ('b');", entries[2].SQL)
+	assert.Equal(t, 2*time.Second, entries[2].QueryTime)
+}
+
+func TestParseSlowLog_invalid(t *testing.T) {
+	_, err := ParseSlowLog(strings.NewReader("# Query_time: abc  Lock_time: 0.000000\nSELECT 1;\n"))
+	assert.ErrorContains(t, err, "invalid Query_time in slow log")
+}
+
+func TestSlowLogStats(t *testing.T) {
+	entries, err := ParseSlowLog(strings.NewReader(slowLog))
+	require.NoError(t, err)
+
+	ss := SlowLogStats(entries).Sorted()
+	require.Len(t, ss, 2)
+	assert.Equal(t, &Stat{Fingerprint: "insert into users (name) values (?)", Sample: "INSERT INTO users (name) VALUES ('a'), ('b');", Count: 1, TotalTime: 2 * time.Second, MaxTime: 2 * time.Second}, ss[0])
+	assert.Equal(t, &Stat{Fingerprint: "select * from users where id=?", Sample: "SELECT * FROM users WHERE id = 1;", Count: 2, TotalTime: 2 * time.Millisecond, MaxTime: 1500 * time.Microsecond, RowsSent: 2, RowsExamined: 101}, ss[1])
+	assert.Equal(t, time.Millisecond, ss[1].AvgTime())
+	assert.Equal(t, 50.5, ss[1].AvgRowsExamined())
+}
@@ -0,0 +1,137 @@
+package stats
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"io"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/haijima/scone/internal/sql"
+)
+
+// Formats of the inputs
+const (
+	FormatAuto              = "auto"
+	FormatSlowLog           = "slowlog"
+	FormatPtQueryDigest     = "pt-query-digest"
+	FormatPerformanceSchema = "performance-schema"
+)
+
+var Formats = []string{FormatAuto, FormatSlowLog, FormatPtQueryDigest, FormatPerformanceSchema}
+
+// Stat is the runtime statistics of the queries which have the same fingerprint.
+type Stat struct {
+	Fingerprint  string
+	Sample       string // one of the executed queries
+	Count        int64
+	TotalTime    time.Duration
+	MaxTime      time.Duration
+	RowsSent     int64
+	RowsExamined int64
+}
+
+func (s *Stat) AvgTime() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.TotalTime / time.Duration(s.Count)
+}
+
+func (s *Stat) AvgRowsExamined() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+	return float64(s.RowsExamined) / float64(s.Count)
+}
+
+// Merge adds the statistics of other into s.
+func (s *Stat) Merge(other *Stat) {
+	if s.Sample == "" {
+		s.Sample = other.Sample
+	}
+	s.Count += other.Count
+	s.TotalTime += other.TotalTime
+	s.MaxTime = max(s.MaxTime, other.MaxTime)
+	s.RowsSent += other.RowsSent
+	s.RowsExamined += other.RowsExamined
+}
+
+// Stats is the statistics of queries by their fingerprints.
+type Stats map[string]*Stat
+
+// Add merges the stat into the one which has the same fingerprint.
+func (ss Stats) Add(s *Stat) {
+	if t, ok := ss[s.Fingerprint]; ok {
+		t.Merge(s)
+		return
+	}
+	ss[s.Fingerprint] = s
+}
+
+// Sorted returns the statistics in descending order of the total time.
+func (ss Stats) Sorted() []*Stat {
+	sorted := make([]*Stat, 0, len(ss))
+	for _, s := range ss {
+		sorted = append(sorted, s)
+	}
+	slices.SortFunc(sorted, func(a, b *Stat) int {
+		return cmp.Or(cmp.Compare(b.TotalTime, a.TotalTime), cmp.Compare(b.Count, a.Count), cmp.Compare(a.Fingerprint, b.Fingerprint))
+	})
+	return sorted
+}
+
+// collapsedListRegexp matches the lists collapsed by performance_schema "(...)" and pt-query-digest "(?+)".
+var collapsedListRegexp = regexp.MustCompile(`\(\s*(?:\.\.\.|\?\+)\s*\)`)
+
+// Fingerprint returns the fingerprint of the executed query, which is compared with sql.Query.Fingerprint of the queries in the source code.
+// The digest texts of performance_schema and the fingerprints of pt-query-digest are also accepted.
+func Fingerprint(query string) string {
+	query = collapsedListRegexp.ReplaceAllString(query, "(?)")
+	if q, ok := sql.ParseString(query); ok {
+		return q.Fingerprint()
+	}
+	return (&sql.Query{Raw: sql.Normalize(query)}).Fingerprint()
+}
+
+// Get returns the statistics of the query, or nil if the query is not executed.
+func (ss Stats) Get(q *sql.Query) *Stat {
+	if ss == nil || q.Fingerprint() == "" {
+		return nil
+	}
+	return ss[q.Fingerprint()]
+}
+
+// Read reads the statistics in the format. FormatAuto detects the format by the content:
+// a JSON object is a report of pt-query-digest, and a header containing DIGEST_TEXT is a dump of performance_schema.
+func Read(r io.Reader, format string) (Stats, error) {
+	br := bufio.NewReader(r)
+	if format == FormatAuto {
+		head, _ := br.Peek(4096)
+		firstLine, _, _ := bytes.Cut(bytes.TrimSpace(head), []byte("\n"))
+		switch {
+		case bytes.HasPrefix(firstLine, []byte("{")):
+			format = FormatPtQueryDigest
+		case bytes.Contains(bytes.ToUpper(firstLine), []byte("DIGEST_TEXT")):
+			format = FormatPerformanceSchema
+		default:
+			format = FormatSlowLog
+		}
+	}
+	switch format {
+	case FormatSlowLog:
+		entries, err := ParseSlowLog(br)
+		if err != nil {
+			return nil, err
+		}
+		return SlowLogStats(entries), nil
+	case FormatPtQueryDigest:
+		return ParsePtQueryDigest(br)
+	case FormatPerformanceSchema:
+		return ParsePerformanceSchema(br)
+	}
+	return nil, errors.Newf("unknown stats format: %s", format)
+}
@@ -0,0 +1,98 @@
+package stats
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const ptQueryDigestReport = `{
+  "classes": [
+    {
+      "fingerprint": "select * from users where id = ?",
+      "example": {"query": "SELECT * FROM users WHERE id = 1"},
+      "metrics": {
+        "Query_time": {"avg": "0.001000", "max": "0.003000", "sum": "0.100000"},
+        "Rows_examined": {"sum": "100"},
+        "Rows_sent": {"sum": 100}
+      },
+      "query_count": 100
+    },
+    {
+      "fingerprint": "select * from users where id in(?+)",
+      "metrics": {"Query_time": {"sum": "1.5", "max": "0.5"}},
+      "query_count": 3
+    }
+  ],
+  "global": {"query_count": 103}
+}`
+
+func TestParsePtQueryDigest(t *testing.T) {
+	ss, err := ParsePtQueryDigest(strings.NewReader(ptQueryDigestReport))
+	require.NoError(t, err)
+	require.Len(t, ss, 2)
+	assert.Equal(t, &Stat{Fingerprint: "select * from users where id=?", Sample: "SELECT * FROM users WHERE id = 1", Count: 100, TotalTime: 100 * time.Millisecond, MaxTime: 3 * time.Millisecond, RowsSent: 100, RowsExamined: 100}, ss["select * from users where id=?"])
+	// The fingerprint of pt-query-digest is used if the example is not reported
+	assert.Equal(t, int64(3), ss["select * from users where id in (?)"].Count)
+}
+
+func TestParsePerformanceSchema(t *testing.T) {
+	tests := []struct {
+		name string
+		dump string
+	}{
+		{"tsv", "SCHEMA_NAME\tDIGEST_TEXT\tCOUNT_STAR\tSUM_TIMER_WAIT\tMAX_TIMER_WAIT\tSUM_ROWS_SENT\tSUM_ROWS_EXAMINED\tQUERY_SAMPLE_TEXT\n" +
+			"isucon\tSELECT * FROM `users` WHERE `id` IN (...)\t10\t2000000000\t500000000\t20\t30\tNULL\n" +
+			"isucon\tSELECT * FROM `users` WHERE `id` = ?\t5\t1000000000\t300000000\t5\t5\tSELECT * FROM users WHERE id = 1\n" +
+			"NULL\tNULL\t42\t100\t10\t0\t0\tNULL\n"},
+		{"csv", "schema_name,digest_text,count_star,sum_timer_wait,max_timer_wait,sum_rows_sent,sum_rows_examined\n" +
+			"isucon,\"SELECT * FROM `users` WHERE `id` IN (...)\",10,2000000000,500000000,20,30\n" +
+			"isucon,\"SELECT * FROM `users` WHERE `id` = ?\",5,1000000000,300000000,5,5\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ss, err := ParsePerformanceSchema(strings.NewReader(tt.dump))
+			require.NoError(t, err)
+			require.Len(t, ss, 2)
+			assert.Equal(t, int64(10), ss["select * from users where id in (?)"].Count)
+			assert.Equal(t, 2*time.Millisecond, ss["select * from users where id in (?)"].TotalTime)
+			assert.Equal(t, 500*time.Microsecond, ss["select * from users where id in (?)"].MaxTime)
+			assert.Equal(t, int64(30), ss["select * from users where id in (?)"].RowsExamined)
+			assert.Equal(t, int64(5), ss["select * from users where id=?"].Count)
+		})
+	}
+}
+
+func TestParsePerformanceSchema_noDigest(t *testing.T) {
+	_, err := ParsePerformanceSchema(strings.NewReader("a,b\n1,2\n"))
+	assert.EqualError(t, err, "DIGEST_TEXT column is not found in performance_schema dump")
+}
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"slowlog", slowLog, "select * from users where id=?"},
+		{"pt-query-digest", ptQueryDigestReport, "select * from users where id=?"},
+		{"performance-schema", "DIGEST_TEXT,COUNT_STAR\nSELECT * FROM `users` WHERE `id` = ?,3\n", "select * from users where id=?"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ss, err := Read(strings.NewReader(tt.input), FormatAuto)
+			require.NoError(t, err)
+			assert.Contains(t, ss, tt.want)
+
+			ss, err = Read(strings.NewReader(tt.input), tt.name)
+			require.NoError(t, err)
+			assert.Contains(t, ss, tt.want)
+		})
+	}
+
+	_, err := Read(strings.NewReader(""), "unknown")
+	assert.EqualError(t, err, "unknown stats format: unknown")
+}